@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/kochnevns/finances-backend/internal/config"
+	"github.com/kochnevns/finances-backend/internal/storage/sqlite"
+)
+
+// openStorage parses subcommand flags and opens the storage from the
+// config. Positional arguments left after the flags are in the returned set.
+func openStorage(name string, args []string) (*sqlite.Storage, *flag.FlagSet, error) {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+
+	cfg, err := loadConfig(flags, args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	storage, err := sqlite.New(cfg.StoragePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return storage, flags, nil
+}
+
+// loadConfig adds the --config flag to flags, parses args and loads the
+// config it points to.
+func loadConfig(flags *flag.FlagSet, args []string) (*config.Config, error) {
+	configPath := flags.String("config", os.Getenv("CONFIG_PATH"), "path to config file")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	return config.MustLoadPath(*configPath), nil
+}
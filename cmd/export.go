@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kochnevns/finances-backend/db"
+	"github.com/kochnevns/finances-backend/internal/lib/export"
+	"github.com/kochnevns/finances-backend/internal/models"
+	"github.com/kochnevns/finances-backend/internal/storage/sqlite"
+)
+
+const exportUsage = `usage: main export [--config=path] [--user=name] [--from=YYYY-MM-DD] [--to=YYYY-MM-DD]
+                   [--category=name,...] [--format=csv|json|xlsx] [--output=file]
+
+writes the expenses to the file, or to stdout, oldest first. The format defaults
+to the file extension, or csv. CSV exports import back with --profile=export.
+
+Expenses are written with their tags and accounts but without line items,
+sharing or attachments; importing an export back drops tags and accounts too`
+
+// runExport implements the export subcommand.
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	userName := flags.String("user", "", "account to export (default the owner)")
+	fromFlag := flags.String("from", "", "first day to export, inclusive")
+	toFlag := flags.String("to", "", "last day to export, inclusive")
+	categoryFlag := flags.String("category", "", "comma-separated categories to export (default all)")
+	format := flags.String("format", "", "csv, json or xlsx")
+	output := flags.String("output", "", "file to write (default stdout)")
+
+	cfg, err := loadConfig(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() > 0 {
+		return errors.New(exportUsage)
+	}
+
+	filter := models.ExpensesFilter{UserID: models.OwnerID}
+
+	if *fromFlag != "" {
+		if filter.From, err = time.Parse(time.DateOnly, *fromFlag); err != nil {
+			return fmt.Errorf("--from must be in YYYY-MM-DD format\n%s", exportUsage)
+		}
+	}
+	if *toFlag != "" {
+		if filter.To, err = time.Parse(time.DateOnly, *toFlag); err != nil {
+			return fmt.Errorf("--to must be in YYYY-MM-DD format\n%s", exportUsage)
+		}
+	}
+
+	for _, name := range strings.Split(*categoryFlag, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			filter.Categories = append(filter.Categories, name)
+		}
+	}
+
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(*output), ".")
+		if _, ok := export.ContentTypes[*format]; !ok {
+			*format = export.CSV
+		}
+	}
+
+	if _, ok := export.ContentTypes[*format]; !ok {
+		return fmt.Errorf("unknown format %q\n%s", *format, exportUsage)
+	}
+
+	storage, err := sqlite.New(cfg.StoragePath)
+	if err != nil {
+		return err
+	}
+	defer storage.Stop() // nolint: errcheck
+
+	ctx := context.Background()
+
+	if _, err := storage.Migrate(ctx, db.Migrations(), db.Seeds()); err != nil {
+		return err
+	}
+
+	if *userName != "" {
+		user, err := storage.User(ctx, *userName)
+		if err != nil {
+			return err
+		}
+		filter.UserID = user.ID
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close() // nolint: errcheck
+
+		out = file
+	}
+
+	w, err := export.New(out, *format)
+	if err != nil {
+		return err
+	}
+
+	if err := storage.EachExpense(ctx, filter, w.Write); err != nil {
+		return err
+	}
+
+	return w.Close()
+}
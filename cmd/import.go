@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/kochnevns/finances-backend/db"
+	"github.com/kochnevns/finances-backend/internal/lib/categorize"
+	"github.com/kochnevns/finances-backend/internal/lib/export"
+	"github.com/kochnevns/finances-backend/internal/lib/statement"
+	"github.com/kochnevns/finances-backend/internal/models"
+	"github.com/kochnevns/finances-backend/internal/storage"
+	"github.com/kochnevns/finances-backend/internal/storage/sqlite"
+)
+
+const importUsage = `usage: main import <command> [--config=path] [--user=name] [flags] [args]
+
+commands:
+  expenses --profile=name [--dry-run] <file.csv>  import a bank CSV export, skipping recorded expenses;
+                                                  --profile=export reads files of main export
+  profile <profile.json>                          save the column mapping in the file
+  profiles                                        list saved profiles`
+
+// runImport implements the import subcommand.
+func runImport(args []string) error {
+	if len(args) == 0 {
+		return errors.New(importUsage)
+	}
+
+	command := args[0]
+
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	userName := flags.String("user", "", "account to import into (default the owner)")
+	profileName := flags.String("profile", "", "saved profile to read the export with")
+	dryRun := flags.Bool("dry-run", false, "report what would be imported without saving")
+
+	cfg, err := loadConfig(flags, args[1:])
+	if err != nil {
+		return err
+	}
+
+	s, err := sqlite.New(cfg.StoragePath)
+	if err != nil {
+		return err
+	}
+	defer s.Stop() // nolint: errcheck
+
+	ctx := context.Background()
+
+	if _, err := s.Migrate(ctx, db.Migrations(), db.Seeds()); err != nil {
+		return err
+	}
+
+	userID := models.OwnerID
+	if *userName != "" {
+		user, err := s.User(ctx, *userName)
+		if err != nil {
+			return err
+		}
+		userID = user.ID
+	}
+
+	switch command {
+	case "expenses":
+		path := flags.Arg(0)
+		if path == "" || *profileName == "" {
+			return errors.New(importUsage)
+		}
+
+		return importExpenses(ctx, s, userID, path, *profileName, *dryRun)
+	case "profile":
+		path := flags.Arg(0)
+		if path == "" {
+			return errors.New(importUsage)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		var profile models.ImportProfile
+		if err := json.Unmarshal(data, &profile); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		if err := statement.ValidateProfile(profile); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		if err := s.SaveImportProfile(ctx, userID, profile); err != nil {
+			return err
+		}
+
+		fmt.Printf("saved profile %q\n", profile.Name)
+
+		return nil
+	case "profiles":
+		profiles, err := s.ListImportProfiles(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range profiles {
+			fmt.Println(p.Name)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unknown import command %q\n%s", command, importUsage)
+	}
+}
+
+func importExpenses(ctx context.Context, s *sqlite.Storage, userID int64, path, profileName string, dryRun bool) error {
+	profile, err := s.ImportProfile(ctx, userID, profileName)
+	if errors.Is(err, storage.ErrImportProfileNotFound) && profileName == export.Profile.Name {
+		profile, err = export.Profile, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close() // nolint: errcheck
+
+	stored, err := s.ListRules(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	rules, err := categorize.New(stored)
+	if err != nil {
+		return err
+	}
+
+	rows, err := statement.Read(file, profile, rules)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	rows, err = s.ImportExpenses(ctx, userID, rows, dryRun)
+	if err != nil {
+		return err
+	}
+
+	counts := map[string]int{}
+	for _, row := range rows {
+		counts[row.Status]++
+
+		switch row.Status {
+		case models.ImportNew:
+			fmt.Printf("%d\t%s\t%d\t%s\t%s\n", row.Line, row.Expense.Date, row.Expense.Amount, row.Expense.Category, row.Expense.Description)
+		case models.ImportSkipped, models.ImportInvalid:
+			fmt.Printf("%d\t%s: %s\n", row.Line, row.Status, row.Reason)
+		}
+	}
+
+	verb := "imported"
+	if dryRun {
+		verb = "would import"
+	}
+
+	fmt.Printf("%s %d expenses, %d duplicates, %d skipped, %d invalid\n", verb,
+		counts[models.ImportNew], counts[models.ImportDuplicate], counts[models.ImportSkipped], counts[models.ImportInvalid])
+
+	return nil
+}
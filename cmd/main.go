@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
@@ -16,12 +17,33 @@ const (
 	envProd  = "prod"
 )
 
+// subcommands run instead of the server when named as the first argument.
+var subcommands = map[string]func(args []string) error{
+	"migrate": runMigrate,
+	"user":    runUser,
+	"token":   runToken,
+	"rates":   runRates,
+	"import":  runImport,
+	"export":  runExport,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			if err := run(os.Args[2:]); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			return
+		}
+	}
+
 	cfg := config.MustLoad()
 
 	log := setupLogger(cfg.Env)
 
-	application := app.New(log, cfg.GRPC.Port, cfg.HTTP.Port, cfg.StoragePath)
+	application := app.New(log, cfg)
 
 	go func() {
 		application.GRPCServer.MustRun()
@@ -31,6 +53,14 @@ func main() {
 		application.HTTPServer.MustRunHTTP()
 	}()
 
+	go func() {
+		application.Trash.Run()
+	}()
+
+	go func() {
+		application.Recurring.Run()
+	}()
+
 	// Graceful shutdown
 
 	stop := make(chan os.Signal, 1)
@@ -39,6 +69,8 @@ func main() {
 	<-stop
 
 	application.GRPCServer.Stop()
+	application.Trash.Stop()
+	application.Recurring.Stop()
 	log.Info("Gracefully stopped")
 }
 
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kochnevns/finances-backend/db"
+)
+
+const migrateUsage = `usage: main migrate <command> [--config=path]
+
+commands:
+  up      apply pending schema migrations
+  down    roll back the latest schema migration
+  status  list schema migrations
+
+a new database records the seed-data migrations of the historical ledger as
+applied without running them, so it starts with an empty ledger`
+
+// runMigrate implements the migrate subcommand.
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	command := args[0]
+
+	storage, _, err := openStorage("migrate", args[1:])
+	if err != nil {
+		return err
+	}
+	defer storage.Stop() // nolint: errcheck
+
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		applied, err := storage.Migrate(ctx, db.Migrations(), db.Seeds())
+		for _, name := range applied {
+			fmt.Println("applied", name)
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(applied) == 0 {
+			fmt.Println("nothing to apply")
+		}
+
+		return nil
+	case "down":
+		name, err := storage.MigrateDown(ctx, db.Migrations())
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			fmt.Println("nothing to roll back")
+		} else {
+			fmt.Println("rolled back", name)
+		}
+
+		return nil
+	case "status":
+		migrations, err := storage.MigrationsStatus(ctx, db.Migrations())
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			mark := "[ ]"
+			if m.Applied {
+				mark = "[X]"
+			}
+			fmt.Println(mark, m.Name)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", command, migrateUsage)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/kochnevns/finances-backend/db"
+	"github.com/kochnevns/finances-backend/internal/lib/currency"
+)
+
+const ratesUsage = `usage: main rates <command> [--config=path] [args]
+
+commands:
+  import <file.csv>  store "date,currency,rate" records, replacing known ones
+  list [currency]    list known rates`
+
+// runRates implements the rates subcommand.
+func runRates(args []string) error {
+	if len(args) == 0 {
+		return errors.New(ratesUsage)
+	}
+
+	command := args[0]
+
+	storage, flags, err := openStorage("rates", args[1:])
+	if err != nil {
+		return err
+	}
+	defer storage.Stop() // nolint: errcheck
+
+	ctx := context.Background()
+
+	if _, err := storage.Migrate(ctx, db.Migrations(), db.Seeds()); err != nil {
+		return err
+	}
+
+	switch command {
+	case "import":
+		path := flags.Arg(0)
+		if path == "" {
+			return errors.New(ratesUsage)
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close() // nolint: errcheck
+
+		rates, err := currency.ReadRatesCSV(file)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		if err := storage.SaveRates(ctx, rates); err != nil {
+			return err
+		}
+
+		fmt.Printf("imported %d rates\n", len(rates))
+
+		return nil
+	case "list":
+		code := flags.Arg(0)
+		if code != "" {
+			if code, err = currency.Normalize(code); err != nil {
+				return err
+			}
+		}
+
+		rates, err := storage.ListRates(ctx, code)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range rates {
+			fmt.Printf("%s\t%s\t%g\n", r.Date, r.Currency, r.Rate)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unknown rates command %q\n%s", command, ratesUsage)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/kochnevns/finances-backend/internal/lib/jwt"
+	"github.com/kochnevns/finances-backend/internal/storage/sqlite"
+)
+
+const tokenUsage = `usage: main token [--config=path] [--ttl=duration] <user name>
+
+prints a bearer token for the user signed with the AUTH_SECRET environment variable`
+
+// runToken implements the token subcommand.
+func runToken(args []string) error {
+	flags := flag.NewFlagSet("token", flag.ExitOnError)
+	ttl := flags.Duration("ttl", 0, "token lifetime (default auth.token_ttl)")
+
+	cfg, err := loadConfig(flags, args)
+	if err != nil {
+		return err
+	}
+
+	name := flags.Arg(0)
+	if name == "" {
+		return errors.New(tokenUsage)
+	}
+
+	if *ttl == 0 {
+		*ttl = cfg.Auth.TokenTTL
+	}
+
+	storage, err := sqlite.New(cfg.StoragePath)
+	if err != nil {
+		return err
+	}
+	defer storage.Stop() // nolint: errcheck
+
+	user, err := storage.User(context.Background(), name)
+	if err != nil {
+		return err
+	}
+
+	token, err := jwt.NewToken(user, cfg.Auth.Secret, *ttl)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(token)
+
+	return nil
+}
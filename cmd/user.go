@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kochnevns/finances-backend/db"
+)
+
+const userUsage = `usage: main user <command> [--config=path] [name]
+
+commands:
+  add <name>  create an account with an empty ledger
+  list        list accounts`
+
+// runUser implements the user subcommand.
+func runUser(args []string) error {
+	if len(args) == 0 {
+		return errors.New(userUsage)
+	}
+
+	command := args[0]
+
+	storage, flags, err := openStorage("user", args[1:])
+	if err != nil {
+		return err
+	}
+	defer storage.Stop() // nolint: errcheck
+
+	ctx := context.Background()
+
+	if _, err := storage.Migrate(ctx, db.Migrations(), db.Seeds()); err != nil {
+		return err
+	}
+
+	switch command {
+	case "add":
+		name := flags.Arg(0)
+		if name == "" {
+			return errors.New(userUsage)
+		}
+
+		id, err := storage.CreateUser(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("created user %q with id %d\n", name, id)
+
+		return nil
+	case "list":
+		users, err := storage.ListUsers(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, u := range users {
+			fmt.Printf("%d\t%s\t%s\n", u.ID, u.Name, u.CreatedAt)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unknown user command %q\n%s", command, userUsage)
+	}
+}
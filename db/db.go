@@ -0,0 +1,44 @@
+// Package db embeds the dbmate migrations so that the binary can apply them
+// without the dbmate CLI.
+package db
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// seeds are the versions of the migrations that imported the historical
+// ledger of the first deployment.
+var seeds = []string{
+	"20240426164111", // delete_expenses
+	"20240426164205", // insert_expenses
+	"20240426165802", // fix_expenses
+	"20240426171925", // fix_dates
+	"20240426172847", // fix_expenses_onemoretime
+	"20240430184719", // remove_tests
+	"20240522193109", // set_buhanka
+	"20240618210651", // remove_taxi
+}
+
+// Migrations returns the schema migrations.
+func Migrations() fs.FS {
+	return sub(migrations, "migrations")
+}
+
+// Seeds returns the versions of the seed-data migrations. New installs skip
+// them and start with an empty ledger.
+func Seeds() []string {
+	return seeds
+}
+
+func sub(fsys embed.FS, dir string) fs.FS {
+	res, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err) // dir is a constant embedded above
+	}
+
+	return res
+}
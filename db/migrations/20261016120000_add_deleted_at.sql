@@ -0,0 +1,9 @@
+-- migrate:up
+
+ALTER TABLE Expenses ADD deleted_at TEXT;
+CREATE INDEX expenses_deleted_at_idx ON Expenses (deleted_at);
+
+-- migrate:down
+
+DROP INDEX expenses_deleted_at_idx;
+ALTER TABLE Expenses DROP COLUMN deleted_at;
@@ -0,0 +1,9 @@
+-- migrate:up
+
+ALTER TABLE Categories ADD archived_at TEXT;
+CREATE UNIQUE INDEX categories_name_idx ON Categories (name);
+
+-- migrate:down
+
+DROP INDEX categories_name_idx;
+ALTER TABLE Categories DROP COLUMN archived_at;
@@ -0,0 +1,27 @@
+-- migrate:up
+
+CREATE TABLE Users (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	created_at TEXT NOT NULL
+);
+CREATE UNIQUE INDEX users_name_idx ON Users (name);
+
+-- Everything recorded before accounts existed belongs to the owner.
+INSERT INTO Users (id, name, created_at) VALUES (1, 'owner', strftime('%Y-%m-%dT%H:%M:%SZ', 'now'));
+
+ALTER TABLE Expenses ADD user_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE Categories ADD user_id INTEGER NOT NULL DEFAULT 1;
+
+CREATE INDEX expenses_user_date_idx ON Expenses (user_id, date);
+DROP INDEX categories_name_idx;
+CREATE UNIQUE INDEX categories_user_name_idx ON Categories (user_id, name);
+
+-- migrate:down
+
+DROP INDEX categories_user_name_idx;
+CREATE UNIQUE INDEX categories_name_idx ON Categories (name);
+DROP INDEX expenses_user_date_idx;
+ALTER TABLE Categories DROP COLUMN user_id;
+ALTER TABLE Expenses DROP COLUMN user_id;
+DROP TABLE Users;
@@ -0,0 +1,17 @@
+-- migrate:up
+
+-- An empty currency is the base currency from the config.
+ALTER TABLE Expenses ADD currency TEXT NOT NULL DEFAULT '';
+
+-- rate is the price of one unit of currency in the base currency on date.
+CREATE TABLE ExchangeRates (
+	currency TEXT NOT NULL,
+	date TEXT NOT NULL,
+	rate REAL NOT NULL,
+	PRIMARY KEY (currency, date)
+);
+
+-- migrate:down
+
+DROP TABLE ExchangeRates;
+ALTER TABLE Expenses DROP COLUMN currency;
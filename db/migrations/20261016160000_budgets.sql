@@ -0,0 +1,16 @@
+-- migrate:up
+
+-- A budget applies to its month. With rolls_forward it also applies to the
+-- following months until another budget of the category replaces it.
+CREATE TABLE Budgets (
+	user_id INTEGER NOT NULL,
+	category_id INTEGER NOT NULL,
+	month TEXT NOT NULL,
+	amount INTEGER NOT NULL,
+	rolls_forward INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (user_id, category_id, month)
+);
+
+-- migrate:down
+
+DROP TABLE Budgets;
@@ -0,0 +1,31 @@
+-- migrate:up
+
+-- next_date is the first occurrence not materialized yet, NULL once the
+-- rule has ended.
+CREATE TABLE RecurringExpenses (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	description TEXT NOT NULL,
+	amount INTEGER NOT NULL,
+	currency TEXT NOT NULL DEFAULT '',
+	category_id INTEGER NOT NULL,
+	frequency TEXT NOT NULL,
+	interval INTEGER NOT NULL DEFAULT 1,
+	start_date TEXT NOT NULL,
+	end_date TEXT,
+	next_date TEXT,
+	paused INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX recurring_next_date_idx ON RecurringExpenses (next_date);
+
+-- An occurrence becomes at most one expense, whatever happens to next_date.
+ALTER TABLE Expenses ADD recurring_id INTEGER;
+ALTER TABLE Expenses ADD recurring_date TEXT;
+CREATE UNIQUE INDEX expenses_recurring_idx ON Expenses (recurring_id, recurring_date);
+
+-- migrate:down
+
+DROP INDEX expenses_recurring_idx;
+ALTER TABLE Expenses DROP COLUMN recurring_date;
+ALTER TABLE Expenses DROP COLUMN recurring_id;
+DROP TABLE RecurringExpenses;
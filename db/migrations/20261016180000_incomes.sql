@@ -0,0 +1,26 @@
+-- migrate:up
+
+CREATE TABLE IncomeSources (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	color TEXT
+);
+CREATE UNIQUE INDEX income_sources_user_name_idx ON IncomeSources (user_id, name);
+
+-- An empty currency is the base currency from the config, like in Expenses.
+CREATE TABLE Incomes (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	date TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	amount INTEGER NOT NULL,
+	currency TEXT NOT NULL DEFAULT '',
+	source_id INTEGER NOT NULL
+);
+CREATE INDEX incomes_user_date_idx ON Incomes (user_id, date);
+
+-- migrate:down
+
+DROP TABLE Incomes;
+DROP TABLE IncomeSources;
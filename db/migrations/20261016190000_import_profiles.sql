@@ -0,0 +1,13 @@
+-- migrate:up
+
+-- profile is the JSON encoded column mapping of a bank CSV export.
+CREATE TABLE ImportProfiles (
+	user_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	profile TEXT NOT NULL,
+	PRIMARY KEY (user_id, name)
+);
+
+-- migrate:down
+
+DROP TABLE ImportProfiles;
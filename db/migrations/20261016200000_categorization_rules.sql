@@ -0,0 +1,21 @@
+-- migrate:up
+
+-- A rule assigns its category to new expenses without one when all of its
+-- set conditions hold. Empty strings and zeros are unset conditions;
+-- weekdays is a bit mask with bit 0 for Sunday.
+CREATE TABLE CategorizationRules (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	category_id INTEGER NOT NULL,
+	priority INTEGER NOT NULL DEFAULT 0,
+	contains TEXT NOT NULL DEFAULT '',
+	pattern TEXT NOT NULL DEFAULT '',
+	min_amount INTEGER NOT NULL DEFAULT 0,
+	max_amount INTEGER NOT NULL DEFAULT 0,
+	weekdays INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX categorization_rules_user_idx ON CategorizationRules (user_id, priority);
+
+-- migrate:down
+
+DROP TABLE CategorizationRules;
@@ -0,0 +1,21 @@
+-- migrate:up
+
+-- Tags are free-form labels of expenses, lowercase and unique per user.
+CREATE TABLE Tags (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	name TEXT NOT NULL
+);
+CREATE UNIQUE INDEX tags_user_name_idx ON Tags (user_id, name);
+
+CREATE TABLE ExpenseTags (
+	expense_id INTEGER NOT NULL,
+	tag_id INTEGER NOT NULL,
+	PRIMARY KEY (expense_id, tag_id)
+);
+CREATE INDEX expense_tags_tag_idx ON ExpenseTags (tag_id);
+
+-- migrate:down
+
+DROP TABLE ExpenseTags;
+DROP TABLE Tags;
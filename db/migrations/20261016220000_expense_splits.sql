@@ -0,0 +1,15 @@
+-- migrate:up
+
+-- The line items of a split expense, which add up to its amount. Reports
+-- attribute split expenses per line item instead of to their category.
+CREATE TABLE ExpenseSplits (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	expense_id INTEGER NOT NULL,
+	category_id INTEGER NOT NULL,
+	amount INTEGER NOT NULL
+);
+CREATE INDEX expense_splits_expense_idx ON ExpenseSplits (expense_id);
+
+-- migrate:down
+
+DROP TABLE ExpenseSplits;
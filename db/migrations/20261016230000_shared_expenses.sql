@@ -0,0 +1,39 @@
+-- migrate:up
+
+-- Who paid a shared expense and how it is shared. People are names within
+-- the ledger, e.g. household members, not user accounts.
+CREATE TABLE SharedExpenses (
+	expense_id INTEGER NOT NULL PRIMARY KEY,
+	paid_by TEXT NOT NULL,
+	method TEXT NOT NULL
+);
+
+-- The share of each participant in the currency of the expense. percent
+-- is set for shares by percentage; amount is the share either way.
+CREATE TABLE ExpenseShares (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	expense_id INTEGER NOT NULL,
+	person TEXT NOT NULL,
+	percent REAL NOT NULL DEFAULT 0,
+	amount INTEGER NOT NULL
+);
+CREATE INDEX expense_shares_expense_idx ON ExpenseShares (expense_id);
+
+-- Money paid back between people. An empty currency is the base currency.
+CREATE TABLE Settlements (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	date TEXT NOT NULL,
+	from_person TEXT NOT NULL,
+	to_person TEXT NOT NULL,
+	amount INTEGER NOT NULL,
+	currency TEXT NOT NULL DEFAULT '',
+	note TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX settlements_user_date_idx ON Settlements (user_id, date);
+
+-- migrate:down
+
+DROP TABLE Settlements;
+DROP TABLE ExpenseShares;
+DROP TABLE SharedExpenses;
@@ -0,0 +1,20 @@
+-- migrate:up
+
+-- Files attached to expenses, e.g. receipts. The content is stored outside
+-- the database under its SHA-256, shared by attachments of equal content.
+CREATE TABLE Attachments (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	expense_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	content_type TEXT NOT NULL,
+	size INTEGER NOT NULL,
+	hash TEXT NOT NULL,
+	created_at TEXT NOT NULL
+);
+CREATE INDEX attachments_expense_idx ON Attachments (expense_id);
+CREATE INDEX attachments_hash_idx ON Attachments (hash);
+
+-- migrate:down
+
+DROP TABLE Attachments;
@@ -0,0 +1,39 @@
+-- migrate:up
+
+-- Where money is kept: cards, cash, savings. Amounts of an account are in
+-- its currency; the balance starts at opening_balance on opened_on.
+CREATE TABLE Accounts (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	currency TEXT NOT NULL,
+	opening_balance INTEGER NOT NULL DEFAULT 0,
+	opened_on TEXT NOT NULL
+);
+CREATE UNIQUE INDEX accounts_user_name_idx ON Accounts (user_id, name);
+
+-- The account an expense is paid from or an income is paid to, if known.
+ALTER TABLE Expenses ADD account_id INTEGER;
+ALTER TABLE Incomes ADD account_id INTEGER;
+
+-- Money moved between accounts of the user, which is neither spent nor
+-- earned. amount leaves the first account and to_amount, in the currency
+-- of the second one, arrives.
+CREATE TABLE Transfers (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	date TEXT NOT NULL,
+	from_account_id INTEGER NOT NULL,
+	to_account_id INTEGER NOT NULL,
+	amount INTEGER NOT NULL,
+	to_amount INTEGER NOT NULL,
+	description TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX transfers_user_date_idx ON Transfers (user_id, date);
+
+-- migrate:down
+
+DROP TABLE Transfers;
+ALTER TABLE Incomes DROP COLUMN account_id;
+ALTER TABLE Expenses DROP COLUMN account_id;
+DROP TABLE Accounts;
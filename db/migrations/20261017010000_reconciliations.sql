@@ -0,0 +1,26 @@
+-- migrate:up
+
+-- Whether an expense, income or transfer has shown up on a bank statement.
+-- A transfer is cleared once for both of its accounts.
+ALTER TABLE Expenses ADD cleared INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE Incomes ADD cleared INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE Transfers ADD cleared INTEGER NOT NULL DEFAULT 0;
+
+-- A check of an account against the balance of a statement on a date. It
+-- is finished once the cleared balance matches the statement.
+CREATE TABLE Reconciliations (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	account_id INTEGER NOT NULL,
+	date TEXT NOT NULL,
+	statement_balance INTEGER NOT NULL,
+	finished_at TEXT
+);
+CREATE INDEX reconciliations_account_idx ON Reconciliations (account_id);
+
+-- migrate:down
+
+DROP TABLE Reconciliations;
+ALTER TABLE Transfers DROP COLUMN cleared;
+ALTER TABLE Incomes DROP COLUMN cleared;
+ALTER TABLE Expenses DROP COLUMN cleared;
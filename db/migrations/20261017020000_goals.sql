@@ -0,0 +1,30 @@
+-- migrate:up
+
+-- Amounts the user saves up for, in the currency of the goal: that of the
+-- account the savings are kept in when one is linked.
+CREATE TABLE Goals (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	target INTEGER NOT NULL,
+	currency TEXT NOT NULL,
+	deadline TEXT,
+	account_id INTEGER,
+	created_on TEXT NOT NULL
+);
+CREATE UNIQUE INDEX goals_user_name_idx ON Goals (user_id, name);
+
+-- Money put towards a goal, negative when taken back.
+CREATE TABLE GoalContributions (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	goal_id INTEGER NOT NULL,
+	date TEXT NOT NULL,
+	amount INTEGER NOT NULL,
+	note TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX goal_contributions_goal_idx ON GoalContributions (goal_id, date);
+
+-- migrate:down
+
+DROP TABLE GoalContributions;
+DROP TABLE Goals;
@@ -0,0 +1,12 @@
+-- migrate:up
+
+-- The balances of the account as of the date of the reconciliation, kept
+-- when it is finished so that later edits do not change them. NULL while
+-- in progress and for reconciliations finished before they were kept.
+ALTER TABLE Reconciliations ADD balance INTEGER;
+ALTER TABLE Reconciliations ADD cleared_balance INTEGER;
+
+-- migrate:down
+
+ALTER TABLE Reconciliations DROP COLUMN cleared_balance;
+ALTER TABLE Reconciliations DROP COLUMN balance;
@@ -3,7 +3,7 @@ CREATE TABLE Categories (
 	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
 	name TEXT NOT NULL,
 	icon TEXT
-, color TEXT);
+, color TEXT, archived_at TEXT, user_id INTEGER NOT NULL DEFAULT 1);
 CREATE TABLE IF NOT EXISTS "Expenses"
 (
     id          INTEGER not null
@@ -12,7 +12,184 @@ CREATE TABLE IF NOT EXISTS "Expenses"
     description TEXT,
     amount      INTEGER,
     category_id INTEGER
+, deleted_at TEXT, user_id INTEGER NOT NULL DEFAULT 1, currency TEXT NOT NULL DEFAULT '', recurring_id INTEGER, recurring_date TEXT, account_id INTEGER, cleared INTEGER NOT NULL DEFAULT 0);
+CREATE INDEX expenses_deleted_at_idx ON Expenses (deleted_at);
+CREATE TABLE Users (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	created_at TEXT NOT NULL
+);
+CREATE UNIQUE INDEX users_name_idx ON Users (name);
+CREATE INDEX expenses_user_date_idx ON Expenses (user_id, date);
+CREATE UNIQUE INDEX categories_user_name_idx ON Categories (user_id, name);
+CREATE TABLE ExchangeRates (
+	currency TEXT NOT NULL,
+	date TEXT NOT NULL,
+	rate REAL NOT NULL,
+	PRIMARY KEY (currency, date)
+);
+CREATE TABLE Budgets (
+	user_id INTEGER NOT NULL,
+	category_id INTEGER NOT NULL,
+	month TEXT NOT NULL,
+	amount INTEGER NOT NULL,
+	rolls_forward INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (user_id, category_id, month)
+);
+CREATE TABLE RecurringExpenses (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	description TEXT NOT NULL,
+	amount INTEGER NOT NULL,
+	currency TEXT NOT NULL DEFAULT '',
+	category_id INTEGER NOT NULL,
+	frequency TEXT NOT NULL,
+	interval INTEGER NOT NULL DEFAULT 1,
+	start_date TEXT NOT NULL,
+	end_date TEXT,
+	next_date TEXT,
+	paused INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX recurring_next_date_idx ON RecurringExpenses (next_date);
+CREATE UNIQUE INDEX expenses_recurring_idx ON Expenses (recurring_id, recurring_date);
+CREATE TABLE IncomeSources (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	color TEXT
+);
+CREATE UNIQUE INDEX income_sources_user_name_idx ON IncomeSources (user_id, name);
+CREATE TABLE Incomes (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	date TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	amount INTEGER NOT NULL,
+	currency TEXT NOT NULL DEFAULT '',
+	source_id INTEGER NOT NULL
+, account_id INTEGER, cleared INTEGER NOT NULL DEFAULT 0);
+CREATE INDEX incomes_user_date_idx ON Incomes (user_id, date);
+CREATE TABLE ImportProfiles (
+	user_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	profile TEXT NOT NULL,
+	PRIMARY KEY (user_id, name)
+);
+CREATE TABLE CategorizationRules (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	category_id INTEGER NOT NULL,
+	priority INTEGER NOT NULL DEFAULT 0,
+	contains TEXT NOT NULL DEFAULT '',
+	pattern TEXT NOT NULL DEFAULT '',
+	min_amount INTEGER NOT NULL DEFAULT 0,
+	max_amount INTEGER NOT NULL DEFAULT 0,
+	weekdays INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX categorization_rules_user_idx ON CategorizationRules (user_id, priority);
+CREATE TABLE Tags (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	name TEXT NOT NULL
+);
+CREATE UNIQUE INDEX tags_user_name_idx ON Tags (user_id, name);
+CREATE TABLE ExpenseTags (
+	expense_id INTEGER NOT NULL,
+	tag_id INTEGER NOT NULL,
+	PRIMARY KEY (expense_id, tag_id)
+);
+CREATE INDEX expense_tags_tag_idx ON ExpenseTags (tag_id);
+CREATE TABLE ExpenseSplits (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	expense_id INTEGER NOT NULL,
+	category_id INTEGER NOT NULL,
+	amount INTEGER NOT NULL
+);
+CREATE INDEX expense_splits_expense_idx ON ExpenseSplits (expense_id);
+CREATE TABLE SharedExpenses (
+	expense_id INTEGER NOT NULL PRIMARY KEY,
+	paid_by TEXT NOT NULL,
+	method TEXT NOT NULL
+);
+CREATE TABLE ExpenseShares (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	expense_id INTEGER NOT NULL,
+	person TEXT NOT NULL,
+	percent REAL NOT NULL DEFAULT 0,
+	amount INTEGER NOT NULL
+);
+CREATE INDEX expense_shares_expense_idx ON ExpenseShares (expense_id);
+CREATE TABLE Settlements (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	date TEXT NOT NULL,
+	from_person TEXT NOT NULL,
+	to_person TEXT NOT NULL,
+	amount INTEGER NOT NULL,
+	currency TEXT NOT NULL DEFAULT '',
+	note TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX settlements_user_date_idx ON Settlements (user_id, date);
+CREATE TABLE Attachments (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	expense_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	content_type TEXT NOT NULL,
+	size INTEGER NOT NULL,
+	hash TEXT NOT NULL,
+	created_at TEXT NOT NULL
+);
+CREATE INDEX attachments_expense_idx ON Attachments (expense_id);
+CREATE INDEX attachments_hash_idx ON Attachments (hash);
+CREATE TABLE Accounts (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	currency TEXT NOT NULL,
+	opening_balance INTEGER NOT NULL DEFAULT 0,
+	opened_on TEXT NOT NULL
+);
+CREATE UNIQUE INDEX accounts_user_name_idx ON Accounts (user_id, name);
+CREATE TABLE Transfers (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	date TEXT NOT NULL,
+	from_account_id INTEGER NOT NULL,
+	to_account_id INTEGER NOT NULL,
+	amount INTEGER NOT NULL,
+	to_amount INTEGER NOT NULL,
+	description TEXT NOT NULL DEFAULT ''
+, cleared INTEGER NOT NULL DEFAULT 0);
+CREATE INDEX transfers_user_date_idx ON Transfers (user_id, date);
+CREATE TABLE Reconciliations (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	account_id INTEGER NOT NULL,
+	date TEXT NOT NULL,
+	statement_balance INTEGER NOT NULL,
+	finished_at TEXT
+, balance INTEGER, cleared_balance INTEGER);
+CREATE INDEX reconciliations_account_idx ON Reconciliations (account_id);
+CREATE TABLE Goals (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	target INTEGER NOT NULL,
+	currency TEXT NOT NULL,
+	deadline TEXT,
+	account_id INTEGER,
+	created_on TEXT NOT NULL
+);
+CREATE UNIQUE INDEX goals_user_name_idx ON Goals (user_id, name);
+CREATE TABLE GoalContributions (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	goal_id INTEGER NOT NULL,
+	date TEXT NOT NULL,
+	amount INTEGER NOT NULL,
+	note TEXT NOT NULL DEFAULT ''
 );
+CREATE INDEX goal_contributions_goal_idx ON GoalContributions (goal_id, date);
 -- Dbmate schema migrations
 INSERT INTO "schema_migrations" (version) VALUES
   ('20240424201629'),
@@ -25,4 +202,21 @@ INSERT INTO "schema_migrations" (version) VALUES
   ('20240426172847'),
   ('20240430184719'),
   ('20240504182624'),
-  ('20240504182746');
+  ('20240504182746'),
+  ('20261016120000'),
+  ('20261016130000'),
+  ('20261016140000'),
+  ('20261016150000'),
+  ('20261016160000'),
+  ('20261016170000'),
+  ('20261016180000'),
+  ('20261016190000'),
+  ('20261016200000'),
+  ('20261016210000'),
+  ('20261016220000'),
+  ('20261016230000'),
+  ('20261016235000'),
+  ('20261017000000'),
+  ('20261017010000'),
+  ('20261017020000'),
+  ('20261017030000');
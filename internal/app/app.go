@@ -1,40 +1,76 @@
 package app
 
 import (
+	"context"
 	"log/slog"
 
+	"github.com/kochnevns/finances-backend/db"
+
 	grpcapp "github.com/kochnevns/finances-backend/internal/app/grpc"
 	httpapp "github.com/kochnevns/finances-backend/internal/app/http"
+	recurringapp "github.com/kochnevns/finances-backend/internal/app/recurring"
+	trashapp "github.com/kochnevns/finances-backend/internal/app/trash"
+	"github.com/kochnevns/finances-backend/internal/config"
+	authgrpc "github.com/kochnevns/finances-backend/internal/grpc/auth"
 	"github.com/kochnevns/finances-backend/internal/imcache"
+	"github.com/kochnevns/finances-backend/internal/lib/currency"
 	"github.com/kochnevns/finances-backend/internal/services/finances"
+	"github.com/kochnevns/finances-backend/internal/storage/blobs"
 	"github.com/kochnevns/finances-backend/internal/storage/sqlite"
 )
 
 type App struct {
 	GRPCServer *grpcapp.App
 	HTTPServer *httpapp.App
+	Trash      *trashapp.App
+	Recurring  *recurringapp.App
 }
 
 func New(
 	log *slog.Logger,
-	grpcPort int,
-	httpPort int,
-	storagePath string,
+	cfg *config.Config,
 ) *App {
-	storage, err := sqlite.New(storagePath)
+	storage, err := sqlite.New(cfg.StoragePath)
+	if err != nil {
+		panic(err)
+	}
+
+	applied, err := storage.Migrate(context.Background(), db.Migrations(), db.Seeds())
+	if err != nil {
+		panic(err)
+	}
+
+	for _, name := range applied {
+		log.Info("migration applied", slog.String("name", name))
+	}
+
+	baseCurrency, err := currency.Normalize(cfg.BaseCurrency)
 	if err != nil {
 		panic(err)
 	}
 
 	imcache := imcache.NewIMCache()
 
-	financesService := finances.New(log, storage, storage, storage, storage, storage, imcache)
+	blobStore, err := blobs.New(cfg.Attachments.Dir)
+	if err != nil {
+		panic(err)
+	}
+
+	attachmentLimits := finances.AttachmentLimits{MaxSize: cfg.Attachments.MaxSize, Types: cfg.Attachments.Types}
+
+	financesService := finances.New(log, storage, storage, storage, storage, storage, storage, storage, storage, storage, storage, storage, storage, storage, storage, storage, storage, storage, storage, storage, imcache, cfg.Trash.Retention, blobStore, attachmentLimits, baseCurrency)
+
+	auth := authgrpc.New(cfg.Auth.Secret)
 
-	grpcApp := grpcapp.New(log, financesService, grpcPort)
-	httpApp := httpapp.New(httpPort, grpcPort, log)
+	grpcApp := grpcapp.New(log, financesService, auth, cfg.GRPC.Port)
+	httpApp := httpapp.New(cfg.HTTP.Port, cfg.GRPC.Port, log, financesService, auth)
+	trashApp := trashapp.New(log, financesService, cfg.Trash.PurgeInterval)
+	recurringApp := recurringapp.New(log, financesService, cfg.Recurring.Interval)
 
 	return &App{
 		GRPCServer: grpcApp,
 		HTTPServer: httpApp,
+		Trash:      trashApp,
+		Recurring:  recurringApp,
 	}
 }
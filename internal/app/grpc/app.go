@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"net"
 
+	authgrpc "github.com/kochnevns/finances-backend/internal/grpc/auth"
 	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
 
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
@@ -13,7 +14,6 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
-	//authgrpc "github.com/kochnevns/finanses-auth/internal/grpc/auth"
 )
 
 type App struct {
@@ -26,6 +26,7 @@ type App struct {
 func New(
 	log *slog.Logger,
 	financesService financesgrpc.Finances,
+	auth *authgrpc.Authenticator,
 	port int,
 ) *App {
 	loggingOpts := []logging.Option{
@@ -56,6 +57,11 @@ func New(
 	gRPCServer := grpc.NewServer(grpc.ChainUnaryInterceptor(
 		recovery.UnaryServerInterceptor(recoveryOpts...),
 		logging.UnaryServerInterceptor(InterceptorLogger(log), loggingOpts...),
+		auth.UnaryServerInterceptor(),
+	), grpc.ChainStreamInterceptor(
+		recovery.StreamServerInterceptor(recoveryOpts...),
+		logging.StreamServerInterceptor(InterceptorLogger(log), loggingOpts...),
+		auth.StreamServerInterceptor(),
 	))
 
 	financesgrpc.Register(gRPCServer, financesService)
@@ -12,6 +12,9 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 
+	authgrpc "github.com/kochnevns/finances-backend/internal/grpc/auth"
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+	financeshttp "github.com/kochnevns/finances-backend/internal/http/finances"
 	gw "github.com/kochnevns/finances-protos/finances" // import proto files for gateway to work
 )
 
@@ -19,10 +22,12 @@ type App struct {
 	port     int
 	grpcPort int // gRPC server port
 	log      *slog.Logger
+	finances financesgrpc.Finances // serves routes missing from the gRPC contract
+	auth     *authgrpc.Authenticator
 } // App
 
-func New(port int, grpcPort int, log *slog.Logger) *App {
-	return &App{port: port, grpcPort: grpcPort, log: log}
+func New(port int, grpcPort int, log *slog.Logger, finances financesgrpc.Finances, auth *authgrpc.Authenticator) *App {
+	return &App{port: port, grpcPort: grpcPort, log: log, finances: finances, auth: auth}
 }
 
 func (a *App) MustRunHTTP() {
@@ -57,6 +62,10 @@ func (a *App) RunHTTP() error {
 		return err
 	}
 
+	if err := financeshttp.Register(mux, a.finances, a.auth); err != nil {
+		return err
+	}
+
 	a.log.Info("Starting HTTP server", slog.String("port", strconv.Itoa(a.port))) // log
 
 	if err := http.ListenAndServe(fmt.Sprintf(":%d", a.port), h); err != nil {
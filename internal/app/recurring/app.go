@@ -0,0 +1,74 @@
+package recurringapp
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/kochnevns/finances-backend/internal/logger/sl"
+)
+
+type Materializer interface {
+	RunRecurring(ctx context.Context) (int64, error)
+}
+
+// App periodically turns due occurrences of recurring expenses into expenses.
+type App struct {
+	log          *slog.Logger
+	materializer Materializer
+	interval     time.Duration
+	stop         chan struct{}
+	done         chan struct{}
+}
+
+// New creates new recurring expenses scheduler app.
+func New(log *slog.Logger, materializer Materializer, interval time.Duration) *App {
+	return &App{
+		log:          log,
+		materializer: materializer,
+		interval:     interval,
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+}
+
+// Run materializes due expenses once, catching up on the ones missed while
+// the server was down, and then on every interval until Stop is called.
+func (a *App) Run() {
+	const op = "recurringapp.Run"
+
+	log := a.log.With(slog.String("op", op))
+
+	defer close(a.done)
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	log.Info("recurring expenses scheduler started", slog.Duration("interval", a.interval))
+
+	for {
+		saved, err := a.materializer.RunRecurring(context.Background())
+		if err != nil {
+			log.Error("failed to materialize recurring expenses", sl.Err(err))
+		}
+		if saved > 0 {
+			log.Info("recurring expenses materialized", slog.Int64("expenses", saved))
+		}
+
+		select {
+		case <-a.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop stops the scheduler and waits for the current run to finish.
+func (a *App) Stop() {
+	const op = "recurringapp.Stop"
+
+	a.log.With(slog.String("op", op)).Info("stopping recurring expenses scheduler")
+
+	close(a.stop)
+	<-a.done
+}
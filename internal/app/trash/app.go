@@ -0,0 +1,72 @@
+package trashapp
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/kochnevns/finances-backend/internal/logger/sl"
+)
+
+type Purger interface {
+	PurgeTrash(ctx context.Context) (int64, error)
+}
+
+// App periodically purges expenses whose trash retention period is over.
+type App struct {
+	log      *slog.Logger
+	purger   Purger
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// New creates new trash purging app.
+func New(log *slog.Logger, purger Purger, interval time.Duration) *App {
+	return &App{
+		log:      log,
+		purger:   purger,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Run purges the trash once and then on every interval until Stop is called.
+func (a *App) Run() {
+	const op = "trashapp.Run"
+
+	log := a.log.With(slog.String("op", op))
+
+	defer close(a.done)
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	log.Info("trash purger started", slog.Duration("interval", a.interval))
+
+	for {
+		purged, err := a.purger.PurgeTrash(context.Background())
+		if err != nil {
+			log.Error("failed to purge trash", sl.Err(err))
+		} else if purged > 0 {
+			log.Info("trash purged", slog.Int64("expenses", purged))
+		}
+
+		select {
+		case <-a.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop stops the purger and waits for the current run to finish.
+func (a *App) Stop() {
+	const op = "trashapp.Stop"
+
+	a.log.With(slog.String("op", op)).Info("stopping trash purger")
+
+	close(a.stop)
+	<-a.done
+}
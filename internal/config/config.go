@@ -1,18 +1,29 @@
 package config
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"os"
+	"slices"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type Config struct {
-	Env         string     `yaml:"env" env-default:"local"`
-	StoragePath string     `yaml:"storage_path" env-required:"true"`
-	GRPC        GRPCConfig `yaml:"grpc"`
-	HTTP        HTTPConfig `yaml:"http"`
+	Env         string            `yaml:"env" env-default:"local"`
+	StoragePath string            `yaml:"storage_path" env-required:"true"`
+	GRPC        GRPCConfig        `yaml:"grpc"`
+	HTTP        HTTPConfig        `yaml:"http"`
+	Trash       TrashConfig       `yaml:"trash"`
+	Auth        AuthConfig        `yaml:"auth"`
+	Recurring   RecurringConfig   `yaml:"recurring"`
+	Attachments AttachmentsConfig `yaml:"attachments"`
+
+	// BaseCurrency is the ISO 4217 code reports are converted to. Stored
+	// exchange rates are prices in it, so they must be reloaded if it changes.
+	BaseCurrency string `yaml:"base_currency" env-default:"RUB"`
 }
 
 type GRPCConfig struct {
@@ -23,6 +34,52 @@ type HTTPConfig struct {
 	Port int `yaml:"port"`
 }
 
+// TrashConfig controls how long deleted expenses stay restorable.
+type TrashConfig struct {
+	Retention     time.Duration `yaml:"retention" env-default:"720h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env-default:"1h"`
+}
+
+// RecurringConfig controls how often due recurring expenses are materialized.
+type RecurringConfig struct {
+	Interval time.Duration `yaml:"interval" env-default:"1h"`
+}
+
+// AttachmentsConfig controls where receipt files are stored and which ones
+// are accepted. Files are stored once per content, named by its SHA-256.
+type AttachmentsConfig struct {
+	Dir     string   `yaml:"dir" env-default:"./attachments"`
+	MaxSize int64    `yaml:"max_size" env-default:"10485760"` // in bytes
+	Types   []string `yaml:"types" env-default:"image/jpeg,image/png,image/webp,image/heic,application/pdf"`
+}
+
+// AuthConfig configures bearer token authentication. Every call needs a
+// token. The secret tokens are signed with is only read from the environment.
+type AuthConfig struct {
+	Secret   string        `yaml:"-" env:"AUTH_SECRET" env-required:"true"`
+	TokenTTL time.Duration `yaml:"token_ttl" env-default:"720h"`
+}
+
+// minSecretLength is the shortest secret accepted, in bytes.
+const minSecretLength = 32
+
+// knownSecrets are secrets published in examples and tests of this repo.
+var knownSecrets = []string{"local-secret", "test-secret", "secret", "changeme"}
+
+// validate rejects secrets that are short or known, so tokens cannot be
+// forged with them.
+func (c AuthConfig) validate() error {
+	if slices.Contains(knownSecrets, c.Secret) {
+		return errors.New("AUTH_SECRET is a known default")
+	}
+
+	if len(c.Secret) < minSecretLength {
+		return fmt.Errorf("AUTH_SECRET must be at least %d bytes long", minSecretLength)
+	}
+
+	return nil
+}
+
 func MustLoad() *Config {
 	configPath := fetchConfigPath()
 	if configPath == "" {
@@ -44,6 +101,10 @@ func MustLoadPath(configPath string) *Config {
 		panic("cannot read config: " + err.Error())
 	}
 
+	if err := cfg.Auth.validate(); err != nil {
+		panic("invalid auth config: " + err.Error())
+	}
+
 	return &cfg
 }
 
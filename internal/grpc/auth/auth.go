@@ -0,0 +1,79 @@
+package authgrpc
+
+import (
+	"context"
+	"strings"
+
+	middleware "github.com/grpc-ecosystem/go-grpc-middleware/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/kochnevns/finances-backend/internal/lib/jwt"
+	"github.com/kochnevns/finances-backend/internal/lib/userctx"
+)
+
+// Authenticator checks bearer tokens of incoming calls.
+type Authenticator struct {
+	secret string
+}
+
+// New creates an authenticator of tokens signed with secret.
+func New(secret string) *Authenticator {
+	return &Authenticator{secret: secret}
+}
+
+// Authenticate validates the "Bearer <token>" authorization value and
+// returns ctx carrying the ID of the user the token belongs to. Every method
+// needs a token: the services read and write data of a user only.
+func (a *Authenticator) Authenticate(ctx context.Context, authorization string) (context.Context, error) {
+	scheme, token, ok := strings.Cut(authorization, " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") || token == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+
+	userID, err := jwt.ParseToken(token, a.secret)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	return userctx.WithUserID(ctx, userID), nil
+}
+
+// UnaryServerInterceptor rejects calls without a valid token.
+func (a *Authenticator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var authorization string
+		if values := metadata.ValueFromIncomingContext(ctx, "authorization"); len(values) > 0 {
+			authorization = values[0]
+		}
+
+		ctx, err := a.Authenticate(ctx, authorization)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is UnaryServerInterceptor for streaming calls.
+func (a *Authenticator) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		var authorization string
+		if values := metadata.ValueFromIncomingContext(ss.Context(), "authorization"); len(values) > 0 {
+			authorization = values[0]
+		}
+
+		ctx, err := a.Authenticate(ss.Context(), authorization)
+		if err != nil {
+			return err
+		}
+
+		wrapped := middleware.WrapServerStream(ss)
+		wrapped.WrappedContext = ctx
+
+		return handler(srv, wrapped)
+	}
+}
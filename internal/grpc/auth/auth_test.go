@@ -0,0 +1,50 @@
+package authgrpc_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	authgrpc "github.com/kochnevns/finances-backend/internal/grpc/auth"
+	"github.com/kochnevns/finances-backend/internal/lib/jwt"
+	"github.com/kochnevns/finances-backend/internal/lib/userctx"
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+const secret = "test-secret"
+
+func TestAuthenticate(t *testing.T) {
+	auth := authgrpc.New(secret)
+
+	valid, err := jwt.NewToken(models.User{ID: 7, Name: "bob"}, secret, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err := auth.Authenticate(context.Background(), "Bearer "+valid)
+	if err != nil {
+		t.Fatalf("valid token: %v", err)
+	}
+	if id, ok := userctx.UserID(ctx); !ok || id != 7 {
+		t.Fatalf("user id = %d, %v; want 7", id, ok)
+	}
+
+	expired, _ := jwt.NewToken(models.User{ID: 7}, secret, -time.Minute)
+	foreign, _ := jwt.NewToken(models.User{ID: 7}, "other-secret", time.Hour)
+
+	for name, header := range map[string]string{
+		"missing":      "",
+		"not bearer":   "Basic " + valid,
+		"expired":      "Bearer " + expired,
+		"wrong secret": "Bearer " + foreign,
+		"garbage":      "Bearer garbage",
+	} {
+		_, err := auth.Authenticate(context.Background(), header)
+		if status.Code(err) != codes.Unauthenticated {
+			t.Errorf("%s: got %v, want Unauthenticated", name, err)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package financesgrpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// Metadata keys with the details of a streamed file. Content-Type is taken
+// by gRPC itself.
+const (
+	ExpenseIDKey = "expense-id"
+	FileNameKey  = "file-name"
+	FileTypeKey  = "file-type"
+	FileSizeKey  = "file-size"
+)
+
+// chunkSize is the size of the file chunks sent by Download.
+const chunkSize = 32 << 10
+
+// The finances proto has no messages for files, so attachments are
+// streamed by a service of their own built on well-known types: the
+// content in BytesValue chunks and its details in metadata.
+//
+// Upload is client-streaming: the chunks with expense-id, file-name and
+// optionally file-type in the request metadata; the response is a Struct
+// with the recorded attachment. Download takes the attachment ID as an
+// Int64Value and streams the chunks, with file-name, file-type and
+// file-size in the response header.
+var attachmentsServiceDesc = grpc.ServiceDesc{
+	ServiceName: "finances.Attachments",
+	HandlerType: (*attachmentsServer)(nil),
+	Streams: []grpc.StreamDesc{
+		{StreamName: "Upload", Handler: uploadHandler, ClientStreams: true},
+		{StreamName: "Download", Handler: downloadHandler, ServerStreams: true},
+	},
+	Metadata: "attachments",
+}
+
+type attachmentsServer interface {
+	Upload(stream grpc.ServerStream) error
+	Download(id *wrapperspb.Int64Value, stream grpc.ServerStream) error
+}
+
+type attachmentsAPI struct {
+	finances Finances
+}
+
+func uploadHandler(srv any, stream grpc.ServerStream) error {
+	return srv.(attachmentsServer).Upload(stream)
+}
+
+func downloadHandler(srv any, stream grpc.ServerStream) error {
+	id := &wrapperspb.Int64Value{}
+	if err := stream.RecvMsg(id); err != nil {
+		return err
+	}
+
+	return srv.(attachmentsServer).Download(id, stream)
+}
+
+func (a *attachmentsAPI) Upload(stream grpc.ServerStream) error {
+	ctx := stream.Context()
+
+	expenseID, err := strconv.ParseInt(metadataValue(ctx, ExpenseIDKey), 10, 64)
+	if err != nil || expenseID <= 0 {
+		return status.Error(codes.InvalidArgument, "expense-id metadata is required")
+	}
+
+	attachment, err := a.finances.UploadAttachment(ctx, expenseID, metadataValue(ctx, FileNameKey), &chunkReader{stream: stream})
+	if err != nil {
+		if s, ok := status.FromError(err); ok {
+			return s.Err()
+		}
+
+		return StatusError(err)
+	}
+
+	rsp, err := structpb.NewStruct(map[string]any{
+		"id":          attachment.ID,
+		"expenseId":   attachment.ExpenseID,
+		"name":        attachment.Name,
+		"contentType": attachment.ContentType,
+		"size":        attachment.Size,
+		"createdAt":   attachment.CreatedAt,
+	})
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	return stream.SendMsg(rsp)
+}
+
+func (a *attachmentsAPI) Download(id *wrapperspb.Int64Value, stream grpc.ServerStream) error {
+	if id.GetValue() <= 0 {
+		return status.Error(codes.InvalidArgument, "attachment id is required")
+	}
+
+	attachment, content, err := a.finances.OpenAttachment(stream.Context(), id.GetValue())
+	if err != nil {
+		return StatusError(err)
+	}
+	defer content.Close() // nolint: errcheck
+
+	err = stream.SendHeader(metadata.Pairs(
+		FileNameKey, attachment.Name,
+		FileTypeKey, attachment.ContentType,
+		FileSizeKey, strconv.FormatInt(attachment.Size, 10),
+	))
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := content.Read(buf)
+		if n > 0 {
+			if err := stream.SendMsg(wrapperspb.Bytes(buf[:n])); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+}
+
+// chunkReader reads the content of an upload from the stream.
+type chunkReader struct {
+	stream grpc.ServerStream
+	chunk  []byte
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for len(r.chunk) == 0 {
+		msg := &wrapperspb.BytesValue{}
+		if err := r.stream.RecvMsg(msg); err != nil {
+			return 0, err
+		}
+		r.chunk = msg.GetValue()
+	}
+
+	n := copy(p, r.chunk)
+	r.chunk = r.chunk[n:]
+
+	return n, nil
+}
+
+func metadataValue(ctx context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
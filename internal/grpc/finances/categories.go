@@ -0,0 +1,172 @@
+package financesgrpc
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// The finances proto can only create and list categories, and its Category
+// has no ID, so the rest is served by a service of its own built on
+// well-known types, like attachments.
+//
+// List takes Empty and returns a Struct with "categories": id, name, icon
+// and color of each category that is not archived. Edit takes a Struct with
+// the id and the name, icon and color to change, empty ones are kept, and
+// returns the saved category. Archive and Unarchive take the category ID as
+// an Int64Value. Merge takes a Struct with "fromId" and "toId" and moves
+// everything of the first category into the second.
+var categoriesServiceDesc = grpc.ServiceDesc{
+	ServiceName: "finances.Categories",
+	HandlerType: (*categoriesServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "List", Handler: categoriesHandler("List", categoriesServer.List)},
+		{MethodName: "Edit", Handler: categoriesHandler("Edit", categoriesServer.Edit)},
+		{MethodName: "Archive", Handler: categoriesHandler("Archive", categoriesServer.Archive)},
+		{MethodName: "Unarchive", Handler: categoriesHandler("Unarchive", categoriesServer.Unarchive)},
+		{MethodName: "Merge", Handler: categoriesHandler("Merge", categoriesServer.Merge)},
+	},
+	Metadata: "categories",
+}
+
+type categoriesServer interface {
+	List(ctx context.Context, in *emptypb.Empty) (*structpb.Struct, error)
+	Edit(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error)
+	Archive(ctx context.Context, id *wrapperspb.Int64Value) (*emptypb.Empty, error)
+	Unarchive(ctx context.Context, id *wrapperspb.Int64Value) (*emptypb.Empty, error)
+	Merge(ctx context.Context, in *structpb.Struct) (*emptypb.Empty, error)
+}
+
+type categoriesAPI struct {
+	finances Finances
+}
+
+// categoriesHandler decodes the request of the method and calls it through
+// the interceptors, the way generated code does.
+func categoriesHandler[In any, Req interface {
+	*In
+	proto.Message
+}, Rsp proto.Message](
+	method string, call func(categoriesServer, context.Context, Req) (Rsp, error),
+) func(any, context.Context, func(any) error, grpc.UnaryServerInterceptor) (any, error) {
+	return func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+		in := Req(new(In))
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+
+		handler := func(ctx context.Context, req any) (any, error) {
+			return call(srv.(categoriesServer), ctx, req.(Req))
+		}
+		if interceptor == nil {
+			return handler(ctx, in)
+		}
+
+		info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/finances.Categories/" + method}
+
+		return interceptor(ctx, in, info, handler)
+	}
+}
+
+func (c *categoriesAPI) List(ctx context.Context, _ *emptypb.Empty) (*structpb.Struct, error) {
+	categories, err := c.finances.CategoriesList(ctx)
+	if err != nil {
+		return nil, StatusError(err)
+	}
+
+	list := make([]any, 0, len(categories))
+	for _, category := range categories {
+		list = append(list, categoryFields(category))
+	}
+
+	rsp, err := structpb.NewStruct(map[string]any{"categories": list})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return rsp, nil
+}
+
+func (c *categoriesAPI) Edit(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	id := structID(in, "id")
+	if id <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "category id is required")
+	}
+
+	fields := in.GetFields()
+	category, err := c.finances.EditCategory(ctx, id,
+		strings.TrimSpace(fields["name"].GetStringValue()), fields["icon"].GetStringValue(), fields["color"].GetStringValue(),
+	)
+	if err != nil {
+		return nil, StatusError(err)
+	}
+
+	rsp, err := structpb.NewStruct(categoryFields(category))
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return rsp, nil
+}
+
+func (c *categoriesAPI) Archive(ctx context.Context, id *wrapperspb.Int64Value) (*emptypb.Empty, error) {
+	if id.GetValue() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "category id is required")
+	}
+
+	if err := c.finances.ArchiveCategory(ctx, id.GetValue()); err != nil {
+		return nil, StatusError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (c *categoriesAPI) Unarchive(ctx context.Context, id *wrapperspb.Int64Value) (*emptypb.Empty, error) {
+	if id.GetValue() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "category id is required")
+	}
+
+	if err := c.finances.UnarchiveCategory(ctx, id.GetValue()); err != nil {
+		return nil, StatusError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (c *categoriesAPI) Merge(ctx context.Context, in *structpb.Struct) (*emptypb.Empty, error) {
+	fromID, toID := structID(in, "fromId"), structID(in, "toId")
+	if fromID <= 0 || toID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "both category ids are required")
+	}
+
+	if fromID == toID {
+		return nil, status.Error(codes.InvalidArgument, "cannot merge a category into itself")
+	}
+
+	if err := c.finances.MergeCategories(ctx, fromID, toID); err != nil {
+		return nil, StatusError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func categoryFields(c Category) map[string]any {
+	return map[string]any{
+		"id":    c.ID,
+		"name":  c.Name,
+		"icon":  c.Icon,
+		"color": c.Color,
+	}
+}
+
+// structID reads an ID from the Struct, where all numbers are doubles.
+func structID(s *structpb.Struct, key string) int64 {
+	return int64(s.GetFields()[key].GetNumberValue())
+}
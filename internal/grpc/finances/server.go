@@ -2,22 +2,360 @@ package financesgrpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
 	"time"
 
+	"github.com/kochnevns/finances-backend/internal/storage"
 	financesgrpcsrv "github.com/kochnevns/finances-protos/finances"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
+// ExpenseKey is the response header of ExpenseEdit with the saved expense,
+// a serialized finances.Expense: ExpenseResponse has no field for it.
+const ExpenseKey = "expense-bin"
+
 type Expense struct {
 	ID          int64
 	Description string
 	Amount      int64  // in cents
+	Currency    string // ISO 4217 code, e.g. "USD"
 	Date        string // YYYY-MM-DD
 	Category    string // "food", "groceries", "transport", "misc"
 	Color       string
+	DeletedAt   string // RFC 3339, set for expenses in the trash
+	// Tags are sorted labels of the expense. Saving an expense with nil
+	// Tags keeps the recorded ones, an empty list removes them.
+	Tags []string
+	// Splits are the line items of a split expense, the largest first.
+	// Saving an expense with nil Splits keeps the recorded ones, an empty
+	// list makes it a single item again.
+	Splits []Split
+	// Sharing tells who paid the expense and how it is shared between
+	// people, nil for expenses that are not shared. Saving an expense with
+	// nil Sharing keeps the recorded one, reshared when the amount changes;
+	// a Sharing without shares stops sharing it.
+	Sharing *Sharing
+	// Account is the account the expense is paid from, nil for none.
+	// Saving an expense with nil Account keeps the recorded one, an empty
+	// name unlinks it.
+	Account *string
+}
+
+// Sharing is how an expense is shared: "equal", by "percent" or by
+// "exact" amounts. Shares are in the currency of the expense.
+type Sharing struct {
+	PaidBy string
+	Method string
+	Shares []Share
+}
+
+type Share struct {
+	Person  string
+	Percent float64 // for the "percent" method
+	Amount  int64   // in cents, given for the "exact" method
+}
+
+// Settlement is money paid back by one person to another.
+type Settlement struct {
+	ID       int64
+	Date     string // YYYY-MM-DD
+	From     string
+	To       string
+	Amount   int64  // in cents
+	Currency string // ISO 4217 code, e.g. "USD"
+	Note     string
+}
+
+// Debt is what From owes To, or should pay them to settle up, in the base
+// currency.
+type Debt struct {
+	From   string
+	To     string
+	Amount int64
+}
+
+// Account is where money is kept, e.g. a card or cash. Its amounts are
+// in its currency.
+type Account struct {
+	ID             int64
+	Name           string
+	Currency       string // ISO 4217 code, e.g. "USD"
+	OpeningBalance int64  // in cents
+	OpenedOn       string // YYYY-MM-DD
+	Balance        int64  // the current balance in cents
+}
+
+// Transfer is money moved between accounts, which is neither spent nor
+// earned. Amount leaves From and ToAmount, in the currency of To, arrives.
+type Transfer struct {
+	ID          int64
+	Date        string // YYYY-MM-DD
+	From        string
+	To          string
+	Amount      int64 // in cents
+	ToAmount    int64 // in cents, the same as Amount between accounts in one currency
+	Description string
+}
+
+// BalancePoint is the balance of an account at the end of a day it
+// changed on.
+type BalancePoint struct {
+	Date    string // YYYY-MM-DD
+	Change  int64
+	Balance int64
+}
+
+// Reconciliation is a check of an account against a bank statement.
+// Balance is computed from all the items dated up to the statement and
+// ClearedBalance from the cleared ones, both as they were when it was
+// finished; Difference is what is left to clear, the statement balance less
+// the cleared balance.
+type Reconciliation struct {
+	ID               int64
+	Account          string
+	Date             string // YYYY-MM-DD, of the statement
+	StatementBalance int64  // in cents
+	Balance          int64
+	ClearedBalance   int64
+	Difference       int64
+	FinishedAt       string // RFC 3339, empty while in progress
+}
+
+// LedgerItem is an "expense", "income" or "transfer" as it changes the
+// balance of an account: Amount is negative for money leaving it.
+type LedgerItem struct {
+	Kind        string
+	ID          int64
+	Date        string // YYYY-MM-DD
+	Description string
+	Amount      int64 // in cents
+}
+
+// Goal is an amount to save up, by the deadline when there is one, in the
+// currency of the goal. Saved counts the contributions only; the linked
+// account sets the currency and is not looked at otherwise.
+type Goal struct {
+	ID       int64
+	Name     string
+	Target   int64  // in cents
+	Currency string // ISO 4217 code, that of the account when one is linked
+	Deadline string // YYYY-MM-DD, empty for none
+	Account  string // where the savings are kept, empty for none
+	Saved    int64  // the sum of the contributions
+}
+
+// Contribution is money put towards a goal, negative when taken back.
+type Contribution struct {
+	ID     int64
+	GoalID int64
+	Date   string // YYYY-MM-DD
+	Amount int64  // in cents
+	Note   string
+}
+
+// GoalProgress is how far a goal is and how it is going.
+type GoalProgress struct {
+	Saved     int64
+	Remaining int64
+	Percent   float64
+	// MonthlyRequired is what is left to save per month to meet the deadline.
+	MonthlyRequired int64
+	// MonthlyPace is what was saved per month recently.
+	MonthlyPace int64
+	// ProjectedOn is when the target is reached at the pace (YYYY-MM-DD),
+	// empty when nothing is being saved.
+	ProjectedOn string
+	OnTrack     bool
+}
+
+// Attachment is a file attached to an expense, e.g. a receipt.
+type Attachment struct {
+	ID          int64
+	ExpenseID   int64
+	Name        string
+	ContentType string
+	Size        int64  // in bytes
+	CreatedAt   string // RFC 3339
+}
+
+// Balance is what a person is owed in the base currency, negative when
+// they owe.
+type Balance struct {
+	Person string
+	Amount int64
+}
+
+// Split is a line item of an expense with a category of its own.
+type Split struct {
+	Category string
+	Color    string
+	Amount   int64 // in cents, in the currency of the expense
+}
+
+// Tag is a label of expenses and the number of expenses it is on.
+type Tag struct {
+	Name     string
+	Expenses int
+}
+
+// TagReport is the total of the expenses with a tag in the base currency.
+type TagReport struct {
+	Tag      string
+	Amount   int64
+	Expenses int
+}
+
+type Income struct {
+	ID          int64
+	Description string
+	Amount      int64  // in cents
+	Currency    string // ISO 4217 code, e.g. "USD"
+	Date        string // YYYY-MM-DD
+	Source      string // income source name, e.g. "salary"
+	Color       string
+	// Account is the account the income is paid to, nil for none, saved
+	// like Expense.Account.
+	Account *string
+}
+
+type IncomeSource struct {
+	ID    int64
+	Name  string
+	Color string
+}
+
+// ExchangeRate is the price of one unit of Currency in the base currency on Date.
+type ExchangeRate struct {
+	Currency string
+	Date     string // YYYY-MM-DD
+	Rate     float64
+}
+
+// ErrInvalidArgument is wrapped by Finances implementations to report
+// requests that cannot be served as given.
+var ErrInvalidArgument = errors.New("invalid argument")
+
+// ErrUnauthenticated is returned by Finances implementations on calls that
+// do not carry the identity of a user.
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// ErrPermissionDenied is returned by Finances implementations on calls the
+// user is not allowed to make.
+var ErrPermissionDenied = errors.New("permission denied")
+
+// ErrUnreconciled is returned on finishing a reconciliation whose cleared
+// balance does not match the statement.
+var ErrUnreconciled = errors.New("cleared balance does not match the statement")
+
+// ExpensesQuery filters, sorts and pages expenses in SearchExpenses.
+type ExpensesQuery struct {
+	Categories  []string  // category names, any of them or of CategoryIDs matches
+	CategoryIDs []int64   // category IDs, any of them or of Categories matches
+	Tags        []string  // tags, any of them matches
+	From        time.Time // inclusive, zero means unbounded
+	To          time.Time // inclusive, zero means unbounded
+	MinAmount   int64     // 0 means unbounded
+	MaxAmount   int64     // 0 means unbounded
+	Search      string    // words that must all appear in the description
+	SortBy      string    // "date" (default) or "amount"
+	Ascending   bool
+	Cursor      string // next cursor returned with the previous page
+	Limit       int    // page size, 0 means everything
+}
+
+// Recurring is a template of an expense repeated by a rule: every Interval
+// weeks, months or years from StartDate, on its weekday or day of the month.
+type Recurring struct {
+	ID          int64
+	Description string
+	Amount      int64  // in cents
+	Currency    string // ISO 4217 code, e.g. "USD"
+	Category    string
+	Color       string
+	Frequency   string  // "weekly", "monthly" or "yearly"
+	Interval    int     // 1 when zero on creation
+	StartDate   string  // YYYY-MM-DD, the first occurrence
+	EndDate     *string // YYYY-MM-DD, inclusive, empty means never; kept on edit when nil
+	NextDate    string  // YYYY-MM-DD, next occurrence to become an expense, empty once ended
+	Paused      bool
+}
+
+// ImportProfile maps the columns of a bank CSV export to expenses. Columns
+// are header names or 1-based numbers.
+type ImportProfile struct {
+	Name             string
+	Delimiter        string // "," when empty
+	SkipRows         int    // lines before the header
+	NoHeader         bool
+	Date             string
+	DateFormat       string // Go layout, common formats are tried when empty
+	Amount           string
+	Description      string
+	Category         string  // optional
+	Currency         string  // optional
+	DecimalSeparator string  // "." or ",", "." when empty
+	Multiplier       float64 // converts amounts to ledger units, 1 when zero
+	ExpensesNegative bool    // spending has a minus sign, positive rows are skipped
+	Rules            []CategoryRule
+	DefaultCategory  string
+}
+
+// CategoryRule assigns Category to descriptions containing Contains.
+type CategoryRule struct {
+	Contains string
+	Category string
+}
+
+// ImportRow is a line of an imported statement. Status is "new",
+// "duplicate", "skipped" or "invalid", with the Reason of the last two.
+type ImportRow struct {
+	Line    int
+	Expense Expense
+	Status  string
+	Reason  string
+}
+
+type ImportResult struct {
+	Rows       []ImportRow
+	Imported   int // saved, or to be saved on a dry run
+	Duplicates int
+	Skipped    int
+	Invalid    int
+}
+
+// CategorizationRule assigns Category to new expenses saved without one
+// when all of its set conditions hold. Higher priorities are tried first.
+type CategorizationRule struct {
+	ID        int64
+	Category  string
+	Color     string
+	Priority  int
+	Contains  string         // case-insensitive part of the description
+	Pattern   string         // regular expression matching the description
+	MinAmount int64          // 0 means unbounded
+	MaxAmount int64          // 0 means unbounded
+	Weekdays  []time.Weekday // any day when empty
+}
+
+// RuleTest tells how a rule would have categorized recorded expenses.
+type RuleTest struct {
+	Matched      int
+	SameCategory int       // matched expenses already in the category of the rule
+	Expenses     []Expense // the first matched expenses, oldest first
+}
+
+// CategorySuggestion is a category likely meant by a description.
+type CategorySuggestion struct {
+	Category   string
+	Color      string
+	Confidence float64 // between 0 and 1
 }
 
 type ReportFilter string
@@ -37,11 +375,44 @@ type CategoryReport struct {
 	Color    string
 	Amount   int64
 	Percent  float64
+
+	// Budget figures are set in calendar month reports for categories with
+	// a budget for the month.
+	Budget      int64 // 0 when the category has no budget
+	Remaining   int64 // Budget minus Amount, negative when over budget
+	PercentUsed int64 // Amount as a percentage of Budget
+}
+
+// PercentOf returns Amount as a whole percentage of the report total, 0 when
+// the total is 0.
+func (c CategoryReport) PercentOf(total int64) int64 {
+	if total == 0 {
+		return 0
+	}
+
+	return c.Amount * 100 / total
+}
+
+// OverBudget reports whether the category has a budget and spent past it.
+func (c CategoryReport) OverBudget() bool {
+	return c.Budget != 0 && c.Remaining < 0
+}
+
+// Budget is the planned spending of a category in a month, in the base currency.
+type Budget struct {
+	CategoryID   int64
+	Category     string
+	Color        string
+	Month        string // YYYY-MM the budget was set for
+	Amount       int64
+	RollsForward bool // also applies to the following months until replaced
 }
 
 type Category struct {
-	ID   int64
-	Name string // "food", "groceries", "transport", "misc"
+	ID    int64
+	Name  string // "food", "groceries", "transport", "misc"
+	Icon  string
+	Color string
 }
 
 type Finances interface {
@@ -54,14 +425,16 @@ type Finances interface {
 		Id int64, // expense ID in the database, not the ID in the gRPC request
 	) (err error)
 
-	// ExpenseEdit(
-	// 	ctx context.Context,
-	// 	ID int64, // expense ID in the database, not the ID in the gRPC request
-	// 	Description string,
-	// 	Amount int64, // in cents
-	// 	Date string, // YYYY-MM-DD
-	// 	Category string, // "food", "groceries", "transport", "misc"
-	// ) (err error)
+	// ExpenseEdit updates only the non-zero fields of the expense with the given ID.
+	ExpenseEdit(
+		ctx context.Context,
+		ID int64, // expense ID in the database, not the ID in the gRPC request
+		Description string,
+		Amount int64, // in cents
+		Date string, // YYYY-MM-DD
+		Category string, // "food", "groceries", "transport", "misc"
+	) (Expense, error)
+
 	ExpensesList(
 		ctx context.Context,
 		category string,
@@ -69,9 +442,161 @@ type Finances interface {
 		year int64,
 	) (list []Expense, totalAmount int64, err error)
 
-	CreateCategory(context.Context, string) (string, error)
+	// SaveExpense creates the expense when its ID is zero and otherwise
+	// updates its non-zero fields like ExpenseEdit. It returns the saved expense.
+	SaveExpense(ctx context.Context, expense Expense) (Expense, error)
+
+	// SearchExpenses returns a page of expenses matching the query, the total
+	// amount of all matching expenses and the cursor of the next page, which
+	// is empty on the last page.
+	SearchExpenses(ctx context.Context, query ExpensesQuery) (list []Expense, totalAmount int64, nextCursor string, err error)
+
+	DeleteExpense(ctx context.Context, id int64) error
+	RestoreExpense(ctx context.Context, id int64) error
+	TrashList(ctx context.Context) ([]Expense, error)
+
+	CreateCategory(ctx context.Context, name, icon, color string) (Category, error)
+	// EditCategory updates only the non-empty fields of the category.
+	EditCategory(ctx context.Context, id int64, name, icon, color string) (Category, error)
+	// ArchiveCategory hides the category from the list. It takes no new
+	// expenses, line items, recurring templates or rules after that, while
+	// the ones already in it keep it when edited.
+	ArchiveCategory(ctx context.Context, id int64) error
+	UnarchiveCategory(ctx context.Context, id int64) error
+	// MergeCategories moves all expenses and budgets of fromID into toID and
+	// removes fromID. Budgets of months toID has its own budget for are dropped.
+	MergeCategories(ctx context.Context, fromID, toID int64) error
 	CategoriesList(context.Context) ([]Category, error)
 	Report(context.Context, ReportFilter, int, int) (int64, int64, int64, []CategoryReport, error)
+	// RangeReport is Report for an arbitrary inclusive date range.
+	RangeReport(ctx context.Context, from, to time.Time) (int64, int64, int64, []CategoryReport, error)
+
+	// SetRates stores exchange rates, replacing known ones for the same currency and date.
+	// The rates are shared by all users and only the owner sets them.
+	SetRates(ctx context.Context, rates []ExchangeRate) error
+	// Rates lists the known exchange rates of the currency, or of all currencies when it is empty.
+	Rates(ctx context.Context, currency string) ([]ExchangeRate, error)
+
+	// SetBudget creates or replaces the budget of the category for the month (YYYY-MM).
+	SetBudget(ctx context.Context, categoryID int64, month string, amount int64, rollsForward bool) error
+	DeleteBudget(ctx context.Context, categoryID int64, month string) error
+	// Budgets lists the budgets that apply to the month, set for it or rolled forward.
+	Budgets(ctx context.Context, month string) ([]Budget, error)
+
+	CreateRecurring(ctx context.Context, r Recurring) (Recurring, error)
+	// EditRecurring updates only the non-zero fields of the template with the ID of r.
+	EditRecurring(ctx context.Context, r Recurring) (Recurring, error)
+	PauseRecurring(ctx context.Context, id int64, paused bool) error
+	DeleteRecurring(ctx context.Context, id int64) error
+	RecurringList(ctx context.Context) ([]Recurring, error)
+	// RecurringPreview returns the YYYY-MM-DD dates of the next count expenses of the template.
+	RecurringPreview(ctx context.Context, id int64, count int) ([]string, error)
+
+	CreateIncomeSource(ctx context.Context, name, color string) (IncomeSource, error)
+	IncomeSourcesList(ctx context.Context) ([]IncomeSource, error)
+	// EditIncomeSource updates the non-empty fields of the source.
+	EditIncomeSource(ctx context.Context, id int64, name, color string) (IncomeSource, error)
+	// DeleteIncomeSource deletes the source unless incomes are recorded from it.
+	DeleteIncomeSource(ctx context.Context, id int64) error
+	// SaveIncome creates the income when its ID is zero and otherwise updates its non-zero fields.
+	SaveIncome(ctx context.Context, income Income) (Income, error)
+	DeleteIncome(ctx context.Context, id int64) error
+	// IncomesList returns incomes within the inclusive date range and their total.
+	IncomesList(ctx context.Context, from, to time.Time) ([]Income, int64, error)
+	// IncomeReport returns the total income within the inclusive date range and its split per source.
+	IncomeReport(ctx context.Context, from, to time.Time) (int64, []CategoryReport, error)
+
+	SaveImportProfile(ctx context.Context, profile ImportProfile) error
+	ImportProfiles(ctx context.Context) ([]ImportProfile, error)
+	// ImportExpenses saves the expenses of a bank CSV export read with the
+	// named profile, skipping recorded ones. A dry run saves nothing.
+	ImportExpenses(ctx context.Context, csv io.Reader, profile string, dryRun bool) (ImportResult, error)
+
+	// ExportExpenses streams the expenses within the inclusive date range,
+	// of the categories when any are given, to w as "csv", "json" or "xlsx",
+	// with their tags and accounts but not their line items or sharing.
+	ExportExpenses(ctx context.Context, w io.Writer, format string, from, to time.Time, categories []string) error
+
+	// SaveRule creates the categorization rule when its ID is zero and otherwise replaces it.
+	SaveRule(ctx context.Context, rule CategorizationRule) (CategorizationRule, error)
+	DeleteRule(ctx context.Context, id int64) error
+	// Rules lists the categorization rules in the order they are tried.
+	Rules(ctx context.Context) ([]CategorizationRule, error)
+	// SuggestCategory returns the first rule matching the expense, if any.
+	SuggestCategory(ctx context.Context, expense Expense) (CategorizationRule, bool, error)
+	// TestRule matches the rule against the expenses within the inclusive
+	// date range and returns up to limit of the matched ones.
+	TestRule(ctx context.Context, rule CategorizationRule, from, to time.Time, limit int) (RuleTest, error)
+	// SuggestCategories returns up to limit categories most likely meant by
+	// the description, learned from past expenses, most likely first.
+	SuggestCategories(ctx context.Context, description string, limit int) ([]CategorySuggestion, error)
+
+	// Tags lists the tags on expenses, the most used first.
+	Tags(ctx context.Context) ([]Tag, error)
+	// TagsReport sums expenses per tag within the inclusive date range.
+	TagsReport(ctx context.Context, from, to time.Time) ([]TagReport, error)
+
+	// SharedBalances returns who owes whom over shared expenses and
+	// settlements, and the balance of each person.
+	SharedBalances(ctx context.Context) ([]Debt, []Balance, error)
+	// SettleUp suggests transfers that settle all debts. They are fewer
+	// than the people with a balance, not always the fewest possible.
+	SettleUp(ctx context.Context) ([]Debt, error)
+	SaveSettlement(ctx context.Context, settlement Settlement) (Settlement, error)
+	DeleteSettlement(ctx context.Context, id int64) error
+	// Settlements lists the recorded settlements, the latest first.
+	Settlements(ctx context.Context) ([]Settlement, error)
+
+	// UploadAttachment attaches the file read from r to the expense. Its
+	// type is told by the content.
+	UploadAttachment(ctx context.Context, expenseID int64, name string, r io.Reader) (Attachment, error)
+	// OpenAttachment returns the attachment and its content to be closed.
+	OpenAttachment(ctx context.Context, id int64) (Attachment, io.ReadCloser, error)
+	// Attachments lists the attachments of the expense, the first added first.
+	Attachments(ctx context.Context, expenseID int64) ([]Attachment, error)
+	DeleteAttachment(ctx context.Context, id int64) error
+
+	// SaveAccount creates the account when its ID is zero and otherwise
+	// replaces its name, opening balance and date.
+	SaveAccount(ctx context.Context, account Account) (Account, error)
+	DeleteAccount(ctx context.Context, id int64) error
+	// Accounts lists the accounts with their current balances.
+	Accounts(ctx context.Context) ([]Account, error)
+	// AccountHistory returns the running balance of the account on the
+	// days it changed within the inclusive date range.
+	AccountHistory(ctx context.Context, id int64, from, to time.Time) ([]BalancePoint, error)
+	SaveTransfer(ctx context.Context, transfer Transfer) (Transfer, error)
+	DeleteTransfer(ctx context.Context, id int64) error
+	// Transfers lists the transfers within the inclusive date range, the latest first.
+	Transfers(ctx context.Context, from, to time.Time) ([]Transfer, error)
+
+	// StartReconciliation records the balance of a statement of the account
+	// and returns how it compares with the computed balances.
+	StartReconciliation(ctx context.Context, r Reconciliation) (Reconciliation, error)
+	// Reconciliation returns the reconciliation and the items of its account
+	// that are not cleared yet.
+	Reconciliation(ctx context.Context, id int64) (Reconciliation, []LedgerItem, error)
+	// Reconciliations lists the reconciliations, the latest statement first.
+	Reconciliations(ctx context.Context) ([]Reconciliation, error)
+	// FinishReconciliation completes the reconciliation once nothing is left
+	// to clear and fails with ErrUnreconciled before.
+	FinishReconciliation(ctx context.Context, id int64) (Reconciliation, error)
+	DeleteReconciliation(ctx context.Context, id int64) error
+	// SetCleared marks the items, told by Kind and ID, as cleared or not.
+	SetCleared(ctx context.Context, items []LedgerItem, cleared bool) error
+
+	// SaveGoal creates the goal when its ID is zero and otherwise replaces
+	// its name, target, deadline and account.
+	SaveGoal(ctx context.Context, goal Goal) (Goal, error)
+	DeleteGoal(ctx context.Context, id int64) error
+	// Goals lists the goals, the nearest deadline first.
+	Goals(ctx context.Context) ([]Goal, error)
+	// GoalProgress returns the goal and how far it is as of today.
+	GoalProgress(ctx context.Context, id int64) (Goal, GoalProgress, error)
+	Contribute(ctx context.Context, contribution Contribution) (Contribution, error)
+	DeleteContribution(ctx context.Context, id int64) error
+	// Contributions lists the contributions to the goal, the oldest first.
+	Contributions(ctx context.Context, goalID int64) ([]Contribution, error)
 }
 
 type serverAPI struct {
@@ -83,6 +608,8 @@ func Register(gRPCServer *grpc.Server, finances Finances) {
 	financesgrpcsrv.RegisterFinancesServer(gRPCServer, &serverAPI{
 		finances: finances,
 	})
+	gRPCServer.RegisterService(&attachmentsServiceDesc, &attachmentsAPI{finances: finances})
+	gRPCServer.RegisterService(&categoriesServiceDesc, &categoriesAPI{finances: finances})
 }
 
 func (s *serverAPI) MassiveReport(ctx context.Context, in *financesgrpcsrv.MassiveReportRequest) (*financesgrpcsrv.MassiveReportResponse, error) {
@@ -103,75 +630,165 @@ func (s *serverAPI) MassiveReport(ctx context.Context, in *financesgrpcsrv.Massi
 
 		total, middle, median, report, err := s.finances.Report(ctx, Month, month, year)
 		if err != nil {
-			return nil, status.Errorf(codes.Internal, "failed to get report: %v", err)
+			return nil, StatusError(err)
 		}
 
 		reportResponse := &financesgrpcsrv.ReportResponse{
-			Total: total,
-			Month: fmt.Sprintf("%02d.%04d", month, year),
+			Total:      total,
+			Month:      fmt.Sprintf("%02d.%04d", month, year),
+			Categories: reportCategories(report, total),
+			Average:    middle,
+			Median:     median,
 		}
 
-		var categories []*financesgrpcsrv.ReportCategory // TODO: make this an array
-
-		for _, category := range report {
-			categories = append(categories, &financesgrpcsrv.ReportCategory{
-				Name:    category.Category,
-				Amount:  category.Amount,
-				Percent: category.Amount * 100 / total,
-				Color:   category.Color,
-			})
-		}
-
-		reportResponse.Categories = categories
-		reportResponse.Average = middle
-		reportResponse.Median = median
-
 		response.Monthes = append(response.Monthes, reportResponse)
 	}
 
 	return response, nil
 }
+
+// ExpenseEdit applies the non-zero fields of the request to the expense, so
+// it cannot set the amount to zero or clear the description. ExpenseResponse
+// only tells whether the edit succeeded, so the saved expense is sent in the
+// ExpenseKey response header as a serialized finances.Expense.
 func (s *serverAPI) ExpenseEdit(ctx context.Context, in *financesgrpcsrv.ExpenseEditRequest) (*financesgrpcsrv.ExpenseResponse, error) {
-	// err := s.finances.Expense(ctx, in.Description, in.Amount, in.Date, in.Category)
-	// if err != nil {
-	// 	return nil, status.Error(codes.Internal, err.Error())
-	// }
-	// return &financesgrpcsrv.ExpenseResponse{
-	// 	Description: in.Description,
-	// 	Amount:      in.Amount,
-	// 	Date:        in.Date,
-	// 	Category:    in.Category,
-	// }, nil
+	if err := validateExpenseEdit(in); err != nil {
+		return nil, err
+	}
+
+	e := in.GetExpense()
+
+	saved, err := s.finances.ExpenseEdit(ctx, e.GetId(), e.GetDescription(), e.GetAmount(), e.GetDate(), e.GetCategory())
+	if err != nil {
+		return nil, StatusError(err)
+	}
+
+	body, err := proto.Marshal(toProtoExpense(saved))
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	if err := grpc.SetHeader(ctx, metadata.Pairs(ExpenseKey, string(body))); err != nil {
+		return nil, StatusError(err)
+	}
+
+	return &financesgrpcsrv.ExpenseResponse{Ok: true}, nil
+}
 
-	return nil, nil // TODO: implement this
+func validateExpenseEdit(in *financesgrpcsrv.ExpenseEditRequest) error {
+	e := in.GetExpense()
+	if e == nil {
+		return status.Error(codes.InvalidArgument, "expense is required")
+	}
+
+	if e.GetId() <= 0 {
+		return status.Error(codes.InvalidArgument, "expense id is required")
+	}
+
+	if e.GetDate() != "" {
+		if _, err := time.Parse(time.DateOnly, e.GetDate()); err != nil {
+			return status.Error(codes.InvalidArgument, "date must be in YYYY-MM-DD format")
+		}
+	}
+
+	return nil
+}
+
+// StatusError maps storage errors to gRPC status errors. It is shared with
+// the HTTP handlers so both transports report failures the same way.
+func StatusError(err error) error {
+	switch {
+	case errors.Is(err, ErrUnauthenticated):
+		return status.Error(codes.Unauthenticated, "unauthenticated")
+	case errors.Is(err, ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, storage.ErrExpenseNotFound):
+		return status.Error(codes.NotFound, "expense not found")
+	case errors.Is(err, storage.ErrCategoryNotFound):
+		return status.Error(codes.InvalidArgument, "unknown category")
+	case errors.Is(err, storage.ErrCategoryExists):
+		return status.Error(codes.AlreadyExists, "category already exists")
+	case errors.Is(err, storage.ErrCategoryArchived):
+		return status.Error(codes.FailedPrecondition, "category is archived")
+	case errors.Is(err, ErrInvalidArgument):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, storage.ErrBudgetNotFound):
+		return status.Error(codes.NotFound, "budget not found")
+	case errors.Is(err, storage.ErrRecurringNotFound):
+		return status.Error(codes.NotFound, "recurring expense not found")
+	case errors.Is(err, storage.ErrIncomeNotFound):
+		return status.Error(codes.NotFound, "income not found")
+	case errors.Is(err, storage.ErrIncomeSourceNotFound):
+		return status.Error(codes.InvalidArgument, "unknown income source")
+	case errors.Is(err, storage.ErrIncomeSourceExists):
+		return status.Error(codes.AlreadyExists, "income source already exists")
+	case errors.Is(err, storage.ErrIncomeSourceInUse):
+		return status.Error(codes.FailedPrecondition, "income source has incomes")
+	case errors.Is(err, storage.ErrImportProfileNotFound):
+		return status.Error(codes.NotFound, "import profile not found")
+	case errors.Is(err, storage.ErrRuleNotFound):
+		return status.Error(codes.NotFound, "categorization rule not found")
+	case errors.Is(err, storage.ErrSettlementNotFound):
+		return status.Error(codes.NotFound, "settlement not found")
+	case errors.Is(err, storage.ErrAttachmentNotFound):
+		return status.Error(codes.NotFound, "attachment not found")
+	case errors.Is(err, storage.ErrAccountNotFound):
+		return status.Error(codes.NotFound, "account not found")
+	case errors.Is(err, storage.ErrAccountExists):
+		return status.Error(codes.AlreadyExists, "account already exists")
+	case errors.Is(err, storage.ErrAccountInUse):
+		return status.Error(codes.FailedPrecondition, "account has expenses, incomes or transfers")
+	case errors.Is(err, storage.ErrTransferNotFound):
+		return status.Error(codes.NotFound, "transfer not found")
+	case errors.Is(err, storage.ErrReconciliationNotFound):
+		return status.Error(codes.NotFound, "reconciliation not found")
+	case errors.Is(err, ErrUnreconciled):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, storage.ErrGoalNotFound):
+		return status.Error(codes.NotFound, "goal not found")
+	case errors.Is(err, storage.ErrGoalExists):
+		return status.Error(codes.AlreadyExists, "goal already exists")
+	case errors.Is(err, storage.ErrContributionNotFound):
+		return status.Error(codes.NotFound, "contribution not found")
+	case errors.Is(err, storage.ErrRateNotFound):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
 }
 
 func (s *serverAPI) Report(ctx context.Context, in *financesgrpcsrv.ReportRequest) (*financesgrpcsrv.ReportResponse, error) {
 	nowMonth := int(time.Now().Month())
 	nowYear := time.Now().Year()
 
-	total, _, _, report, err := s.finances.Report(ctx, ReportFilter(fmt.Sprintf("%s", in.GetType())), nowMonth, nowYear)
+	total, middle, median, report, err := s.finances.Report(ctx, ReportFilter(in.GetType().String()), nowMonth, nowYear)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, StatusError(err)
 	}
 
-	var categories []*financesgrpcsrv.ReportCategory // TODO: make this an array
+	rsp := &financesgrpcsrv.ReportResponse{
+		Total:      total,
+		Categories: reportCategories(report, total),
+		Average:    middle,
+		Median:     median,
+	}
+
+	return rsp, nil
+}
+
+func reportCategories(report []CategoryReport, total int64) []*financesgrpcsrv.ReportCategory {
+	categories := make([]*financesgrpcsrv.ReportCategory, 0, len(report))
 
 	for _, category := range report {
 		categories = append(categories, &financesgrpcsrv.ReportCategory{
 			Name:    category.Category,
 			Amount:  category.Amount,
-			Percent: category.Amount * 100 / total,
+			Percent: category.PercentOf(total),
 			Color:   category.Color,
 		})
 	}
 
-	rsp := &financesgrpcsrv.ReportResponse{
-		Total:      total,
-		Categories: categories,
-	}
-
-	return rsp, nil
+	return categories
 }
 
 func (s *serverAPI) Expense(
@@ -188,7 +805,7 @@ func (s *serverAPI) Expense(
 	)
 
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, StatusError(err)
 	}
 
 	return &financesgrpcsrv.ExpenseResponse{}, nil
@@ -199,21 +816,14 @@ func (s *serverAPI) ExpensesList(ctx context.Context, req *financesgrpcsrv.Expen
 	list, totalAmount, err := s.finances.ExpensesList(ctx, req.GetCategory(), req.GetMonth(), req.GetYear())
 
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, StatusError(err)
 	}
 
 	rsp := &financesgrpcsrv.ExpensesListResponse{}
 	respList := make([]*financesgrpcsrv.Expense, 0, len(list))
 
 	for _, expense := range list {
-		respList = append(respList, &financesgrpcsrv.Expense{
-			Id:          expense.ID,
-			Amount:      expense.Amount,
-			Date:        expense.Date,
-			Category:    expense.Category,
-			Description: expense.Description,
-			Color:       expense.Color,
-		})
+		respList = append(respList, toProtoExpense(expense))
 	}
 
 	rsp.Expenses = respList
@@ -222,14 +832,23 @@ func (s *serverAPI) ExpensesList(ctx context.Context, req *financesgrpcsrv.Expen
 	return rsp, nil
 }
 
-//	func (s *serverAPI) CreateCategory(ctx context.Context, category string) (string, error) {
-//		return category, nil
-//	}
+func (s *serverAPI) CreateCategory(ctx context.Context, in *financesgrpcsrv.CreateCategoryRequest) (*financesgrpcsrv.CreateCategoryResponse, error) {
+	name := strings.TrimSpace(in.GetName())
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "category name is required")
+	}
+
+	if _, err := s.finances.CreateCategory(ctx, name, "", ""); err != nil {
+		return nil, StatusError(err)
+	}
+
+	return &financesgrpcsrv.CreateCategoryResponse{Ok: true}, nil
+}
+
 func (s *serverAPI) CategoriesList(ctx context.Context, _ *financesgrpcsrv.CategoriesListRequest) (*financesgrpcsrv.CategoriesListResponse, error) {
 	categories, err := s.finances.CategoriesList(ctx)
-
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, StatusError(err)
 	}
 
 	rsp := &financesgrpcsrv.CategoriesListResponse{
@@ -237,9 +856,22 @@ func (s *serverAPI) CategoriesList(ctx context.Context, _ *financesgrpcsrv.Categ
 	}
 	for _, category := range categories {
 		rsp.Categories = append(rsp.Categories, &financesgrpcsrv.Category{
-			Name: category.Name,
+			Name:  category.Name,
+			Image: category.Icon,
+			Color: category.Color,
 		})
 	}
 
 	return rsp, nil
 }
+
+func toProtoExpense(e Expense) *financesgrpcsrv.Expense {
+	return &financesgrpcsrv.Expense{
+		Id:          e.ID,
+		Amount:      e.Amount,
+		Date:        e.Date,
+		Category:    e.Category,
+		Description: e.Description,
+		Color:       e.Color,
+	}
+}
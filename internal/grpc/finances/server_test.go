@@ -0,0 +1,320 @@
+package financesgrpc_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"testing"
+
+	financesgrpcsrv "github.com/kochnevns/finances-protos/finances"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+	"github.com/kochnevns/finances-backend/internal/storage"
+)
+
+// editFinances edits the stored expense like the service: the non-zero
+// fields replace the stored ones.
+type editFinances struct {
+	financesgrpc.Finances
+	stored financesgrpc.Expense
+}
+
+func (f *editFinances) ExpenseEdit(
+	_ context.Context, id int64, description string, amount int64, date string, category string,
+) (financesgrpc.Expense, error) {
+	saved := f.stored
+	saved.ID = id
+	if description != "" {
+		saved.Description = description
+	}
+	if amount != 0 {
+		saved.Amount = amount
+	}
+	if date != "" {
+		saved.Date = date
+	}
+	if category != "" {
+		saved.Category = category
+	}
+
+	return saved, nil
+}
+
+// dial serves the finances over an in-memory connection.
+func dial(t *testing.T, finances financesgrpc.Finances) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	financesgrpc.Register(server, finances)
+	go server.Serve(lis) // nolint: errcheck
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() }) // nolint: errcheck
+
+	return conn
+}
+
+func TestExpenseEdit_SendsSavedExpense(t *testing.T) {
+	conn := dial(t, &editFinances{stored: financesgrpc.Expense{
+		Description: "обед", Amount: 300, Date: "2024-04-03", Category: "Моти", Color: "#fff",
+	}})
+
+	var header metadata.MD
+	rsp, err := financesgrpcsrv.NewFinancesClient(conn).ExpenseEdit(context.Background(),
+		&financesgrpcsrv.ExpenseEditRequest{Expense: &financesgrpcsrv.Expense{Id: 5, Amount: 450}},
+		grpc.Header(&header),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rsp.GetOk() {
+		t.Fatal("not ok")
+	}
+
+	values := header.Get(financesgrpc.ExpenseKey)
+	if len(values) != 1 {
+		t.Fatalf("%s header: %v", financesgrpc.ExpenseKey, values)
+	}
+
+	var saved financesgrpcsrv.Expense
+	if err := proto.Unmarshal([]byte(values[0]), &saved); err != nil {
+		t.Fatal(err)
+	}
+
+	want := &financesgrpcsrv.Expense{Id: 5, Amount: 450, Description: "обед", Date: "2024-04-03", Category: "Моти", Color: "#fff"}
+	if !proto.Equal(&saved, want) {
+		t.Errorf("saved expense = %v, want %v", &saved, want)
+	}
+}
+
+func TestCategoryReport_PercentOf(t *testing.T) {
+	for _, tc := range []struct {
+		amount, total, want int64
+	}{
+		{amount: 250, total: 1000, want: 25},
+		{amount: 333, total: 1000, want: 33},
+		{amount: 1200, total: 1000, want: 120},
+		{amount: 100, total: 0, want: 0},
+	} {
+		c := financesgrpc.CategoryReport{Amount: tc.amount}
+		if got := c.PercentOf(tc.total); got != tc.want {
+			t.Errorf("%d of %d: %d%%, want %d%%", tc.amount, tc.total, got, tc.want)
+		}
+	}
+}
+
+func TestCategoryReport_OverBudget(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		c    financesgrpc.CategoryReport
+		want bool
+	}{
+		{"no budget", financesgrpc.CategoryReport{Amount: 100}, false},
+		{"within", financesgrpc.CategoryReport{Amount: 100, Budget: 300, Remaining: 200}, false},
+		{"spent exactly", financesgrpc.CategoryReport{Amount: 300, Budget: 300}, false},
+		{"over", financesgrpc.CategoryReport{Amount: 301, Budget: 300, Remaining: -1}, true},
+	} {
+		if got := tc.c.OverBudget(); got != tc.want {
+			t.Errorf("%s: %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// categoriesFinances keeps categories like the service: archived ones are
+// not listed and merged ones are gone.
+type categoriesFinances struct {
+	financesgrpc.Finances
+	categories []financesgrpc.Category
+	archived   map[int64]bool
+}
+
+func (f *categoriesFinances) find(id int64) (int, error) {
+	i := slices.IndexFunc(f.categories, func(c financesgrpc.Category) bool { return c.ID == id })
+	if i < 0 {
+		return 0, storage.ErrCategoryNotFound
+	}
+
+	return i, nil
+}
+
+func (f *categoriesFinances) CategoriesList(context.Context) ([]financesgrpc.Category, error) {
+	var list []financesgrpc.Category
+	for _, c := range f.categories {
+		if !f.archived[c.ID] {
+			list = append(list, c)
+		}
+	}
+
+	return list, nil
+}
+
+func (f *categoriesFinances) EditCategory(_ context.Context, id int64, name, icon, color string) (financesgrpc.Category, error) {
+	i, err := f.find(id)
+	if err != nil {
+		return financesgrpc.Category{}, err
+	}
+
+	c := &f.categories[i]
+	if name != "" {
+		c.Name = name
+	}
+	if icon != "" {
+		c.Icon = icon
+	}
+	if color != "" {
+		c.Color = color
+	}
+
+	return *c, nil
+}
+
+func (f *categoriesFinances) ArchiveCategory(_ context.Context, id int64) error {
+	if _, err := f.find(id); err != nil {
+		return err
+	}
+	f.archived[id] = true
+
+	return nil
+}
+
+func (f *categoriesFinances) UnarchiveCategory(_ context.Context, id int64) error {
+	if _, err := f.find(id); err != nil {
+		return err
+	}
+	delete(f.archived, id)
+
+	return nil
+}
+
+func (f *categoriesFinances) MergeCategories(_ context.Context, fromID, toID int64) error {
+	if _, err := f.find(toID); err != nil {
+		return err
+	}
+	i, err := f.find(fromID)
+	if err != nil {
+		return err
+	}
+	f.categories = slices.Delete(f.categories, i, i+1)
+
+	return nil
+}
+
+func TestCategoriesService(t *testing.T) {
+	conn := dial(t, &categoriesFinances{
+		categories: []financesgrpc.Category{
+			{ID: 1, Name: "Моти", Color: "#fff"},
+			{ID: 2, Name: "Такси"},
+			{ID: 3, Name: "Кафе"},
+		},
+		archived: map[int64]bool{},
+	})
+	ctx := context.Background()
+
+	call := func(method string, in, out proto.Message) error {
+		return conn.Invoke(ctx, "/finances.Categories/"+method, in, out)
+	}
+	list := func() []string {
+		t.Helper()
+
+		rsp := &structpb.Struct{}
+		if err := call("List", &emptypb.Empty{}, rsp); err != nil {
+			t.Fatal(err)
+		}
+
+		var names []string
+		for _, c := range rsp.GetFields()["categories"].GetListValue().GetValues() {
+			fields := c.GetStructValue().GetFields()
+			names = append(names, fmt.Sprintf("%v %s", fields["id"].GetNumberValue(), fields["name"].GetStringValue()))
+		}
+
+		return names
+	}
+
+	if got, want := list(), []string{"1 Моти", "2 Такси", "3 Кафе"}; !slices.Equal(got, want) {
+		t.Errorf("listed %v, want %v", got, want)
+	}
+
+	edit, _ := structpb.NewStruct(map[string]any{"id": 1, "name": " Кошка "})
+	edited := &structpb.Struct{}
+	if err := call("Edit", edit, edited); err != nil {
+		t.Fatal(err)
+	}
+	if f := edited.GetFields(); f["name"].GetStringValue() != "Кошка" || f["color"].GetStringValue() != "#fff" {
+		t.Errorf("edited: %v", edited)
+	}
+
+	if err := call("Archive", wrapperspb.Int64(2), &emptypb.Empty{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := list(), []string{"1 Кошка", "3 Кафе"}; !slices.Equal(got, want) {
+		t.Errorf("listed after archiving %v, want %v", got, want)
+	}
+	if err := call("Unarchive", wrapperspb.Int64(2), &emptypb.Empty{}); err != nil {
+		t.Fatal(err)
+	}
+
+	merge, _ := structpb.NewStruct(map[string]any{"fromId": 3, "toId": 1})
+	if err := call("Merge", merge, &emptypb.Empty{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := list(), []string{"1 Кошка", "2 Такси"}; !slices.Equal(got, want) {
+		t.Errorf("listed after merging %v, want %v", got, want)
+	}
+
+	into, _ := structpb.NewStruct(map[string]any{"fromId": 2, "toId": 2})
+	missing, _ := structpb.NewStruct(map[string]any{"id": 9, "name": "Нет"})
+	for name, tc := range map[string]struct {
+		method string
+		in     proto.Message
+		want   codes.Code
+	}{
+		"edit without id":    {"Edit", &structpb.Struct{}, codes.InvalidArgument},
+		"edit missing":       {"Edit", missing, codes.InvalidArgument},
+		"archive without id": {"Archive", wrapperspb.Int64(0), codes.InvalidArgument},
+		"unarchive merged":   {"Unarchive", wrapperspb.Int64(3), codes.InvalidArgument},
+		"merge into itself":  {"Merge", into, codes.InvalidArgument},
+		"merge without ids":  {"Merge", &structpb.Struct{}, codes.InvalidArgument},
+	} {
+		if err := call(tc.method, tc.in, &structpb.Struct{}); status.Code(err) != tc.want {
+			t.Errorf("%s: %v, want %s", name, err, tc.want)
+		}
+	}
+}
+
+// anonymousFinances refuses everything like the service does for calls
+// without a user.
+type anonymousFinances struct {
+	financesgrpc.Finances
+}
+
+func (anonymousFinances) CategoriesList(context.Context) ([]financesgrpc.Category, error) {
+	return nil, financesgrpc.ErrUnauthenticated
+}
+
+func TestCategoriesList_MapsErrors(t *testing.T) {
+	conn := dial(t, anonymousFinances{})
+
+	_, err := financesgrpcsrv.NewFinancesClient(conn).CategoriesList(context.Background(), &financesgrpcsrv.CategoriesListRequest{})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("err = %v, want Unauthenticated", err)
+	}
+}
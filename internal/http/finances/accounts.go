@@ -0,0 +1,225 @@
+package financeshttp
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+)
+
+// account creates an account when ID is zero and otherwise replaces its
+// name, opening balance and date.
+type account struct {
+	ID             int64  `json:"id"`
+	Name           string `json:"name"`
+	Currency       string `json:"currency"` // ISO 4217, the base currency when empty
+	OpeningBalance int64  `json:"openingBalance"`
+	OpenedOn       string `json:"openedOn"` // YYYY-MM-DD, today when empty
+	Balance        int64  `json:"balance"`  // current, ignored when saving
+}
+
+type transfer struct {
+	ID          int64  `json:"id"`
+	Date        string `json:"date"` // YYYY-MM-DD, today when empty
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Amount      int64  `json:"amount"`
+	ToAmount    int64  `json:"toAmount"` // required between accounts in different currencies
+	Description string `json:"description"`
+}
+
+type balancePoint struct {
+	Date    string `json:"date"`
+	Change  int64  `json:"change"`
+	Balance int64  `json:"balance"`
+}
+
+type accountIDRequest struct {
+	ID int64 `json:"id"`
+}
+
+type accountsListRequest struct{}
+
+type accountsListResponse struct {
+	Accounts []account `json:"accounts"`
+}
+
+type accountHistoryRequest struct {
+	ID   int64  `json:"id"`
+	From string `json:"from"` // YYYY-MM-DD, inclusive
+	To   string `json:"to"`   // YYYY-MM-DD, inclusive
+}
+
+type accountHistoryResponse struct {
+	Points []balancePoint `json:"points"`
+}
+
+type transferIDRequest struct {
+	ID int64 `json:"id"`
+}
+
+type transfersListRequest struct {
+	From string `json:"from"` // YYYY-MM-DD, inclusive
+	To   string `json:"to"`   // YYYY-MM-DD, inclusive
+}
+
+type transfersListResponse struct {
+	Transfers []transfer `json:"transfers"` // the latest first
+}
+
+func (h *handlers) accountSave(ctx context.Context, req *account) (*account, error) {
+	if req.ID < 0 {
+		return nil, status.Error(codes.InvalidArgument, "account id must not be negative")
+	}
+
+	saved, err := h.finances.SaveAccount(ctx, financesgrpc.Account{
+		ID:             req.ID,
+		Name:           req.Name,
+		Currency:       req.Currency,
+		OpeningBalance: req.OpeningBalance,
+		OpenedOn:       req.OpenedOn,
+	})
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := toAccount(saved)
+
+	return &rsp, nil
+}
+
+func (h *handlers) accountDelete(ctx context.Context, req *accountIDRequest) (*okResponse, error) {
+	if req.ID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "account id is required")
+	}
+
+	if err := h.finances.DeleteAccount(ctx, req.ID); err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &okResponse{Ok: true}, nil
+}
+
+func (h *handlers) accountsList(ctx context.Context, _ *accountsListRequest) (*accountsListResponse, error) {
+	list, err := h.finances.Accounts(ctx)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := &accountsListResponse{Accounts: make([]account, 0, len(list))}
+	for _, a := range list {
+		rsp.Accounts = append(rsp.Accounts, toAccount(a))
+	}
+
+	return rsp, nil
+}
+
+func (h *handlers) accountHistory(ctx context.Context, req *accountHistoryRequest) (*accountHistoryResponse, error) {
+	if req.ID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "account id is required")
+	}
+
+	from, to, err := parseRange(req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := h.finances.AccountHistory(ctx, req.ID, from, to)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := &accountHistoryResponse{Points: make([]balancePoint, 0, len(points))}
+	for _, p := range points {
+		rsp.Points = append(rsp.Points, balancePoint{Date: p.Date, Change: p.Change, Balance: p.Balance})
+	}
+
+	return rsp, nil
+}
+
+func (h *handlers) transferSave(ctx context.Context, req *transfer) (*transfer, error) {
+	if req.ID != 0 {
+		return nil, status.Error(codes.InvalidArgument, "transfers are recorded anew, delete the wrong one instead")
+	}
+
+	saved, err := h.finances.SaveTransfer(ctx, financesgrpc.Transfer{
+		Date:        req.Date,
+		From:        req.From,
+		To:          req.To,
+		Amount:      req.Amount,
+		ToAmount:    req.ToAmount,
+		Description: req.Description,
+	})
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := toTransfer(saved)
+
+	return &rsp, nil
+}
+
+func (h *handlers) transferDelete(ctx context.Context, req *transferIDRequest) (*okResponse, error) {
+	if req.ID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "transfer id is required")
+	}
+
+	if err := h.finances.DeleteTransfer(ctx, req.ID); err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &okResponse{Ok: true}, nil
+}
+
+func (h *handlers) transfersList(ctx context.Context, req *transfersListRequest) (*transfersListResponse, error) {
+	from, to, err := parseRange(req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := h.finances.Transfers(ctx, from, to)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := &transfersListResponse{Transfers: make([]transfer, 0, len(list))}
+	for _, t := range list {
+		rsp.Transfers = append(rsp.Transfers, toTransfer(t))
+	}
+
+	return rsp, nil
+}
+
+func toAccount(a financesgrpc.Account) account {
+	return account{
+		ID:             a.ID,
+		Name:           a.Name,
+		Currency:       a.Currency,
+		OpeningBalance: a.OpeningBalance,
+		OpenedOn:       a.OpenedOn,
+		Balance:        a.Balance,
+	}
+}
+
+func toTransfer(t financesgrpc.Transfer) transfer {
+	return transfer{
+		ID:          t.ID,
+		Date:        t.Date,
+		From:        t.From,
+		To:          t.To,
+		Amount:      t.Amount,
+		ToAmount:    t.ToAmount,
+		Description: t.Description,
+	}
+}
+
+// accountName returns the name of an account link, empty for none.
+func accountName(name *string) string {
+	if name == nil {
+		return ""
+	}
+
+	return *name
+}
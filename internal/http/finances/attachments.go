@@ -0,0 +1,182 @@
+package financeshttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+)
+
+type attachment struct {
+	ID          int64  `json:"id"`
+	ExpenseID   int64  `json:"expenseId"`
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+type attachmentIDRequest struct {
+	ID int64 `json:"id"`
+}
+
+type attachmentsListRequest struct {
+	ExpenseID int64 `json:"expenseId"`
+}
+
+type attachmentsListResponse struct {
+	Attachments []attachment `json:"attachments"`
+}
+
+// attachmentUpload reads a multipart/form-data request with the expenseId
+// field followed by the file field. The file is streamed to the store
+// without buffering the request.
+func (h *handlers) attachmentUpload(mux *runtime.ServeMux) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		ctx := r.Context()
+		_, outbound := runtime.MarshalerForRequest(mux, r)
+
+		fail := func(err error) {
+			runtime.HTTPError(ctx, mux, outbound, w, r, err)
+		}
+
+		form, err := r.MultipartReader()
+		if err != nil {
+			fail(status.Error(codes.InvalidArgument, "multipart/form-data request is required"))
+			return
+		}
+
+		var expenseID int64
+		for {
+			part, err := form.NextPart()
+			if errors.Is(err, io.EOF) {
+				fail(status.Error(codes.InvalidArgument, "file is required"))
+				return
+			}
+			if err != nil {
+				fail(status.Error(codes.InvalidArgument, err.Error()))
+				return
+			}
+
+			switch part.FormName() {
+			case "expenseId":
+				value, err := io.ReadAll(io.LimitReader(part, 32))
+				if err == nil {
+					expenseID, err = strconv.ParseInt(string(value), 10, 64)
+				}
+				if err != nil || expenseID <= 0 {
+					fail(status.Error(codes.InvalidArgument, "expenseId must be a positive number"))
+					return
+				}
+			case "file":
+				if expenseID == 0 {
+					fail(status.Error(codes.InvalidArgument, "expenseId must come before the file"))
+					return
+				}
+
+				saved, err := h.finances.UploadAttachment(ctx, expenseID, part.FileName(), part)
+				if err != nil {
+					fail(financesgrpc.StatusError(err))
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(toAttachment(saved))
+
+				return
+			}
+		}
+	}
+}
+
+// attachmentDownload sends the file of the attachment named in the JSON
+// body.
+func (h *handlers) attachmentDownload(mux *runtime.ServeMux) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		ctx := r.Context()
+		_, outbound := runtime.MarshalerForRequest(mux, r)
+
+		fail := func(err error) {
+			runtime.HTTPError(ctx, mux, outbound, w, r, err)
+		}
+
+		var req attachmentIDRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			fail(status.Error(codes.InvalidArgument, err.Error()))
+			return
+		}
+
+		if req.ID <= 0 {
+			fail(status.Error(codes.InvalidArgument, "attachment id is required"))
+			return
+		}
+
+		a, content, err := h.finances.OpenAttachment(ctx, req.ID)
+		if err != nil {
+			fail(financesgrpc.StatusError(err))
+			return
+		}
+		defer content.Close() // nolint: errcheck
+
+		w.Header().Set("Content-Type", a.ContentType)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Content-Length", strconv.FormatInt(a.Size, 10))
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Name}))
+
+		if _, err := io.Copy(w, content); err != nil {
+			// The status is sent already; dropping the connection tells the
+			// client that the file is incomplete.
+			panic(http.ErrAbortHandler)
+		}
+	}
+}
+
+func (h *handlers) attachmentsList(ctx context.Context, req *attachmentsListRequest) (*attachmentsListResponse, error) {
+	if req.ExpenseID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "expense id is required")
+	}
+
+	list, err := h.finances.Attachments(ctx, req.ExpenseID)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := &attachmentsListResponse{Attachments: make([]attachment, 0, len(list))}
+	for _, a := range list {
+		rsp.Attachments = append(rsp.Attachments, toAttachment(a))
+	}
+
+	return rsp, nil
+}
+
+func (h *handlers) attachmentDelete(ctx context.Context, req *attachmentIDRequest) (*okResponse, error) {
+	if req.ID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "attachment id is required")
+	}
+
+	if err := h.finances.DeleteAttachment(ctx, req.ID); err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &okResponse{Ok: true}, nil
+}
+
+func toAttachment(a financesgrpc.Attachment) attachment {
+	return attachment{
+		ID:          a.ID,
+		ExpenseID:   a.ExpenseID,
+		Name:        a.Name,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		CreatedAt:   a.CreatedAt,
+	}
+}
@@ -0,0 +1,84 @@
+package financeshttp
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+)
+
+type budget struct {
+	CategoryID   int64  `json:"categoryId"`
+	Category     string `json:"category"`
+	Color        string `json:"color"`
+	Month        string `json:"month"` // YYYY-MM the budget was set for
+	Amount       int64  `json:"amount"`
+	RollsForward bool   `json:"rollsForward"`
+}
+
+type budgetSetRequest struct {
+	CategoryID   int64  `json:"categoryId"`
+	Month        string `json:"month"` // YYYY-MM
+	Amount       int64  `json:"amount"`
+	RollsForward bool   `json:"rollsForward"`
+}
+
+type budgetDeleteRequest struct {
+	CategoryID int64  `json:"categoryId"`
+	Month      string `json:"month"` // YYYY-MM
+}
+
+type budgetsListRequest struct {
+	Month string `json:"month"` // YYYY-MM
+}
+
+type budgetsListResponse struct {
+	Budgets []budget `json:"budgets"`
+}
+
+func (h *handlers) budgetSet(ctx context.Context, req *budgetSetRequest) (*okResponse, error) {
+	if req.CategoryID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "category id is required")
+	}
+
+	if err := h.finances.SetBudget(ctx, req.CategoryID, req.Month, req.Amount, req.RollsForward); err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &okResponse{Ok: true}, nil
+}
+
+func (h *handlers) budgetDelete(ctx context.Context, req *budgetDeleteRequest) (*okResponse, error) {
+	if req.CategoryID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "category id is required")
+	}
+
+	if err := h.finances.DeleteBudget(ctx, req.CategoryID, req.Month); err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &okResponse{Ok: true}, nil
+}
+
+func (h *handlers) budgetsList(ctx context.Context, req *budgetsListRequest) (*budgetsListResponse, error) {
+	budgets, err := h.finances.Budgets(ctx, req.Month)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := &budgetsListResponse{Budgets: make([]budget, 0, len(budgets))}
+	for _, b := range budgets {
+		rsp.Budgets = append(rsp.Budgets, budget{
+			CategoryID:   b.CategoryID,
+			Category:     b.Category,
+			Color:        b.Color,
+			Month:        b.Month,
+			Amount:       b.Amount,
+			RollsForward: b.RollsForward,
+		})
+	}
+
+	return rsp, nil
+}
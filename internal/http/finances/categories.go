@@ -0,0 +1,87 @@
+package financeshttp
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+)
+
+type category struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Icon  string `json:"icon"`
+	Color string `json:"color"`
+}
+
+type categoryIDRequest struct {
+	ID int64 `json:"id"`
+}
+
+type editCategoryRequest struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Icon  string `json:"icon"`
+	Color string `json:"color"`
+}
+
+type mergeCategoriesRequest struct {
+	FromID int64 `json:"fromId"`
+	ToID   int64 `json:"toId"`
+}
+
+func (h *handlers) editCategory(ctx context.Context, req *editCategoryRequest) (*category, error) {
+	if req.ID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "category id is required")
+	}
+
+	c, err := h.finances.EditCategory(ctx, req.ID, strings.TrimSpace(req.Name), req.Icon, req.Color)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &category{ID: c.ID, Name: c.Name, Icon: c.Icon, Color: c.Color}, nil
+}
+
+func (h *handlers) archiveCategory(ctx context.Context, req *categoryIDRequest) (*okResponse, error) {
+	if req.ID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "category id is required")
+	}
+
+	if err := h.finances.ArchiveCategory(ctx, req.ID); err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &okResponse{Ok: true}, nil
+}
+
+func (h *handlers) unarchiveCategory(ctx context.Context, req *categoryIDRequest) (*okResponse, error) {
+	if req.ID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "category id is required")
+	}
+
+	if err := h.finances.UnarchiveCategory(ctx, req.ID); err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &okResponse{Ok: true}, nil
+}
+
+func (h *handlers) mergeCategories(ctx context.Context, req *mergeCategoriesRequest) (*okResponse, error) {
+	if req.FromID <= 0 || req.ToID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "both category ids are required")
+	}
+
+	if req.FromID == req.ToID {
+		return nil, status.Error(codes.InvalidArgument, "cannot merge a category into itself")
+	}
+
+	if err := h.finances.MergeCategories(ctx, req.FromID, req.ToID); err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &okResponse{Ok: true}, nil
+}
@@ -0,0 +1,141 @@
+package financeshttp
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+)
+
+const maxPageSize = 500
+
+// expenseSaveRequest creates an expense when ID is zero and otherwise
+// updates its non-zero fields.
+type expenseSaveRequest struct {
+	ID          int64  `json:"id"`
+	Amount      int64  `json:"amount"`
+	Currency    string `json:"currency"` // ISO 4217, the base currency when empty
+	Description string `json:"description"`
+	Category    string `json:"category"`
+	Date        string `json:"date"` // YYYY-MM-DD
+	// Tags replace the recorded ones when present, [] removes them all.
+	Tags []string `json:"tags"`
+	// Splits replace the line items when present and must add up to the
+	// amount, [] makes the expense a single item again.
+	Splits []split `json:"splits"`
+	// Sharing replaces how the expense is shared when present.
+	Sharing *sharing `json:"sharing"`
+	// Account is the account paid from, replaced when present; "" unlinks it.
+	Account *string `json:"account"`
+}
+
+type expensesSearchRequest struct {
+	Categories  []string `json:"categories"`
+	CategoryIDs []int64  `json:"categoryIds"`
+	Tags        []string `json:"tags"` // any of them matches
+	From        string   `json:"from"` // YYYY-MM-DD, inclusive, optional
+	To          string   `json:"to"`   // YYYY-MM-DD, inclusive, optional
+	MinAmount   int64    `json:"minAmount"`
+	MaxAmount   int64    `json:"maxAmount"`
+	Search      string   `json:"search"`
+	SortBy      string   `json:"sortBy"` // "date" or "amount"
+	Ascending   bool     `json:"ascending"`
+	Cursor      string   `json:"cursor"`
+	Limit       int      `json:"limit"`
+}
+
+type expensesSearchResponse struct {
+	Expenses   []expense `json:"expenses"`
+	Total      int64     `json:"total"`
+	NextCursor string    `json:"nextCursor,omitempty"`
+}
+
+// expenseSave creates the expense when the ID is zero and otherwise edits it
+// like the ExpenseEdit RPC: only the given non-zero fields change, so an edit
+// cannot set the amount to zero or clear the description. Unlike the RPC it
+// answers with the saved expense in the body.
+func (h *handlers) expenseSave(ctx context.Context, req *expenseSaveRequest) (*expense, error) {
+	if req.ID < 0 {
+		return nil, status.Error(codes.InvalidArgument, "expense id must not be negative")
+	}
+
+	// The category of a new expense may be left to the categorization rules.
+	if req.ID == 0 && (req.Amount == 0 || req.Date == "") {
+		return nil, status.Error(codes.InvalidArgument, "amount and date are required")
+	}
+
+	if req.Date != "" {
+		if _, err := time.Parse(time.DateOnly, req.Date); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "date must be in YYYY-MM-DD format")
+		}
+	}
+
+	saved, err := h.finances.SaveExpense(ctx, financesgrpc.Expense{
+		ID:          req.ID,
+		Amount:      req.Amount,
+		Currency:    req.Currency,
+		Description: req.Description,
+		Category:    req.Category,
+		Date:        req.Date,
+		Tags:        req.Tags,
+		Splits:      fromSplits(req.Splits),
+		Sharing:     fromSharing(req.Sharing),
+		Account:     req.Account,
+	})
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := toExpense(saved)
+
+	return &rsp, nil
+}
+
+func (h *handlers) expensesSearch(ctx context.Context, req *expensesSearchRequest) (*expensesSearchResponse, error) {
+	query := financesgrpc.ExpensesQuery{
+		Categories:  req.Categories,
+		CategoryIDs: req.CategoryIDs,
+		Tags:        req.Tags,
+		MinAmount:   req.MinAmount,
+		MaxAmount:   req.MaxAmount,
+		Search:      req.Search,
+		SortBy:      req.SortBy,
+		Ascending:   req.Ascending,
+		Cursor:      req.Cursor,
+		Limit:       req.Limit,
+	}
+
+	var err error
+	if req.From != "" {
+		if query.From, err = time.Parse(time.DateOnly, req.From); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "from must be in YYYY-MM-DD format")
+		}
+	}
+	if req.To != "" {
+		if query.To, err = time.Parse(time.DateOnly, req.To); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "to must be in YYYY-MM-DD format")
+		}
+	}
+
+	if req.Limit < 0 || req.Limit > maxPageSize {
+		return nil, status.Errorf(codes.InvalidArgument, "limit must be between 0 and %d", maxPageSize)
+	}
+
+	if req.MaxAmount != 0 && req.MaxAmount < req.MinAmount {
+		return nil, status.Error(codes.InvalidArgument, "maxAmount must not be less than minAmount")
+	}
+
+	list, total, next, err := h.finances.SearchExpenses(ctx, query)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &expensesSearchResponse{
+		Expenses:   toExpenses(list),
+		Total:      total,
+		NextCursor: next,
+	}, nil
+}
@@ -0,0 +1,91 @@
+package financeshttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+	"github.com/kochnevns/finances-backend/internal/lib/export"
+)
+
+type expensesExportRequest struct {
+	From       string   `json:"from"`       // YYYY-MM-DD, inclusive
+	To         string   `json:"to"`         // YYYY-MM-DD, inclusive
+	Categories []string `json:"categories"` // all categories when empty
+	Format     string   `json:"format"`     // "csv" (default), "json" or "xlsx"
+}
+
+// expensesExport streams the export as a file download instead of a JSON
+// response. CSV exports can be imported again with the "export" profile,
+// which restores all but tags and accounts. Line items, sharing and
+// attachments are not exported.
+func (h *handlers) expensesExport(mux *runtime.ServeMux) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		ctx := r.Context()
+		_, outbound := runtime.MarshalerForRequest(mux, r)
+
+		fail := func(err error) {
+			runtime.HTTPError(ctx, mux, outbound, w, r, err)
+		}
+
+		var req expensesExportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			fail(status.Error(codes.InvalidArgument, err.Error()))
+			return
+		}
+
+		from, to, err := parseRange(req.From, req.To)
+		if err != nil {
+			fail(err)
+			return
+		}
+
+		if req.Format == "" {
+			req.Format = export.CSV
+		}
+
+		contentType, ok := export.ContentTypes[req.Format]
+		if !ok {
+			fail(status.Error(codes.InvalidArgument, "format must be csv, json or xlsx"))
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(
+			`attachment; filename="expenses-%s-%s.%s"`, req.From, req.To, req.Format,
+		))
+
+		out := &countingWriter{w: w}
+		if err := h.finances.ExportExpenses(ctx, out, req.Format, from, to, req.Categories); err != nil {
+			if out.n == 0 {
+				w.Header().Del("Content-Disposition")
+				fail(financesgrpc.StatusError(err))
+				return
+			}
+
+			// The status is sent already; dropping the connection tells the
+			// client that the file is incomplete.
+			panic(http.ErrAbortHandler)
+		}
+	}
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+
+	return n, err
+}
@@ -0,0 +1,210 @@
+package financeshttp
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+)
+
+// goal creates a goal when ID is zero and otherwise replaces its name,
+// target, deadline and account.
+type goal struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Target   int64  `json:"target"`
+	Currency string `json:"currency"` // ISO 4217, that of the account or the base currency when empty
+	Deadline string `json:"deadline"` // YYYY-MM-DD, optional
+	Account  string `json:"account"`  // where the savings are kept, optional; only sets the currency
+	Saved    int64  `json:"saved"`    // the sum of the contributions, ignored when saving
+}
+
+type contribution struct {
+	ID     int64  `json:"id"`
+	GoalID int64  `json:"goalId"`
+	Date   string `json:"date"`   // YYYY-MM-DD, today when empty
+	Amount int64  `json:"amount"` // negative when taken back
+	Note   string `json:"note"`
+}
+
+type goalIDRequest struct {
+	ID int64 `json:"id"`
+}
+
+type goalsListRequest struct{}
+
+type goalsListResponse struct {
+	Goals []goal `json:"goals"` // the nearest deadline first
+}
+
+type goalProgressResponse struct {
+	Goal      goal    `json:"goal"`
+	Saved     int64   `json:"saved"`
+	Remaining int64   `json:"remaining"`
+	Percent   float64 `json:"percent"`
+	// MonthlyRequired is what is left to save per month to meet the deadline.
+	MonthlyRequired int64  `json:"monthlyRequired"`
+	MonthlyPace     int64  `json:"monthlyPace"`           // saved per month recently
+	ProjectedOn     string `json:"projectedOn,omitempty"` // YYYY-MM-DD at the pace
+	OnTrack         bool   `json:"onTrack"`
+}
+
+type contributionIDRequest struct {
+	ID int64 `json:"id"`
+}
+
+type contributionsListRequest struct {
+	GoalID int64 `json:"goalId"`
+}
+
+type contributionsListResponse struct {
+	Contributions []contribution `json:"contributions"` // the oldest first
+}
+
+func (h *handlers) goalSave(ctx context.Context, req *goal) (*goal, error) {
+	if req.ID < 0 {
+		return nil, status.Error(codes.InvalidArgument, "goal id must not be negative")
+	}
+
+	saved, err := h.finances.SaveGoal(ctx, financesgrpc.Goal{
+		ID:       req.ID,
+		Name:     req.Name,
+		Target:   req.Target,
+		Currency: req.Currency,
+		Deadline: req.Deadline,
+		Account:  req.Account,
+	})
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := toGoal(saved)
+
+	return &rsp, nil
+}
+
+func (h *handlers) goalDelete(ctx context.Context, req *goalIDRequest) (*okResponse, error) {
+	if req.ID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "goal id is required")
+	}
+
+	if err := h.finances.DeleteGoal(ctx, req.ID); err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &okResponse{Ok: true}, nil
+}
+
+func (h *handlers) goalsList(ctx context.Context, _ *goalsListRequest) (*goalsListResponse, error) {
+	list, err := h.finances.Goals(ctx)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := &goalsListResponse{Goals: make([]goal, 0, len(list))}
+	for _, g := range list {
+		rsp.Goals = append(rsp.Goals, toGoal(g))
+	}
+
+	return rsp, nil
+}
+
+func (h *handlers) goalProgress(ctx context.Context, req *goalIDRequest) (*goalProgressResponse, error) {
+	if req.ID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "goal id is required")
+	}
+
+	g, p, err := h.finances.GoalProgress(ctx, req.ID)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &goalProgressResponse{
+		Goal:            toGoal(g),
+		Saved:           p.Saved,
+		Remaining:       p.Remaining,
+		Percent:         p.Percent,
+		MonthlyRequired: p.MonthlyRequired,
+		MonthlyPace:     p.MonthlyPace,
+		ProjectedOn:     p.ProjectedOn,
+		OnTrack:         p.OnTrack,
+	}, nil
+}
+
+func (h *handlers) goalContribute(ctx context.Context, req *contribution) (*contribution, error) {
+	if req.ID != 0 {
+		return nil, status.Error(codes.InvalidArgument, "contributions are recorded anew, delete the wrong one instead")
+	}
+
+	if req.GoalID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "goal id is required")
+	}
+
+	saved, err := h.finances.Contribute(ctx, financesgrpc.Contribution{
+		GoalID: req.GoalID,
+		Date:   req.Date,
+		Amount: req.Amount,
+		Note:   req.Note,
+	})
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := toContribution(saved)
+
+	return &rsp, nil
+}
+
+func (h *handlers) contributionDelete(ctx context.Context, req *contributionIDRequest) (*okResponse, error) {
+	if req.ID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "contribution id is required")
+	}
+
+	if err := h.finances.DeleteContribution(ctx, req.ID); err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &okResponse{Ok: true}, nil
+}
+
+func (h *handlers) contributionsList(ctx context.Context, req *contributionsListRequest) (*contributionsListResponse, error) {
+	if req.GoalID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "goal id is required")
+	}
+
+	list, err := h.finances.Contributions(ctx, req.GoalID)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := &contributionsListResponse{Contributions: make([]contribution, 0, len(list))}
+	for _, c := range list {
+		rsp.Contributions = append(rsp.Contributions, toContribution(c))
+	}
+
+	return rsp, nil
+}
+
+func toGoal(g financesgrpc.Goal) goal {
+	return goal{
+		ID:       g.ID,
+		Name:     g.Name,
+		Target:   g.Target,
+		Currency: g.Currency,
+		Deadline: g.Deadline,
+		Account:  g.Account,
+		Saved:    g.Saved,
+	}
+}
+
+func toContribution(c financesgrpc.Contribution) contribution {
+	return contribution{
+		ID:     c.ID,
+		GoalID: c.GoalID,
+		Date:   c.Date,
+		Amount: c.Amount,
+		Note:   c.Note,
+	}
+}
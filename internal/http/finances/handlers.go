@@ -0,0 +1,240 @@
+// Package financeshttp serves finances operations that are not part of the
+// finances-protos gRPC contract yet. Routes follow the gateway naming
+// (POST /finances.Finances/<Method>) and take and return JSON bodies.
+package financeshttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	authgrpc "github.com/kochnevns/finances-backend/internal/grpc/auth"
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+)
+
+const prefix = "/finances.Finances/"
+
+type handlers struct {
+	finances financesgrpc.Finances
+}
+
+// Register registers finances HTTP handlers on the gateway mux. Requests are
+// authenticated the same way the gRPC server authenticates calls.
+func Register(mux *runtime.ServeMux, finances financesgrpc.Finances, auth *authgrpc.Authenticator) error {
+	h := &handlers{finances: finances}
+
+	routes := map[string]runtime.HandlerFunc{
+		"ExpenseSave":    rpc(mux, h.expenseSave),
+		"ExpensesSearch": rpc(mux, h.expensesSearch),
+		"ExpenseDelete":  rpc(mux, h.expenseDelete),
+		"ExpenseRestore": rpc(mux, h.expenseRestore),
+		"TrashList":      rpc(mux, h.trashList),
+
+		"EditCategory":      rpc(mux, h.editCategory),
+		"ArchiveCategory":   rpc(mux, h.archiveCategory),
+		"UnarchiveCategory": rpc(mux, h.unarchiveCategory),
+		"MergeCategories":   rpc(mux, h.mergeCategories),
+
+		"RangeReport":   rpc(mux, h.rangeReport),
+		"MonthReport":   rpc(mux, h.monthReport),
+		"SavingsReport": rpc(mux, h.savingsReport),
+
+		"BudgetSet":    rpc(mux, h.budgetSet),
+		"BudgetDelete": rpc(mux, h.budgetDelete),
+		"BudgetsList":  rpc(mux, h.budgetsList),
+
+		"RecurringCreate":  rpc(mux, h.recurringCreate),
+		"RecurringEdit":    rpc(mux, h.recurringEdit),
+		"RecurringPause":   rpc(mux, h.recurringPause),
+		"RecurringResume":  rpc(mux, h.recurringResume),
+		"RecurringDelete":  rpc(mux, h.recurringDelete),
+		"RecurringList":    rpc(mux, h.recurringList),
+		"RecurringPreview": rpc(mux, h.recurringPreview),
+
+		"IncomeSourceCreate": rpc(mux, h.incomeSourceCreate),
+		"IncomeSourceEdit":   rpc(mux, h.incomeSourceEdit),
+		"IncomeSourceDelete": rpc(mux, h.incomeSourceDelete),
+		"IncomeSourcesList":  rpc(mux, h.incomeSourcesList),
+		"IncomeSave":         rpc(mux, h.incomeSave),
+		"IncomeDelete":       rpc(mux, h.incomeDelete),
+		"IncomesList":        rpc(mux, h.incomesList),
+
+		"ImportProfileSave":  rpc(mux, h.importProfileSave),
+		"ImportProfilesList": rpc(mux, h.importProfilesList),
+		"ExpensesImport":     rpc(mux, h.expensesImport),
+		"ExpensesExport":     h.expensesExport(mux),
+
+		"RuleSave":        rpc(mux, h.ruleSave),
+		"RuleDelete":      rpc(mux, h.ruleDelete),
+		"RulesList":       rpc(mux, h.rulesList),
+		"RuleTest":        rpc(mux, h.ruleTest),
+		"CategorySuggest": rpc(mux, h.categorySuggest),
+
+		"TagsList":   rpc(mux, h.tagsList),
+		"TagsReport": rpc(mux, h.tagsReport),
+
+		"SharedBalances":   rpc(mux, h.sharedBalances),
+		"SettleUp":         rpc(mux, h.settleUp),
+		"SettlementSave":   rpc(mux, h.settlementSave),
+		"SettlementDelete": rpc(mux, h.settlementDelete),
+		"SettlementsList":  rpc(mux, h.settlementsList),
+
+		"AttachmentUpload":   h.attachmentUpload(mux),
+		"AttachmentDownload": h.attachmentDownload(mux),
+		"AttachmentsList":    rpc(mux, h.attachmentsList),
+		"AttachmentDelete":   rpc(mux, h.attachmentDelete),
+
+		"AccountSave":    rpc(mux, h.accountSave),
+		"AccountDelete":  rpc(mux, h.accountDelete),
+		"AccountsList":   rpc(mux, h.accountsList),
+		"AccountHistory": rpc(mux, h.accountHistory),
+		"TransferSave":   rpc(mux, h.transferSave),
+		"TransferDelete": rpc(mux, h.transferDelete),
+		"TransfersList":  rpc(mux, h.transfersList),
+
+		"ReconciliationStart":  rpc(mux, h.reconciliationStart),
+		"ReconciliationGet":    rpc(mux, h.reconciliationGet),
+		"ReconciliationsList":  rpc(mux, h.reconciliationsList),
+		"ReconciliationFinish": rpc(mux, h.reconciliationFinish),
+		"ReconciliationDelete": rpc(mux, h.reconciliationDelete),
+		"ItemsClear":           rpc(mux, h.itemsClear),
+
+		"GoalSave":           rpc(mux, h.goalSave),
+		"GoalDelete":         rpc(mux, h.goalDelete),
+		"GoalsList":          rpc(mux, h.goalsList),
+		"GoalProgress":       rpc(mux, h.goalProgress),
+		"GoalContribute":     rpc(mux, h.goalContribute),
+		"ContributionDelete": rpc(mux, h.contributionDelete),
+		"ContributionsList":  rpc(mux, h.contributionsList),
+
+		"RatesSet":  rpc(mux, h.ratesSet),
+		"RatesList": rpc(mux, h.ratesList),
+	}
+
+	for name, handler := range routes {
+		if err := mux.HandlePath(http.MethodPost, prefix+name, authenticated(mux, auth, handler)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// authenticated runs next with the caller's identity in the request context
+// and rejects requests without a valid bearer token.
+func authenticated(mux *runtime.ServeMux, auth *authgrpc.Authenticator, next runtime.HandlerFunc) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+		ctx, err := auth.Authenticate(r.Context(), r.Header.Get("Authorization"))
+		if err != nil {
+			_, outbound := runtime.MarshalerForRequest(mux, r)
+			runtime.HTTPError(r.Context(), mux, outbound, w, r, err)
+			return
+		}
+
+		next(w, r.WithContext(ctx), params)
+	}
+}
+
+// rpc adapts a typed handler to the gateway mux: it decodes the JSON request
+// body, encodes the response and reports errors the way gateway routes do.
+func rpc[Req, Rsp any](mux *runtime.ServeMux, fn func(context.Context, *Req) (*Rsp, error)) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		ctx := r.Context()
+		_, outbound := runtime.MarshalerForRequest(mux, r)
+
+		var req Req
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			runtime.HTTPError(ctx, mux, outbound, w, r, status.Error(codes.InvalidArgument, err.Error()))
+			return
+		}
+
+		rsp, err := fn(ctx, &req)
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outbound, w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(rsp)
+	}
+}
+
+type okResponse struct {
+	Ok bool `json:"ok"`
+}
+
+type expense struct {
+	ID          int64    `json:"id"`
+	Amount      int64    `json:"amount"`
+	Currency    string   `json:"currency"`
+	Description string   `json:"description"`
+	Category    string   `json:"category"`
+	Date        string   `json:"date"`
+	Color       string   `json:"color"`
+	DeletedAt   string   `json:"deletedAt,omitempty"`
+	Tags        []string `json:"tags"`
+	Splits      []split  `json:"splits,omitempty"` // line items, the largest first
+	Sharing     *sharing `json:"sharing,omitempty"`
+	Account     string   `json:"account,omitempty"` // paid from
+}
+
+type split struct {
+	Category string `json:"category"`
+	Color    string `json:"color,omitempty"`
+	Amount   int64  `json:"amount"`
+}
+
+func toExpenses(list []financesgrpc.Expense) []expense {
+	rsp := make([]expense, 0, len(list))
+	for _, e := range list {
+		rsp = append(rsp, toExpense(e))
+	}
+
+	return rsp
+}
+
+func toExpense(e financesgrpc.Expense) expense {
+	return expense{
+		ID:          e.ID,
+		Amount:      e.Amount,
+		Currency:    e.Currency,
+		Description: e.Description,
+		Category:    e.Category,
+		Date:        e.Date,
+		Color:       e.Color,
+		DeletedAt:   e.DeletedAt,
+		Tags:        append([]string{}, e.Tags...),
+		Splits:      toSplits(e.Splits),
+		Sharing:     toSharing(e.Sharing),
+		Account:     accountName(e.Account),
+	}
+}
+
+func toSplits(list []financesgrpc.Split) []split {
+	var rsp []split
+	for _, s := range list {
+		rsp = append(rsp, split{Category: s.Category, Color: s.Color, Amount: s.Amount})
+	}
+
+	return rsp
+}
+
+// fromSplits keeps nil apart from an empty list, which removes line items.
+func fromSplits(list []split) []financesgrpc.Split {
+	if list == nil {
+		return nil
+	}
+
+	splits := make([]financesgrpc.Split, 0, len(list))
+	for _, s := range list {
+		splits = append(splits, financesgrpc.Split{Category: s.Category, Amount: s.Amount})
+	}
+
+	return splits
+}
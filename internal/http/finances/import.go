@@ -0,0 +1,153 @@
+package financeshttp
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+)
+
+type importProfile struct {
+	Name             string         `json:"name"`
+	Delimiter        string         `json:"delimiter"`
+	SkipRows         int            `json:"skipRows"`
+	NoHeader         bool           `json:"noHeader"`
+	Date             string         `json:"date"`
+	DateFormat       string         `json:"dateFormat"`
+	Amount           string         `json:"amount"`
+	Description      string         `json:"description"`
+	Category         string         `json:"category"`
+	Currency         string         `json:"currency"`
+	DecimalSeparator string         `json:"decimalSeparator"` // "." or ",", "." when empty
+	Multiplier       float64        `json:"multiplier"`
+	ExpensesNegative bool           `json:"expensesNegative"`
+	Rules            []categoryRule `json:"rules"`
+	DefaultCategory  string         `json:"defaultCategory"`
+}
+
+type categoryRule struct {
+	Contains string `json:"contains"`
+	Category string `json:"category"`
+}
+
+type importProfilesListRequest struct{}
+
+type importProfilesListResponse struct {
+	Profiles []importProfile `json:"profiles"`
+}
+
+type expensesImportRequest struct {
+	Profile string `json:"profile"` // name of a saved profile
+	CSV     string `json:"csv"`     // the bank export
+	DryRun  bool   `json:"dryRun"`
+}
+
+type importRow struct {
+	Line    int     `json:"line"`
+	Status  string  `json:"status"` // "new", "duplicate", "skipped" or "invalid"
+	Reason  string  `json:"reason,omitempty"`
+	Expense expense `json:"expense"`
+}
+
+type expensesImportResponse struct {
+	Imported   int         `json:"imported"`
+	Duplicates int         `json:"duplicates"`
+	Skipped    int         `json:"skipped"`
+	Invalid    int         `json:"invalid"`
+	Rows       []importRow `json:"rows"`
+}
+
+func (h *handlers) importProfileSave(ctx context.Context, req *importProfile) (*okResponse, error) {
+	p := financesgrpc.ImportProfile{
+		Name:             strings.TrimSpace(req.Name),
+		Delimiter:        req.Delimiter,
+		SkipRows:         req.SkipRows,
+		NoHeader:         req.NoHeader,
+		Date:             req.Date,
+		DateFormat:       req.DateFormat,
+		Amount:           req.Amount,
+		Description:      req.Description,
+		Category:         req.Category,
+		Currency:         req.Currency,
+		DecimalSeparator: req.DecimalSeparator,
+		Multiplier:       req.Multiplier,
+		ExpensesNegative: req.ExpensesNegative,
+		DefaultCategory:  req.DefaultCategory,
+	}
+	for _, r := range req.Rules {
+		p.Rules = append(p.Rules, financesgrpc.CategoryRule{Contains: r.Contains, Category: r.Category})
+	}
+
+	if err := h.finances.SaveImportProfile(ctx, p); err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &okResponse{Ok: true}, nil
+}
+
+func (h *handlers) importProfilesList(ctx context.Context, _ *importProfilesListRequest) (*importProfilesListResponse, error) {
+	profiles, err := h.finances.ImportProfiles(ctx)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := &importProfilesListResponse{Profiles: make([]importProfile, 0, len(profiles))}
+	for _, p := range profiles {
+		profile := importProfile{
+			Name:             p.Name,
+			Delimiter:        p.Delimiter,
+			SkipRows:         p.SkipRows,
+			NoHeader:         p.NoHeader,
+			Date:             p.Date,
+			DateFormat:       p.DateFormat,
+			Amount:           p.Amount,
+			Description:      p.Description,
+			Category:         p.Category,
+			Currency:         p.Currency,
+			DecimalSeparator: p.DecimalSeparator,
+			Multiplier:       p.Multiplier,
+			ExpensesNegative: p.ExpensesNegative,
+			Rules:            make([]categoryRule, 0, len(p.Rules)),
+			DefaultCategory:  p.DefaultCategory,
+		}
+		for _, r := range p.Rules {
+			profile.Rules = append(profile.Rules, categoryRule{Contains: r.Contains, Category: r.Category})
+		}
+
+		rsp.Profiles = append(rsp.Profiles, profile)
+	}
+
+	return rsp, nil
+}
+
+func (h *handlers) expensesImport(ctx context.Context, req *expensesImportRequest) (*expensesImportResponse, error) {
+	if req.Profile == "" {
+		return nil, status.Error(codes.InvalidArgument, "profile is required")
+	}
+
+	result, err := h.finances.ImportExpenses(ctx, strings.NewReader(req.CSV), req.Profile, req.DryRun)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := &expensesImportResponse{
+		Imported:   result.Imported,
+		Duplicates: result.Duplicates,
+		Skipped:    result.Skipped,
+		Invalid:    result.Invalid,
+		Rows:       make([]importRow, 0, len(result.Rows)),
+	}
+	for _, row := range result.Rows {
+		rsp.Rows = append(rsp.Rows, importRow{
+			Line:    row.Line,
+			Status:  row.Status,
+			Reason:  row.Reason,
+			Expense: toExpense(row.Expense),
+		})
+	}
+
+	return rsp, nil
+}
@@ -0,0 +1,171 @@
+package financeshttp
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+)
+
+type income struct {
+	ID          int64  `json:"id"`
+	Amount      int64  `json:"amount"`
+	Currency    string `json:"currency"`
+	Description string `json:"description"`
+	Source      string `json:"source"`
+	Date        string `json:"date"`
+	Color       string `json:"color"`
+	// Account is the account paid to; saving replaces it when present and
+	// "" unlinks it.
+	Account *string `json:"account,omitempty"`
+}
+
+type incomeSource struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+type incomeSourceIDRequest struct {
+	ID int64 `json:"id"`
+}
+
+type incomeSourcesListRequest struct{}
+
+type incomeSourcesListResponse struct {
+	Sources []incomeSource `json:"sources"`
+}
+
+type incomeIDRequest struct {
+	ID int64 `json:"id"`
+}
+
+type incomesListRequest struct {
+	From string `json:"from"` // YYYY-MM-DD, inclusive
+	To   string `json:"to"`   // YYYY-MM-DD, inclusive
+}
+
+type incomesListResponse struct {
+	Incomes []income `json:"incomes"`
+	Total   int64    `json:"total"` // in the base currency
+}
+
+func (h *handlers) incomeSourceCreate(ctx context.Context, req *incomeSource) (*incomeSource, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "income source name is required")
+	}
+
+	s, err := h.finances.CreateIncomeSource(ctx, name, req.Color)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &incomeSource{ID: s.ID, Name: s.Name, Color: s.Color}, nil
+}
+
+// incomeSourceEdit updates the non-empty name and color of the source.
+func (h *handlers) incomeSourceEdit(ctx context.Context, req *incomeSource) (*incomeSource, error) {
+	if req.ID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "income source id is required")
+	}
+
+	s, err := h.finances.EditIncomeSource(ctx, req.ID, strings.TrimSpace(req.Name), req.Color)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &incomeSource{ID: s.ID, Name: s.Name, Color: s.Color}, nil
+}
+
+func (h *handlers) incomeSourceDelete(ctx context.Context, req *incomeSourceIDRequest) (*okResponse, error) {
+	if req.ID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "income source id is required")
+	}
+
+	if err := h.finances.DeleteIncomeSource(ctx, req.ID); err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &okResponse{Ok: true}, nil
+}
+
+func (h *handlers) incomeSourcesList(ctx context.Context, _ *incomeSourcesListRequest) (*incomeSourcesListResponse, error) {
+	sources, err := h.finances.IncomeSourcesList(ctx)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := &incomeSourcesListResponse{Sources: make([]incomeSource, 0, len(sources))}
+	for _, s := range sources {
+		rsp.Sources = append(rsp.Sources, incomeSource{ID: s.ID, Name: s.Name, Color: s.Color})
+	}
+
+	return rsp, nil
+}
+
+func (h *handlers) incomeSave(ctx context.Context, req *income) (*income, error) {
+	saved, err := h.finances.SaveIncome(ctx, financesgrpc.Income{
+		ID:          req.ID,
+		Description: req.Description,
+		Amount:      req.Amount,
+		Currency:    req.Currency,
+		Date:        req.Date,
+		Source:      req.Source,
+		Account:     req.Account,
+	})
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := toIncome(saved)
+
+	return &rsp, nil
+}
+
+func (h *handlers) incomeDelete(ctx context.Context, req *incomeIDRequest) (*okResponse, error) {
+	if req.ID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "income id is required")
+	}
+
+	if err := h.finances.DeleteIncome(ctx, req.ID); err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &okResponse{Ok: true}, nil
+}
+
+func (h *handlers) incomesList(ctx context.Context, req *incomesListRequest) (*incomesListResponse, error) {
+	from, to, err := parseRange(req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	list, total, err := h.finances.IncomesList(ctx, from, to)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := &incomesListResponse{Incomes: make([]income, 0, len(list)), Total: total}
+	for _, i := range list {
+		rsp.Incomes = append(rsp.Incomes, toIncome(i))
+	}
+
+	return rsp, nil
+}
+
+func toIncome(i financesgrpc.Income) income {
+	return income{
+		ID:          i.ID,
+		Amount:      i.Amount,
+		Currency:    i.Currency,
+		Description: i.Description,
+		Source:      i.Source,
+		Date:        i.Date,
+		Color:       i.Color,
+		Account:     i.Account,
+	}
+}
@@ -0,0 +1,59 @@
+package financeshttp
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+)
+
+type rate struct {
+	Currency string  `json:"currency"`
+	Date     string  `json:"date"` // YYYY-MM-DD
+	Rate     float64 `json:"rate"` // price of one unit of currency in the base currency
+}
+
+type ratesSetRequest struct {
+	Rates []rate `json:"rates"`
+}
+
+type ratesListRequest struct {
+	Currency string `json:"currency"` // optional
+}
+
+type ratesListResponse struct {
+	Rates []rate `json:"rates"`
+}
+
+func (h *handlers) ratesSet(ctx context.Context, req *ratesSetRequest) (*okResponse, error) {
+	if len(req.Rates) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "rates are required")
+	}
+
+	rates := make([]financesgrpc.ExchangeRate, 0, len(req.Rates))
+	for _, r := range req.Rates {
+		rates = append(rates, financesgrpc.ExchangeRate{Currency: r.Currency, Date: r.Date, Rate: r.Rate})
+	}
+
+	if err := h.finances.SetRates(ctx, rates); err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &okResponse{Ok: true}, nil
+}
+
+func (h *handlers) ratesList(ctx context.Context, req *ratesListRequest) (*ratesListResponse, error) {
+	rates, err := h.finances.Rates(ctx, req.Currency)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := &ratesListResponse{Rates: make([]rate, 0, len(rates))}
+	for _, r := range rates {
+		rsp.Rates = append(rsp.Rates, rate{Currency: r.Currency, Date: r.Date, Rate: r.Rate})
+	}
+
+	return rsp, nil
+}
@@ -0,0 +1,164 @@
+package financeshttp
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+)
+
+type reconciliation struct {
+	ID               int64  `json:"id"`
+	Account          string `json:"account"`
+	Date             string `json:"date"` // YYYY-MM-DD of the statement, today when empty
+	StatementBalance int64  `json:"statementBalance"`
+	Balance          int64  `json:"balance"`        // computed from all items up to the date
+	ClearedBalance   int64  `json:"clearedBalance"` // computed from the cleared items
+	Difference       int64  `json:"difference"`     // left to clear
+	FinishedAt       string `json:"finishedAt,omitempty"`
+}
+
+// ledgerItem is an "expense", "income" or "transfer" of an account.
+type ledgerItem struct {
+	Kind        string `json:"kind"`
+	ID          int64  `json:"id"`
+	Date        string `json:"date,omitempty"`
+	Description string `json:"description,omitempty"`
+	Amount      int64  `json:"amount"` // negative for money leaving the account
+}
+
+type reconciliationIDRequest struct {
+	ID int64 `json:"id"`
+}
+
+type reconciliationResponse struct {
+	Reconciliation reconciliation `json:"reconciliation"`
+	Uncleared      []ledgerItem   `json:"uncleared"` // the oldest first
+}
+
+type reconciliationsListRequest struct{}
+
+type reconciliationsListResponse struct {
+	Reconciliations []reconciliation `json:"reconciliations"`
+}
+
+type itemsClearRequest struct {
+	Items   []ledgerItem `json:"items"` // told by kind and id
+	Cleared bool         `json:"cleared"`
+}
+
+func (h *handlers) reconciliationStart(ctx context.Context, req *reconciliation) (*reconciliation, error) {
+	if req.ID != 0 {
+		return nil, status.Error(codes.InvalidArgument, "reconciliations are started anew, delete the wrong one instead")
+	}
+
+	saved, err := h.finances.StartReconciliation(ctx, financesgrpc.Reconciliation{
+		Account:          req.Account,
+		Date:             req.Date,
+		StatementBalance: req.StatementBalance,
+	})
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := toReconciliation(saved)
+
+	return &rsp, nil
+}
+
+func (h *handlers) reconciliationGet(ctx context.Context, req *reconciliationIDRequest) (*reconciliationResponse, error) {
+	if req.ID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "reconciliation id is required")
+	}
+
+	r, items, err := h.finances.Reconciliation(ctx, req.ID)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := &reconciliationResponse{Reconciliation: toReconciliation(r), Uncleared: make([]ledgerItem, 0, len(items))}
+	for _, item := range items {
+		rsp.Uncleared = append(rsp.Uncleared, ledgerItem{
+			Kind:        item.Kind,
+			ID:          item.ID,
+			Date:        item.Date,
+			Description: item.Description,
+			Amount:      item.Amount,
+		})
+	}
+
+	return rsp, nil
+}
+
+func (h *handlers) reconciliationsList(ctx context.Context, _ *reconciliationsListRequest) (*reconciliationsListResponse, error) {
+	list, err := h.finances.Reconciliations(ctx)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := &reconciliationsListResponse{Reconciliations: make([]reconciliation, 0, len(list))}
+	for _, r := range list {
+		rsp.Reconciliations = append(rsp.Reconciliations, toReconciliation(r))
+	}
+
+	return rsp, nil
+}
+
+func (h *handlers) reconciliationFinish(ctx context.Context, req *reconciliationIDRequest) (*reconciliation, error) {
+	if req.ID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "reconciliation id is required")
+	}
+
+	finished, err := h.finances.FinishReconciliation(ctx, req.ID)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := toReconciliation(finished)
+
+	return &rsp, nil
+}
+
+func (h *handlers) reconciliationDelete(ctx context.Context, req *reconciliationIDRequest) (*okResponse, error) {
+	if req.ID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "reconciliation id is required")
+	}
+
+	if err := h.finances.DeleteReconciliation(ctx, req.ID); err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &okResponse{Ok: true}, nil
+}
+
+func (h *handlers) itemsClear(ctx context.Context, req *itemsClearRequest) (*okResponse, error) {
+	if len(req.Items) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "items are required")
+	}
+
+	items := make([]financesgrpc.LedgerItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, financesgrpc.LedgerItem{Kind: item.Kind, ID: item.ID})
+	}
+
+	if err := h.finances.SetCleared(ctx, items, req.Cleared); err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &okResponse{Ok: true}, nil
+}
+
+func toReconciliation(r financesgrpc.Reconciliation) reconciliation {
+	return reconciliation{
+		ID:               r.ID,
+		Account:          r.Account,
+		Date:             r.Date,
+		StatementBalance: r.StatementBalance,
+		Balance:          r.Balance,
+		ClearedBalance:   r.ClearedBalance,
+		Difference:       r.Difference,
+		FinishedAt:       r.FinishedAt,
+	}
+}
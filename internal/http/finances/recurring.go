@@ -0,0 +1,161 @@
+package financeshttp
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+)
+
+type recurring struct {
+	ID          int64   `json:"id"`
+	Description string  `json:"description"`
+	Amount      int64   `json:"amount"`
+	Currency    string  `json:"currency"`
+	Category    string  `json:"category"`
+	Color       string  `json:"color"`
+	Frequency   string  `json:"frequency"` // "weekly", "monthly" or "yearly"
+	Interval    int     `json:"interval"`
+	StartDate   string  `json:"startDate"`
+	EndDate     *string `json:"endDate"` // empty means never, kept on edit when missing
+	NextDate    string  `json:"nextDate"`
+	Paused      bool    `json:"paused"`
+}
+
+type recurringIDRequest struct {
+	ID int64 `json:"id"`
+}
+
+type recurringListRequest struct{}
+
+type recurringListResponse struct {
+	Recurring []recurring `json:"recurring"`
+}
+
+type recurringPreviewRequest struct {
+	ID    int64 `json:"id"`
+	Count int   `json:"count"` // 5 when zero, at most 100
+}
+
+type recurringPreviewResponse struct {
+	Dates []string `json:"dates"`
+}
+
+func (h *handlers) recurringCreate(ctx context.Context, req *recurring) (*recurring, error) {
+	r, err := h.finances.CreateRecurring(ctx, fromRecurring(*req))
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return toRecurring(r), nil
+}
+
+func (h *handlers) recurringEdit(ctx context.Context, req *recurring) (*recurring, error) {
+	if req.ID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "recurring expense id is required")
+	}
+
+	r, err := h.finances.EditRecurring(ctx, fromRecurring(*req))
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return toRecurring(r), nil
+}
+
+func (h *handlers) recurringPause(ctx context.Context, req *recurringIDRequest) (*okResponse, error) {
+	return h.setRecurringPaused(ctx, req.ID, true)
+}
+
+func (h *handlers) recurringResume(ctx context.Context, req *recurringIDRequest) (*okResponse, error) {
+	return h.setRecurringPaused(ctx, req.ID, false)
+}
+
+func (h *handlers) setRecurringPaused(ctx context.Context, id int64, paused bool) (*okResponse, error) {
+	if id <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "recurring expense id is required")
+	}
+
+	if err := h.finances.PauseRecurring(ctx, id, paused); err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &okResponse{Ok: true}, nil
+}
+
+func (h *handlers) recurringDelete(ctx context.Context, req *recurringIDRequest) (*okResponse, error) {
+	if req.ID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "recurring expense id is required")
+	}
+
+	if err := h.finances.DeleteRecurring(ctx, req.ID); err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &okResponse{Ok: true}, nil
+}
+
+func (h *handlers) recurringList(ctx context.Context, _ *recurringListRequest) (*recurringListResponse, error) {
+	list, err := h.finances.RecurringList(ctx)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := &recurringListResponse{Recurring: make([]recurring, 0, len(list))}
+	for _, r := range list {
+		rsp.Recurring = append(rsp.Recurring, *toRecurring(r))
+	}
+
+	return rsp, nil
+}
+
+func (h *handlers) recurringPreview(ctx context.Context, req *recurringPreviewRequest) (*recurringPreviewResponse, error) {
+	if req.ID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "recurring expense id is required")
+	}
+
+	count := req.Count
+	if count == 0 {
+		count = 5
+	}
+
+	dates, err := h.finances.RecurringPreview(ctx, req.ID, count)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &recurringPreviewResponse{Dates: dates}, nil
+}
+
+func fromRecurring(r recurring) financesgrpc.Recurring {
+	return financesgrpc.Recurring{
+		ID:          r.ID,
+		Description: r.Description,
+		Amount:      r.Amount,
+		Currency:    r.Currency,
+		Category:    r.Category,
+		Frequency:   r.Frequency,
+		Interval:    r.Interval,
+		StartDate:   r.StartDate,
+		EndDate:     r.EndDate,
+	}
+}
+
+func toRecurring(r financesgrpc.Recurring) *recurring {
+	return &recurring{
+		ID:          r.ID,
+		Description: r.Description,
+		Amount:      r.Amount,
+		Currency:    r.Currency,
+		Category:    r.Category,
+		Color:       r.Color,
+		Frequency:   r.Frequency,
+		Interval:    r.Interval,
+		StartDate:   r.StartDate,
+		EndDate:     r.EndDate,
+		NextDate:    r.NextDate,
+		Paused:      r.Paused,
+	}
+}
@@ -0,0 +1,172 @@
+package financeshttp
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+)
+
+type rangeReportRequest struct {
+	From string `json:"from"` // YYYY-MM-DD, inclusive
+	To   string `json:"to"`   // YYYY-MM-DD, inclusive
+}
+
+type monthReportRequest struct {
+	Month string `json:"month"` // YYYY-MM
+}
+
+type reportCategory struct {
+	Name    string `json:"name"`
+	Color   string `json:"color"`
+	Amount  int64  `json:"amount"`
+	Percent int64  `json:"percent"`
+
+	// Set in month reports, budget is 0 for categories without one.
+	Budget      int64 `json:"budget"`
+	Remaining   int64 `json:"remaining"`
+	PercentUsed int64 `json:"percentUsed"`
+	OverBudget  bool  `json:"overBudget"`
+}
+
+type reportResponse struct {
+	From       string           `json:"from"`
+	To         string           `json:"to"`
+	Total      int64            `json:"total"`
+	Average    int64            `json:"average"`
+	Median     int64            `json:"median"`
+	Categories []reportCategory `json:"categories"`
+
+	Income        int64            `json:"income"`
+	Net           int64            `json:"net"`         // income minus total spent
+	SavingsRate   int64            `json:"savingsRate"` // net as a percentage of income, 0 without income
+	IncomeSources []reportCategory `json:"incomeSources"`
+}
+
+type savingsReportRequest struct{}
+
+type savingsReportResponse struct {
+	Months []reportResponse `json:"months"` // the last 12 calendar months, oldest first
+}
+
+func (h *handlers) rangeReport(ctx context.Context, req *rangeReportRequest) (*reportResponse, error) {
+	from, to, err := parseRange(req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	total, middle, median, report, err := h.finances.RangeReport(ctx, from, to)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return h.report(ctx, from, to, total, middle, median, report)
+}
+
+// monthReport is the calendar month report with budget figures, which the
+// gRPC ReportResponse has no fields for.
+func (h *handlers) monthReport(ctx context.Context, req *monthReportRequest) (*reportResponse, error) {
+	month, err := time.Parse("2006-01", req.Month)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "month must be in YYYY-MM format")
+	}
+
+	return h.monthReportOf(ctx, month)
+}
+
+// savingsReport is the gRPC MassiveReport with budget and income figures,
+// which its ReportResponse has no fields for.
+func (h *handlers) savingsReport(ctx context.Context, _ *savingsReportRequest) (*savingsReportResponse, error) {
+	now := time.Now()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	rsp := &savingsReportResponse{Months: make([]reportResponse, 0, 12)}
+	for i := -11; i <= 0; i++ {
+		report, err := h.monthReportOf(ctx, thisMonth.AddDate(0, i, 0))
+		if err != nil {
+			return nil, err
+		}
+
+		rsp.Months = append(rsp.Months, *report)
+	}
+
+	return rsp, nil
+}
+
+func (h *handlers) monthReportOf(ctx context.Context, month time.Time) (*reportResponse, error) {
+	total, middle, median, report, err := h.finances.Report(ctx, financesgrpc.Month, int(month.Month()), month.Year())
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return h.report(ctx, month, month.AddDate(0, 1, -1), total, middle, median, report)
+}
+
+// report completes a spending report over [from, to] with the income of
+// the same dates.
+func (h *handlers) report(
+	ctx context.Context, from, to time.Time, total, middle, median int64, report []financesgrpc.CategoryReport,
+) (*reportResponse, error) {
+	income, sources, err := h.finances.IncomeReport(ctx, from, to)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := &reportResponse{
+		From:          from.Format(time.DateOnly),
+		To:            to.Format(time.DateOnly),
+		Total:         total,
+		Average:       middle,
+		Median:        median,
+		Categories:    toReportCategories(report, total),
+		Income:        income,
+		Net:           income - total,
+		IncomeSources: toReportCategories(sources, income),
+	}
+	if income > 0 {
+		rsp.SavingsRate = rsp.Net * 100 / income
+	}
+
+	return rsp, nil
+}
+
+// parseRange parses an inclusive YYYY-MM-DD date range.
+func parseRange(fromStr, toStr string) (time.Time, time.Time, error) {
+	from, err := time.Parse(time.DateOnly, fromStr)
+	if err != nil {
+		return time.Time{}, time.Time{}, status.Error(codes.InvalidArgument, "from must be in YYYY-MM-DD format")
+	}
+
+	to, err := time.Parse(time.DateOnly, toStr)
+	if err != nil {
+		return time.Time{}, time.Time{}, status.Error(codes.InvalidArgument, "to must be in YYYY-MM-DD format")
+	}
+
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, status.Error(codes.InvalidArgument, "to must not be before from")
+	}
+
+	return from, to, nil
+}
+
+func toReportCategories(report []financesgrpc.CategoryReport, total int64) []reportCategory {
+	categories := make([]reportCategory, 0, len(report))
+
+	for _, c := range report {
+		categories = append(categories, reportCategory{
+			Name:        c.Category,
+			Color:       c.Color,
+			Amount:      c.Amount,
+			Percent:     c.PercentOf(total),
+			Budget:      c.Budget,
+			Remaining:   c.Remaining,
+			PercentUsed: c.PercentUsed,
+			OverBudget:  c.OverBudget(),
+		})
+	}
+
+	return categories
+}
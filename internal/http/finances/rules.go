@@ -0,0 +1,214 @@
+package financeshttp
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+)
+
+type rule struct {
+	ID        int64  `json:"id"`
+	Category  string `json:"category"`
+	Color     string `json:"color"`
+	Priority  int    `json:"priority"` // higher is tried first
+	Contains  string `json:"contains"`
+	Pattern   string `json:"pattern"` // Go regular expression
+	MinAmount int64  `json:"minAmount"`
+	MaxAmount int64  `json:"maxAmount"`
+	Weekdays  []int  `json:"weekdays"` // 0 is Sunday, any day when empty
+}
+
+type ruleIDRequest struct {
+	ID int64 `json:"id"`
+}
+
+type rulesListRequest struct{}
+
+type rulesListResponse struct {
+	Rules []rule `json:"rules"`
+}
+
+type categorySuggestRequest struct {
+	Description string `json:"description"`
+	Amount      int64  `json:"amount"`
+	Date        string `json:"date"` // YYYY-MM-DD
+}
+
+// categorySuggestResponse has the category of the matching rule, if any,
+// and the categories learned from past expenses.
+type categorySuggestResponse struct {
+	Category    string               `json:"category"` // empty when no rule matches
+	Color       string               `json:"color"`
+	RuleID      int64                `json:"ruleId"`
+	Suggestions []categorySuggestion `json:"suggestions"` // most likely first
+}
+
+type categorySuggestion struct {
+	Category   string  `json:"category"`
+	Color      string  `json:"color"`
+	Confidence float64 `json:"confidence"` // between 0 and 1
+}
+
+// suggestionsCount is how many learned categories the picker shows.
+const suggestionsCount = 3
+
+type ruleTestRequest struct {
+	Rule  rule   `json:"rule"`
+	From  string `json:"from"` // YYYY-MM-DD, inclusive, optional
+	To    string `json:"to"`   // YYYY-MM-DD, inclusive, optional
+	Limit int    `json:"limit"`
+}
+
+type ruleTestResponse struct {
+	Matched      int       `json:"matched"`
+	SameCategory int       `json:"sameCategory"`
+	Expenses     []expense `json:"expenses"`
+}
+
+func (h *handlers) ruleSave(ctx context.Context, req *rule) (*rule, error) {
+	if req.ID < 0 {
+		return nil, status.Error(codes.InvalidArgument, "rule id must not be negative")
+	}
+
+	saved, err := h.finances.SaveRule(ctx, fromRule(*req))
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := toRule(saved)
+
+	return &rsp, nil
+}
+
+func (h *handlers) ruleDelete(ctx context.Context, req *ruleIDRequest) (*okResponse, error) {
+	if req.ID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "rule id is required")
+	}
+
+	if err := h.finances.DeleteRule(ctx, req.ID); err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &okResponse{Ok: true}, nil
+}
+
+func (h *handlers) rulesList(ctx context.Context, _ *rulesListRequest) (*rulesListResponse, error) {
+	rules, err := h.finances.Rules(ctx)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := &rulesListResponse{Rules: make([]rule, 0, len(rules))}
+	for _, r := range rules {
+		rsp.Rules = append(rsp.Rules, toRule(r))
+	}
+
+	return rsp, nil
+}
+
+func (h *handlers) categorySuggest(ctx context.Context, req *categorySuggestRequest) (*categorySuggestResponse, error) {
+	r, ok, err := h.finances.SuggestCategory(ctx, financesgrpc.Expense{
+		Description: req.Description,
+		Amount:      req.Amount,
+		Date:        req.Date,
+	})
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	learned, err := h.finances.SuggestCategories(ctx, req.Description, suggestionsCount)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := &categorySuggestResponse{Suggestions: make([]categorySuggestion, 0, len(learned))}
+	if ok {
+		rsp.Category, rsp.Color, rsp.RuleID = r.Category, r.Color, r.ID
+	}
+
+	for _, s := range learned {
+		rsp.Suggestions = append(rsp.Suggestions, categorySuggestion{
+			Category:   s.Category,
+			Color:      s.Color,
+			Confidence: s.Confidence,
+		})
+	}
+
+	return rsp, nil
+}
+
+func (h *handlers) ruleTest(ctx context.Context, req *ruleTestRequest) (*ruleTestResponse, error) {
+	var from, to time.Time
+	var err error
+
+	if req.From != "" {
+		if from, err = time.Parse(time.DateOnly, req.From); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "from must be in YYYY-MM-DD format")
+		}
+	}
+	if req.To != "" {
+		if to, err = time.Parse(time.DateOnly, req.To); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "to must be in YYYY-MM-DD format")
+		}
+	}
+
+	if req.Limit < 0 || req.Limit > maxPageSize {
+		return nil, status.Errorf(codes.InvalidArgument, "limit must be between 0 and %d", maxPageSize)
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = 20
+	}
+
+	test, err := h.finances.TestRule(ctx, fromRule(req.Rule), from, to, limit)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &ruleTestResponse{
+		Matched:      test.Matched,
+		SameCategory: test.SameCategory,
+		Expenses:     toExpenses(test.Expenses),
+	}, nil
+}
+
+func fromRule(r rule) financesgrpc.CategorizationRule {
+	cr := financesgrpc.CategorizationRule{
+		ID:        r.ID,
+		Category:  r.Category,
+		Priority:  r.Priority,
+		Contains:  r.Contains,
+		Pattern:   r.Pattern,
+		MinAmount: r.MinAmount,
+		MaxAmount: r.MaxAmount,
+	}
+	for _, d := range r.Weekdays {
+		cr.Weekdays = append(cr.Weekdays, time.Weekday(d))
+	}
+
+	return cr
+}
+
+func toRule(r financesgrpc.CategorizationRule) rule {
+	rsp := rule{
+		ID:        r.ID,
+		Category:  r.Category,
+		Color:     r.Color,
+		Priority:  r.Priority,
+		Contains:  r.Contains,
+		Pattern:   r.Pattern,
+		MinAmount: r.MinAmount,
+		MaxAmount: r.MaxAmount,
+		Weekdays:  make([]int, 0, len(r.Weekdays)),
+	}
+	for _, d := range r.Weekdays {
+		rsp.Weekdays = append(rsp.Weekdays, int(d))
+	}
+
+	return rsp
+}
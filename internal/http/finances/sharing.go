@@ -0,0 +1,186 @@
+package financeshttp
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+)
+
+// sharing is how an expense is shared: "equal", by "percent" or by
+// "exact" amounts.
+type sharing struct {
+	PaidBy string  `json:"paidBy"`
+	Method string  `json:"method"`
+	Shares []share `json:"shares"` // [] stops sharing the expense
+}
+
+type share struct {
+	Person  string  `json:"person"`
+	Percent float64 `json:"percent,omitempty"`
+	Amount  int64   `json:"amount"`
+}
+
+type settlement struct {
+	ID       int64  `json:"id"`
+	Date     string `json:"date"` // YYYY-MM-DD, today when empty
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"` // ISO 4217, the base currency when empty
+	Note     string `json:"note"`
+}
+
+type debt struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Amount int64  `json:"amount"`
+}
+
+type balance struct {
+	Person string `json:"person"`
+	Amount int64  `json:"amount"` // negative when the person owes
+}
+
+type sharedBalancesRequest struct{}
+
+type sharedBalancesResponse struct {
+	Debts    []debt    `json:"debts"`
+	Balances []balance `json:"balances"` // the largest first
+}
+
+type settleUpRequest struct{}
+
+type settleUpResponse struct {
+	Transfers []debt `json:"transfers"` // fewer than the people with a balance, not always the fewest
+}
+
+type settlementIDRequest struct {
+	ID int64 `json:"id"`
+}
+
+type settlementsListRequest struct{}
+
+type settlementsListResponse struct {
+	Settlements []settlement `json:"settlements"` // the latest first
+}
+
+func (h *handlers) sharedBalances(ctx context.Context, _ *sharedBalancesRequest) (*sharedBalancesResponse, error) {
+	debts, balances, err := h.finances.SharedBalances(ctx)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := &sharedBalancesResponse{Debts: toDebts(debts), Balances: make([]balance, 0, len(balances))}
+	for _, b := range balances {
+		rsp.Balances = append(rsp.Balances, balance{Person: b.Person, Amount: b.Amount})
+	}
+
+	return rsp, nil
+}
+
+func (h *handlers) settleUp(ctx context.Context, _ *settleUpRequest) (*settleUpResponse, error) {
+	transfers, err := h.finances.SettleUp(ctx)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &settleUpResponse{Transfers: toDebts(transfers)}, nil
+}
+
+func (h *handlers) settlementSave(ctx context.Context, req *settlement) (*settlement, error) {
+	if req.ID != 0 {
+		return nil, status.Error(codes.InvalidArgument, "settlements are recorded anew, delete the wrong one instead")
+	}
+
+	saved, err := h.finances.SaveSettlement(ctx, financesgrpc.Settlement{
+		Date:     req.Date,
+		From:     req.From,
+		To:       req.To,
+		Amount:   req.Amount,
+		Currency: req.Currency,
+		Note:     req.Note,
+	})
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := toSettlement(saved)
+
+	return &rsp, nil
+}
+
+func (h *handlers) settlementDelete(ctx context.Context, req *settlementIDRequest) (*okResponse, error) {
+	if req.ID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "settlement id is required")
+	}
+
+	if err := h.finances.DeleteSettlement(ctx, req.ID); err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &okResponse{Ok: true}, nil
+}
+
+func (h *handlers) settlementsList(ctx context.Context, _ *settlementsListRequest) (*settlementsListResponse, error) {
+	list, err := h.finances.Settlements(ctx)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := &settlementsListResponse{Settlements: make([]settlement, 0, len(list))}
+	for _, s := range list {
+		rsp.Settlements = append(rsp.Settlements, toSettlement(s))
+	}
+
+	return rsp, nil
+}
+
+func toSettlement(s financesgrpc.Settlement) settlement {
+	return settlement{
+		ID:       s.ID,
+		Date:     s.Date,
+		From:     s.From,
+		To:       s.To,
+		Amount:   s.Amount,
+		Currency: s.Currency,
+		Note:     s.Note,
+	}
+}
+
+func toDebts(list []financesgrpc.Debt) []debt {
+	rsp := make([]debt, 0, len(list))
+	for _, d := range list {
+		rsp = append(rsp, debt{From: d.From, To: d.To, Amount: d.Amount})
+	}
+
+	return rsp
+}
+
+func toSharing(s *financesgrpc.Sharing) *sharing {
+	if s == nil {
+		return nil
+	}
+
+	rsp := &sharing{PaidBy: s.PaidBy, Method: s.Method, Shares: make([]share, 0, len(s.Shares))}
+	for _, sh := range s.Shares {
+		rsp.Shares = append(rsp.Shares, share{Person: sh.Person, Percent: sh.Percent, Amount: sh.Amount})
+	}
+
+	return rsp
+}
+
+func fromSharing(s *sharing) *financesgrpc.Sharing {
+	if s == nil {
+		return nil
+	}
+
+	req := &financesgrpc.Sharing{PaidBy: s.PaidBy, Method: s.Method}
+	for _, sh := range s.Shares {
+		req.Shares = append(req.Shares, financesgrpc.Share{Person: sh.Person, Percent: sh.Percent, Amount: sh.Amount})
+	}
+
+	return req
+}
@@ -0,0 +1,61 @@
+package financeshttp
+
+import (
+	"context"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+)
+
+type tag struct {
+	Name     string `json:"name"`
+	Expenses int    `json:"expenses"`
+}
+
+type tagsListRequest struct{}
+
+type tagsListResponse struct {
+	Tags []tag `json:"tags"`
+}
+
+type tagReport struct {
+	Tag      string `json:"tag"`
+	Amount   int64  `json:"amount"`
+	Expenses int    `json:"expenses"`
+}
+
+type tagsReportResponse struct {
+	Tags []tagReport `json:"tags"` // the largest first
+}
+
+func (h *handlers) tagsList(ctx context.Context, _ *tagsListRequest) (*tagsListResponse, error) {
+	tags, err := h.finances.Tags(ctx)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := &tagsListResponse{Tags: make([]tag, 0, len(tags))}
+	for _, t := range tags {
+		rsp.Tags = append(rsp.Tags, tag{Name: t.Name, Expenses: t.Expenses})
+	}
+
+	return rsp, nil
+}
+
+func (h *handlers) tagsReport(ctx context.Context, req *rangeReportRequest) (*tagsReportResponse, error) {
+	from, to, err := parseRange(req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := h.finances.TagsReport(ctx, from, to)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	rsp := &tagsReportResponse{Tags: make([]tagReport, 0, len(report))}
+	for _, r := range report {
+		rsp.Tags = append(rsp.Tags, tagReport{Tag: r.Tag, Amount: r.Amount, Expenses: r.Expenses})
+	}
+
+	return rsp, nil
+}
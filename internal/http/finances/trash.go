@@ -0,0 +1,53 @@
+package financeshttp
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+)
+
+type expenseIDRequest struct {
+	ID int64 `json:"id"`
+}
+
+type trashListRequest struct{}
+
+type trashListResponse struct {
+	Expenses []expense `json:"expenses"`
+}
+
+func (h *handlers) expenseDelete(ctx context.Context, req *expenseIDRequest) (*okResponse, error) {
+	if req.ID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "expense id is required")
+	}
+
+	if err := h.finances.DeleteExpense(ctx, req.ID); err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &okResponse{Ok: true}, nil
+}
+
+func (h *handlers) expenseRestore(ctx context.Context, req *expenseIDRequest) (*okResponse, error) {
+	if req.ID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "expense id is required")
+	}
+
+	if err := h.finances.RestoreExpense(ctx, req.ID); err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &okResponse{Ok: true}, nil
+}
+
+func (h *handlers) trashList(ctx context.Context, _ *trashListRequest) (*trashListResponse, error) {
+	list, err := h.finances.TrashList(ctx)
+	if err != nil {
+		return nil, financesgrpc.StatusError(err)
+	}
+
+	return &trashListResponse{Expenses: toExpenses(list)}, nil
+}
@@ -0,0 +1,126 @@
+// Package categorize picks categories of expenses by user-defined rules.
+package categorize
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+var ErrInvalid = errors.New("invalid rule")
+
+// Matcher tests expenses against a rule.
+type Matcher struct {
+	rule     models.CategorizationRule
+	contains string
+	pattern  *regexp.Regexp
+}
+
+// Compile checks the rule and prepares it for matching. A rule needs at
+// least one condition.
+func Compile(r models.CategorizationRule) (*Matcher, error) {
+	if r.Contains == "" && r.Pattern == "" && r.MinAmount == 0 && r.MaxAmount == 0 && len(r.Weekdays) == 0 {
+		return nil, fmt.Errorf("%w: at least one condition is required", ErrInvalid)
+	}
+
+	if r.MinAmount < 0 || r.MaxAmount < 0 {
+		return nil, fmt.Errorf("%w: amounts must not be negative", ErrInvalid)
+	}
+
+	if r.MaxAmount != 0 && r.MinAmount > r.MaxAmount {
+		return nil, fmt.Errorf("%w: min amount is above max amount", ErrInvalid)
+	}
+
+	for _, d := range r.Weekdays {
+		if d < time.Sunday || d > time.Saturday {
+			return nil, fmt.Errorf("%w: weekday %d is out of range", ErrInvalid, d)
+		}
+	}
+
+	m := &Matcher{rule: r, contains: strings.ToLower(r.Contains)}
+
+	if r.Pattern != "" {
+		var err error
+		if m.pattern, err = regexp.Compile(r.Pattern); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+		}
+	}
+
+	return m, nil
+}
+
+func (m *Matcher) Rule() models.CategorizationRule {
+	return m.rule
+}
+
+// Matches tells whether all conditions of the rule hold for the expense.
+// Weekday conditions never hold for expenses without a valid date.
+func (m *Matcher) Matches(e models.Expense) bool {
+	if m.contains != "" && !strings.Contains(strings.ToLower(e.Description), m.contains) {
+		return false
+	}
+
+	if m.pattern != nil && !m.pattern.MatchString(e.Description) {
+		return false
+	}
+
+	if m.rule.MinAmount != 0 && e.Amount < m.rule.MinAmount {
+		return false
+	}
+
+	if m.rule.MaxAmount != 0 && e.Amount > m.rule.MaxAmount {
+		return false
+	}
+
+	if len(m.rule.Weekdays) > 0 {
+		date, err := time.Parse(time.DateOnly, e.Date)
+		if err != nil || !slices.Contains(m.rule.Weekdays, date.Weekday()) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Rules are matchers in the order they are tried.
+type Rules []*Matcher
+
+// New compiles the rules and orders them by priority, highest first, and
+// then by ID.
+func New(rules []models.CategorizationRule) (Rules, error) {
+	list := make(Rules, 0, len(rules))
+	for _, r := range rules {
+		m, err := Compile(r)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", r.ID, err)
+		}
+		list = append(list, m)
+	}
+
+	slices.SortStableFunc(list, func(a, b *Matcher) int {
+		if c := cmp.Compare(b.rule.Priority, a.rule.Priority); c != 0 {
+			return c
+		}
+
+		return cmp.Compare(a.rule.ID, b.rule.ID)
+	})
+
+	return list, nil
+}
+
+// Categorize returns the first rule matching the expense.
+func (rs Rules) Categorize(e models.Expense) (models.CategorizationRule, bool) {
+	for _, m := range rs {
+		if m.Matches(e) {
+			return m.rule, true
+		}
+	}
+
+	return models.CategorizationRule{}, false
+}
@@ -0,0 +1,161 @@
+// Package classify guesses categories of expense descriptions with a naive
+// Bayes model over description words.
+package classify
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// stemLength is the number of leading letters a word is reduced to, a crude
+// stemmer that folds most Russian and English inflections together.
+const stemLength = 6
+
+// Prediction is a category and the probability the model gives it.
+type Prediction struct {
+	Category   string
+	Confidence float64 // between 0 and 1
+}
+
+// Model counts in how many descriptions of each category a word occurs.
+// It is not safe for concurrent use.
+type Model struct {
+	docs  map[string]int            // descriptions per category
+	words map[string]map[string]int // descriptions per category and word
+	total int
+	vocab map[string]int // categories per word
+}
+
+func New() *Model {
+	return &Model{
+		docs:  map[string]int{},
+		words: map[string]map[string]int{},
+		vocab: map[string]int{},
+	}
+}
+
+// Tokens returns the distinct stems of the words of the description, in
+// order of appearance. Numbers and one-letter words are dropped.
+func Tokens(description string) []string {
+	var tokens []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(description), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !strings.ContainsFunc(word, unicode.IsLetter) {
+			continue
+		}
+
+		runes := []rune(word)
+		if len(runes) < 2 {
+			continue
+		}
+		if len(runes) > stemLength {
+			word = string(runes[:stemLength])
+		}
+
+		if !slices.Contains(tokens, word) {
+			tokens = append(tokens, word)
+		}
+	}
+
+	return tokens
+}
+
+// Add learns that the description belongs to the category.
+func (m *Model) Add(description, category string) {
+	m.update(description, category, 1)
+}
+
+// Remove forgets a description added before, when it is edited or deleted.
+func (m *Model) Remove(description, category string) {
+	if m.docs[category] == 0 {
+		return
+	}
+
+	m.update(description, category, -1)
+}
+
+func (m *Model) update(description, category string, delta int) {
+	if category == "" {
+		return
+	}
+
+	m.docs[category] += delta
+	m.total += delta
+
+	words := m.words[category]
+	if words == nil {
+		words = map[string]int{}
+		m.words[category] = words
+	}
+
+	for _, token := range Tokens(description) {
+		before := words[token]
+		words[token] = max(before+delta, 0)
+
+		switch {
+		case before == 0 && words[token] > 0:
+			m.vocab[token]++
+		case before > 0 && words[token] == 0:
+			delete(words, token)
+			if m.vocab[token]--; m.vocab[token] <= 0 {
+				delete(m.vocab, token)
+			}
+		}
+	}
+
+	if m.docs[category] <= 0 {
+		delete(m.docs, category)
+		delete(m.words, category)
+	}
+}
+
+// Predict returns up to limit most likely categories of the description,
+// most likely first. Nothing is predicted when none of its words is known.
+func (m *Model) Predict(description string, limit int) []Prediction {
+	var known []string
+	for _, token := range Tokens(description) {
+		if m.vocab[token] > 0 {
+			known = append(known, token)
+		}
+	}
+
+	if len(known) == 0 || m.total == 0 {
+		return nil
+	}
+
+	// Naive Bayes over word presence with add-one smoothing. Words the
+	// description lacks are not counted, they say little of short texts.
+	scores := make([]Prediction, 0, len(m.docs))
+	for category, docs := range m.docs {
+		score := math.Log(float64(docs) / float64(m.total))
+		for _, token := range known {
+			score += math.Log(float64(m.words[category][token]+1) / float64(docs+2))
+		}
+		scores = append(scores, Prediction{Category: category, Confidence: score})
+	}
+
+	// Turn log scores into probabilities without overflowing.
+	top := slices.MaxFunc(scores, func(a, b Prediction) int { return cmp.Compare(a.Confidence, b.Confidence) }).Confidence
+	var sum float64
+	for i := range scores {
+		scores[i].Confidence = math.Exp(scores[i].Confidence - top)
+		sum += scores[i].Confidence
+	}
+	for i := range scores {
+		scores[i].Confidence /= sum
+	}
+
+	slices.SortFunc(scores, func(a, b Prediction) int {
+		if c := cmp.Compare(b.Confidence, a.Confidence); c != 0 {
+			return c
+		}
+
+		return cmp.Compare(a.Category, b.Category)
+	})
+
+	return scores[:min(limit, len(scores))]
+}
@@ -0,0 +1,95 @@
+package classify_test
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/kochnevns/finances-backend/internal/lib/classify"
+)
+
+func TestTokens(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want []string
+	}{
+		{"Яндекс Такси", []string{"яндекс", "такси"}},
+		{"Пятёрочка, пятёрочка 24/7", []string{"пятёро"}},
+		{"кофе x 2 шт.", []string{"кофе", "шт"}},
+		{"Supermarkets-groceries", []string{"superm", "grocer"}},
+		{"12 345", nil},
+		{"", nil},
+	} {
+		if got := classify.Tokens(tc.in); !slices.Equal(got, tc.want) {
+			t.Errorf("Tokens(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestPredict(t *testing.T) {
+	m := classify.New()
+	for _, e := range []struct{ description, category string }{
+		{"Яндекс Такси", "Транспорт"},
+		{"Яндекс Такси поездка", "Транспорт"},
+		{"Такси до аэропорта", "Транспорт"},
+		{"Пятёрочка", "Продукты"},
+		{"Пятёрочка у дома", "Продукты"},
+		{"Яндекс Лавка", "Продукты"},
+		{"Кофе", ""},
+	} {
+		m.Add(e.description, e.category)
+	}
+
+	for _, tc := range []struct {
+		description string
+		limit       int
+		want        []string
+	}{
+		{"такси", 1, []string{"Транспорт"}},
+		{"Пятёрочка на углу", 2, []string{"Продукты", "Транспорт"}},
+		{"Яндекс", 5, []string{"Транспорт", "Продукты"}},
+		{"кофе", 3, nil},
+		{"что-то новое", 3, nil},
+	} {
+		predictions := m.Predict(tc.description, tc.limit)
+
+		var got []string
+		for i, p := range predictions {
+			got = append(got, p.Category)
+			if p.Confidence <= 0 || p.Confidence > 1 || i > 0 && p.Confidence > predictions[i-1].Confidence {
+				t.Errorf("Predict(%q): confidences %+v", tc.description, predictions)
+			}
+		}
+
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("Predict(%q) = %+v, want %q", tc.description, predictions, tc.want)
+		}
+	}
+
+	var sum float64
+	for _, p := range m.Predict("Яндекс", 5) {
+		sum += p.Confidence
+	}
+	if math.Abs(sum-1) > 1e-9 {
+		t.Errorf("confidences of all categories add up to %g", sum)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	m := classify.New()
+	m.Add("Яндекс Такси", "Транспорт")
+	m.Add("Яндекс Такси", "Развлечения")
+
+	m.Remove("Яндекс Такси", "Развлечения")
+	m.Remove("Яндекс Такси", "Неизвестная")
+
+	predictions := m.Predict("такси", 5)
+	if len(predictions) != 1 || predictions[0].Category != "Транспорт" || predictions[0].Confidence != 1 {
+		t.Errorf("after removing: %+v", predictions)
+	}
+
+	m.Remove("Яндекс Такси", "Транспорт")
+	if predictions := m.Predict("такси", 5); predictions != nil {
+		t.Errorf("after removing all: %+v", predictions)
+	}
+}
@@ -0,0 +1,83 @@
+// Package currency validates currency codes and exchange rates and reads
+// rates from CSV files.
+package currency
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+var ErrInvalid = errors.New("invalid currency")
+
+// Normalize returns the upper-cased ISO 4217 code.
+func Normalize(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	if len(code) != 3 || strings.Trim(code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return "", fmt.Errorf("%w: %q is not a three-letter code", ErrInvalid, code)
+	}
+
+	return code, nil
+}
+
+// ValidateRate checks the rate and normalizes its currency code.
+func ValidateRate(r models.ExchangeRate) (models.ExchangeRate, error) {
+	code, err := Normalize(r.Currency)
+	if err != nil {
+		return models.ExchangeRate{}, err
+	}
+	r.Currency = code
+
+	if _, err := time.Parse(time.DateOnly, r.Date); err != nil {
+		return models.ExchangeRate{}, fmt.Errorf("%w: rate date %q must be in YYYY-MM-DD format", ErrInvalid, r.Date)
+	}
+
+	if r.Rate <= 0 {
+		return models.ExchangeRate{}, fmt.Errorf("%w: rate of %s on %s must be positive", ErrInvalid, r.Currency, r.Date)
+	}
+
+	return r, nil
+}
+
+// ReadRatesCSV reads "date,currency,rate" records, e.g.
+// "2024-05-01,USD,91.78". A header row is skipped and a decimal comma is
+// accepted in the rate.
+func ReadRatesCSV(r io.Reader) ([]models.ExchangeRate, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 3
+	cr.TrimLeadingSpace = true
+
+	var rates []models.ExchangeRate
+	for line := 1; ; line++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return rates, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if line == 1 && strings.EqualFold(record[0], "date") {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(strings.Replace(record[2], ",", ".", 1), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w: bad rate %q", line, ErrInvalid, record[2])
+		}
+
+		rate, err := ValidateRate(models.ExchangeRate{Date: record[0], Currency: record[1], Rate: value})
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		rates = append(rates, rate)
+	}
+}
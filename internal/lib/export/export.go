@@ -0,0 +1,256 @@
+// Package export writes expenses out as CSV, JSON or XLSX one at a time, so
+// that exports of any size are streamed rather than held in memory.
+//
+// Exports are flat: an expense is written with its tags and the account it
+// is paid from, but without its line items, how it is shared or its
+// attachments. Importing a CSV export back restores neither tags nor
+// accounts.
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+const (
+	CSV  = "csv"
+	JSON = "json"
+	XLSX = "xlsx"
+)
+
+var ErrFormat = errors.New("unknown export format")
+
+// ContentTypes maps the supported formats to their MIME types.
+var ContentTypes = map[string]string{
+	CSV:  "text/csv; charset=utf-8",
+	JSON: "application/json",
+	XLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// columns are the fields of an exported expense in CSV and XLSX files.
+var columns = []string{"date", "amount", "currency", "category", "color", "description", "tags", "account"}
+
+// Profile reads CSV exports back with the import path, so that an export
+// of one ledger can be imported into another.
+var Profile = models.ImportProfile{
+	Name:        "export",
+	Date:        "date",
+	DateFormat:  time.DateOnly,
+	Amount:      "amount",
+	Description: "description",
+	Category:    "category",
+	Currency:    "currency",
+}
+
+// Writer writes expenses in one of the formats. Close completes the file
+// but does not close the underlying writer.
+type Writer interface {
+	Write(e models.Expense) error
+	Close() error
+}
+
+// New returns a Writer of the format to w.
+func New(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case CSV:
+		return newCSVWriter(w)
+	case JSON:
+		return newJSONWriter(w), nil
+	case XLSX:
+		return newXLSXWriter(w)
+	default:
+		return nil, fmt.Errorf("%w %q", ErrFormat, format)
+	}
+}
+
+func fields(e models.Expense) []string {
+	return []string{
+		e.Date, strconv.FormatInt(e.Amount, 10), e.Currency, e.Category, e.Color, e.Description,
+		strings.Join(e.Tags, ","), e.Account,
+	}
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return nil, err
+	}
+
+	return &csvWriter{w: cw}, nil
+}
+
+func (c *csvWriter) Write(e models.Expense) error {
+	return c.w.Write(fields(e))
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonExpense is an exported expense in JSON files.
+type jsonExpense struct {
+	Date        string   `json:"date"`
+	Amount      int64    `json:"amount"`
+	Currency    string   `json:"currency"`
+	Category    string   `json:"category"`
+	Color       string   `json:"color"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags,omitempty"`
+	Account     string   `json:"account,omitempty"`
+}
+
+// jsonWriter writes a JSON array with an expense per line.
+type jsonWriter struct {
+	w     *bufio.Writer
+	count int
+}
+
+func newJSONWriter(w io.Writer) *jsonWriter {
+	return &jsonWriter{w: bufio.NewWriter(w)}
+}
+
+func (j *jsonWriter) Write(e models.Expense) error {
+	data, err := json.Marshal(jsonExpense{
+		Date:        e.Date,
+		Amount:      e.Amount,
+		Currency:    e.Currency,
+		Category:    e.Category,
+		Color:       e.Color,
+		Description: e.Description,
+		Tags:        e.Tags,
+		Account:     e.Account,
+	})
+	if err != nil {
+		return err
+	}
+
+	sep := ",\n"
+	if j.count == 0 {
+		sep = "[\n"
+	}
+	j.count++
+
+	if _, err := j.w.WriteString(sep); err != nil {
+		return err
+	}
+	_, err = j.w.Write(data)
+
+	return err
+}
+
+func (j *jsonWriter) Close() error {
+	end := "\n]\n"
+	if j.count == 0 {
+		end = "[]\n"
+	}
+
+	if _, err := j.w.WriteString(end); err != nil {
+		return err
+	}
+
+	return j.w.Flush()
+}
+
+// xlsxParts are the fixed parts of a workbook with the single sheet
+// xl/worksheets/sheet1.xml.
+var xlsxParts = []struct{ name, data string }{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Expenses" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// xlsxWriter writes the rows straight into the compressed sheet. Strings
+// are inline rather than shared, which would need them all up front.
+type xlsxWriter struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+}
+
+func newXLSXWriter(w io.Writer) (*xlsxWriter, error) {
+	zw := zip.NewWriter(w)
+
+	for _, part := range xlsxParts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.data); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	x := &xlsxWriter{zw: zw, sheet: bufio.NewWriter(f)}
+
+	_, _ = x.sheet.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if err := x.row(columns, -1); err != nil {
+		return nil, err
+	}
+
+	return x, nil
+}
+
+// row writes the cells, the one at number as a number and the others as
+// text. Errors of bufio.Writer stick, so the last write reports any.
+func (x *xlsxWriter) row(cells []string, number int) error {
+	_, _ = x.sheet.WriteString("<row>")
+	for i, cell := range cells {
+		if i == number {
+			_, _ = x.sheet.WriteString("<c><v>" + cell + "</v></c>")
+			continue
+		}
+
+		_, _ = x.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+		_ = xml.EscapeText(x.sheet, []byte(cell))
+		_, _ = x.sheet.WriteString("</t></is></c>")
+	}
+	_, err := x.sheet.WriteString("</row>")
+
+	return err
+}
+
+func (x *xlsxWriter) Write(e models.Expense) error {
+	return x.row(fields(e), 1)
+}
+
+func (x *xlsxWriter) Close() error {
+	_, _ = x.sheet.WriteString("</sheetData></worksheet>")
+
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+
+	return x.zw.Close()
+}
@@ -0,0 +1,175 @@
+package export_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kochnevns/finances-backend/internal/lib/categorize"
+	"github.com/kochnevns/finances-backend/internal/lib/export"
+	"github.com/kochnevns/finances-backend/internal/lib/statement"
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+var expenses = []models.Expense{
+	{Date: "2024-04-01", Amount: 100, Currency: "RUB", Category: "Моти", Color: "#fff", Description: `кофе, "с собой"`, Tags: []string{"кофе", "работа"}, Account: "Карта"},
+	{Date: "2024-04-02", Amount: 7, Currency: "USD", Category: "<Моти'>", Description: "такси & чай"},
+}
+
+func write(t *testing.T, format string, list []models.Expense) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := export.New(&buf, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range list {
+		if err := w.Write(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestNew_UnknownFormat(t *testing.T) {
+	if _, err := export.New(io.Discard, "pdf"); !errors.Is(err, export.ErrFormat) {
+		t.Errorf("err = %v, want ErrFormat", err)
+	}
+}
+
+func TestCSV_ReadsBackWithProfile(t *testing.T) {
+	rows, err := statement.Read(bytes.NewReader(write(t, export.CSV, expenses)), export.Profile, categorize.Rules{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != len(expenses) {
+		t.Fatalf("rows: %+v", rows)
+	}
+
+	for i, row := range rows {
+		e := expenses[i]
+		got := row.Expense
+		if row.Status != models.ImportNew || got.Date != e.Date || got.Amount != e.Amount ||
+			got.Currency != e.Currency || got.Category != e.Category || got.Description != e.Description {
+			t.Errorf("row %d: %+v, want %+v", i, row, e)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		list []models.Expense
+	}{
+		{"none", nil},
+		{"some", expenses},
+	} {
+		var got []struct {
+			Date        string   `json:"date"`
+			Amount      int64    `json:"amount"`
+			Currency    string   `json:"currency"`
+			Category    string   `json:"category"`
+			Color       string   `json:"color"`
+			Description string   `json:"description"`
+			Tags        []string `json:"tags"`
+			Account     string   `json:"account"`
+		}
+		if err := json.Unmarshal(write(t, export.JSON, tc.list), &got); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		if got == nil || len(got) != len(tc.list) {
+			t.Fatalf("%s: %+v", tc.name, got)
+		}
+		for i, e := range tc.list {
+			g := got[i]
+			if g.Date != e.Date || g.Amount != e.Amount || g.Currency != e.Currency ||
+				g.Category != e.Category || g.Color != e.Color || g.Description != e.Description ||
+				!slices.Equal(g.Tags, e.Tags) || g.Account != e.Account {
+				t.Errorf("%s: expense %d: %+v, want %+v", tc.name, i, g, e)
+			}
+		}
+	}
+}
+
+func TestXLSX(t *testing.T) {
+	data := write(t, export.XLSX, expenses)
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sheet []byte
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Every part must be well-formed XML.
+		d := xml.NewDecoder(bytes.NewReader(content))
+		for {
+			if _, err := d.Token(); err != nil {
+				if !errors.Is(err, io.EOF) {
+					t.Errorf("%s: %v", f.Name, err)
+				}
+				break
+			}
+		}
+
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			sheet = content
+		}
+	}
+
+	var ws struct {
+		Rows []struct {
+			Cells []struct {
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(sheet, &ws); err != nil {
+		t.Fatal(err)
+	}
+	if len(ws.Rows) != 1+len(expenses) {
+		t.Fatalf("rows: %+v", ws.Rows)
+	}
+
+	for i, e := range expenses {
+		var cells []string
+		for _, c := range ws.Rows[i+1].Cells {
+			cells = append(cells, c.Value+c.Inline)
+		}
+
+		want := []string{
+			e.Date, strconv.FormatInt(e.Amount, 10), e.Currency, e.Category, e.Color, e.Description,
+			strings.Join(e.Tags, ","), e.Account,
+		}
+		if !slices.Equal(cells, want) {
+			t.Errorf("row %d: %q, want %q", i+1, cells, want)
+		}
+		if ws.Rows[i+1].Cells[1].Type != "" {
+			t.Errorf("row %d: the amount is not a number", i+1)
+		}
+	}
+}
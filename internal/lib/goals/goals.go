@@ -0,0 +1,124 @@
+// Package goals works out how far savings goals are and when they will be
+// reached at the pace they are saved up for.
+package goals
+
+import (
+	"math"
+	"time"
+
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+// PaceMonths is how many recent months the pace of saving is taken over.
+const PaceMonths = 3
+
+// daysPerMonth is the length of an average month.
+const daysPerMonth = 365.25 / 12
+
+// horizon is how many days ahead a goal is projected. A target further away
+// at the pace is as good as never reached, and its date would overflow.
+const horizon = 100 * 365
+
+// Progress is how far a goal is and how it is going. Amounts are in the
+// currency of the goal.
+type Progress struct {
+	Saved     int64
+	Remaining int64   // to the target, 0 once it is reached
+	Percent   float64 // of the target saved, to a tenth
+	// MonthlyRequired is what is left to save per month to reach the target
+	// by the deadline, all of it once the deadline is due; 0 without one.
+	MonthlyRequired int64
+	// MonthlyPace is what was saved per month over the last PaceMonths, or
+	// since saving started when that is later.
+	MonthlyPace int64
+	// ProjectedOn is when the target is reached at the pace, or was reached,
+	// as YYYY-MM-DD. It is empty when nothing is being saved or the target
+	// is beyond the horizon at the pace.
+	ProjectedOn string
+	// OnTrack tells that the target is reached, or will be by the deadline
+	// at the pace.
+	OnTrack bool
+}
+
+// Compute returns the progress of the goal as of today. Contributions are
+// the goal's, the oldest first.
+func Compute(goal models.Goal, contributions []models.Contribution, today time.Time) (Progress, error) {
+	today = day(today)
+
+	// Saving starts with the goal or with the first contribution, which may
+	// be recorded after the fact.
+	started, err := time.Parse(time.DateOnly, goal.CreatedOn)
+	if err != nil {
+		return Progress{}, err
+	}
+	if len(contributions) > 0 && contributions[0].Date < goal.CreatedOn {
+		if started, err = time.Parse(time.DateOnly, contributions[0].Date); err != nil {
+			return Progress{}, err
+		}
+	}
+
+	paceFrom := today.AddDate(0, -PaceMonths, 0)
+	if started.After(paceFrom) {
+		paceFrom = started
+	}
+
+	var p Progress
+	var recent int64
+	for _, c := range contributions {
+		date, err := time.Parse(time.DateOnly, c.Date)
+		if err != nil {
+			return Progress{}, err
+		}
+
+		if p.Saved < goal.Target && p.Saved+c.Amount >= goal.Target {
+			p.ProjectedOn = c.Date
+		}
+		p.Saved += c.Amount
+
+		if !date.Before(paceFrom) && !date.After(today) {
+			recent += c.Amount
+		}
+	}
+
+	if goal.Target > 0 {
+		p.Percent = math.Round(float64(p.Saved)/float64(goal.Target)*1000) / 10
+	}
+
+	months := math.Max(today.Sub(paceFrom).Hours()/24/daysPerMonth, 1)
+	p.MonthlyPace = int64(math.Round(float64(recent) / months))
+
+	if p.Saved >= goal.Target {
+		p.OnTrack = true
+		return p, nil
+	}
+
+	p.Remaining = goal.Target - p.Saved
+	p.ProjectedOn = ""
+
+	if p.MonthlyPace > 0 {
+		if days := math.Ceil(float64(p.Remaining) / float64(p.MonthlyPace) * daysPerMonth); days <= horizon {
+			p.ProjectedOn = today.AddDate(0, 0, int(days)).Format(time.DateOnly)
+		}
+	}
+
+	if goal.Deadline == "" {
+		p.OnTrack = p.ProjectedOn != ""
+		return p, nil
+	}
+
+	deadline, err := time.Parse(time.DateOnly, goal.Deadline)
+	if err != nil {
+		return Progress{}, err
+	}
+
+	left := math.Max(math.Ceil(deadline.Sub(today).Hours()/24/daysPerMonth), 1)
+	p.MonthlyRequired = int64(math.Ceil(float64(p.Remaining) / left))
+	p.OnTrack = p.ProjectedOn != "" && p.ProjectedOn <= goal.Deadline
+
+	return p, nil
+}
+
+// day returns the date of t at midnight UTC, the way dates are parsed.
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
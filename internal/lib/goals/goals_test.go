@@ -0,0 +1,106 @@
+package goals_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kochnevns/finances-backend/internal/lib/goals"
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+func TestCompute(t *testing.T) {
+	today := time.Date(2024, 6, 15, 18, 30, 0, 0, time.Local)
+
+	for _, tc := range []struct {
+		name          string
+		goal          models.Goal
+		contributions []models.Contribution
+		want          goals.Progress
+	}{
+		{
+			name: "nothing saved",
+			goal: models.Goal{Target: 1000, CreatedOn: "2024-01-01"},
+			want: goals.Progress{Remaining: 1000},
+		},
+		{
+			name: "nothing saved by a deadline",
+			goal: models.Goal{Target: 1000, CreatedOn: "2024-01-01", Deadline: "2024-09-01"},
+			want: goals.Progress{Remaining: 1000, MonthlyRequired: 334},
+		},
+		{
+			name:          "started today",
+			goal:          models.Goal{Target: 1000, CreatedOn: "2024-06-15"},
+			contributions: []models.Contribution{{Date: "2024-06-15", Amount: 100}},
+			want:          goals.Progress{Saved: 100, Remaining: 900, Percent: 10, MonthlyPace: 100, ProjectedOn: "2025-03-16", OnTrack: true},
+		},
+		{
+			name: "pace of the last months",
+			goal: models.Goal{Target: 1000, CreatedOn: "2023-01-01", Deadline: "2024-12-31"},
+			contributions: []models.Contribution{
+				{Date: "2023-06-01", Amount: 400},
+				{Date: "2024-04-01", Amount: 150},
+				{Date: "2024-05-01", Amount: 150},
+			},
+			want: goals.Progress{Saved: 700, Remaining: 300, Percent: 70, MonthlyRequired: 43, MonthlyPace: 99, ProjectedOn: "2024-09-16", OnTrack: true},
+		},
+		{
+			name:          "recorded before the goal",
+			goal:          models.Goal{Target: 1000, CreatedOn: "2024-06-01"},
+			contributions: []models.Contribution{{Date: "2024-05-15", Amount: 100}},
+			want:          goals.Progress{Saved: 100, Remaining: 900, Percent: 10, MonthlyPace: 98, ProjectedOn: "2025-03-22", OnTrack: true},
+		},
+		{
+			name:          "past the deadline",
+			goal:          models.Goal{Target: 1000, CreatedOn: "2024-01-01", Deadline: "2024-05-01"},
+			contributions: []models.Contribution{{Date: "2024-06-01", Amount: 200}},
+			want:          goals.Progress{Saved: 200, Remaining: 800, Percent: 20, MonthlyRequired: 800, MonthlyPace: 66, ProjectedOn: "2025-06-19"},
+		},
+		{
+			name: "reached",
+			goal: models.Goal{Target: 1000, CreatedOn: "2024-01-01", Deadline: "2024-02-01"},
+			contributions: []models.Contribution{
+				{Date: "2024-02-01", Amount: 600},
+				{Date: "2024-03-01", Amount: 500},
+				{Date: "2024-04-01", Amount: -50},
+			},
+			want: goals.Progress{Saved: 1050, Percent: 105, MonthlyPace: -17, ProjectedOn: "2024-03-01", OnTrack: true},
+		},
+		{
+			name:          "taken back",
+			goal:          models.Goal{Target: 1000, CreatedOn: "2024-01-01"},
+			contributions: []models.Contribution{{Date: "2024-02-01", Amount: 500}, {Date: "2024-05-01", Amount: -200}},
+			want:          goals.Progress{Saved: 300, Remaining: 700, Percent: 30, MonthlyPace: -66},
+		},
+		{
+			name:          "beyond the horizon",
+			goal:          models.Goal{Target: 1 << 62, CreatedOn: "2024-06-15"},
+			contributions: []models.Contribution{{Date: "2024-06-15", Amount: 3}},
+			want:          goals.Progress{Saved: 3, Remaining: 1<<62 - 3, MonthlyPace: 3},
+		},
+	} {
+		got, err := goals.Compute(tc.goal, tc.contributions, today)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+
+		if got != tc.want {
+			t.Errorf("%s: %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCompute_BadDates(t *testing.T) {
+	for name, tc := range map[string]struct {
+		goal          models.Goal
+		contributions []models.Contribution
+	}{
+		"created":      {goal: models.Goal{Target: 1, CreatedOn: "вчера"}},
+		"deadline":     {goal: models.Goal{Target: 2, CreatedOn: "2024-01-01", Deadline: "31.12.2024"}},
+		"contribution": {goal: models.Goal{Target: 1, CreatedOn: "2024-01-01"}, contributions: []models.Contribution{{Date: "2024-13-01", Amount: 1}}},
+	} {
+		if _, err := goals.Compute(tc.goal, tc.contributions, time.Now()); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
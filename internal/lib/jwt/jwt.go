@@ -0,0 +1,57 @@
+// Package jwt issues and verifies the HMAC-signed bearer tokens used by the
+// gRPC server and the HTTP gateway.
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+type claims struct {
+	Name string `json:"name"`
+	jwt.RegisteredClaims
+}
+
+// NewToken issues a token for the user that expires after ttl.
+func NewToken(user models.User, secret string, ttl time.Duration) (string, error) {
+	now := time.Now()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{
+		Name: user.Name,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatInt(user.ID, 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	})
+
+	return token.SignedString([]byte(secret))
+}
+
+// ParseToken verifies the token signature and expiration and returns the ID
+// of the user it was issued for.
+func ParseToken(tokenString string, secret string) (int64, error) {
+	var c claims
+
+	_, err := jwt.ParseWithClaims(tokenString, &c, func(*jwt.Token) (any, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	userID, err := strconv.ParseInt(c.Subject, 10, 64)
+	if err != nil || userID <= 0 {
+		return 0, fmt.Errorf("%w: bad subject %q", ErrInvalidToken, c.Subject)
+	}
+
+	return userID, nil
+}
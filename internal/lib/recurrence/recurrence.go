@@ -0,0 +1,110 @@
+// Package recurrence computes the dates of recurring expenses.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	Weekly  = "weekly"
+	Monthly = "monthly"
+	Yearly  = "yearly"
+)
+
+var ErrInvalid = errors.New("invalid recurrence rule")
+
+// Rule repeats every Interval weeks, months or years from Start. Start fixes
+// the weekday, the day of the month or the date of the occurrences: a rule
+// starting on the 31st falls on the last day of shorter months.
+type Rule struct {
+	Frequency string
+	Interval  int
+	Start     time.Time
+	End       time.Time // inclusive, zero means never
+}
+
+// Validate checks the frequency and the interval of the rule.
+func (r Rule) Validate() error {
+	switch r.Frequency {
+	case Weekly, Monthly, Yearly:
+	default:
+		return fmt.Errorf("%w: unknown frequency %q", ErrInvalid, r.Frequency)
+	}
+
+	if r.Interval <= 0 {
+		return fmt.Errorf("%w: interval must be positive", ErrInvalid)
+	}
+
+	if !r.End.IsZero() && r.End.Before(r.Start) {
+		return fmt.Errorf("%w: end is before start", ErrInvalid)
+	}
+
+	return nil
+}
+
+// occurrence returns the n-th date of the rule, counting from 0.
+func (r Rule) occurrence(n int) time.Time {
+	switch r.Frequency {
+	case Weekly:
+		return r.Start.AddDate(0, 0, 7*r.Interval*n)
+	case Yearly:
+		return addMonths(r.Start, 12*r.Interval*n)
+	default:
+		return addMonths(r.Start, r.Interval*n)
+	}
+}
+
+// addMonths moves t by months, keeping its day unless the target month is
+// shorter.
+func addMonths(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1).Day()
+
+	return first.AddDate(0, 0, min(t.Day(), last)-1)
+}
+
+// Next returns the first date of the rule on or after from. It is false when
+// the rule has ended by then.
+func (r Rule) Next(from time.Time) (time.Time, bool) {
+	n := 0
+	if from.After(r.Start) {
+		// Start from an estimate that is never past the answer.
+		switch r.Frequency {
+		case Weekly:
+			n = int(from.Sub(r.Start).Hours()/24) / (7 * r.Interval)
+		case Yearly:
+			n = (from.Year() - r.Start.Year() - 1) / r.Interval
+		default:
+			n = ((from.Year()-r.Start.Year())*12 + int(from.Month()-r.Start.Month()) - 1) / r.Interval
+		}
+		n = max(n, 0)
+	}
+
+	for {
+		d := r.occurrence(n)
+		if !r.End.IsZero() && d.After(r.End) {
+			return time.Time{}, false
+		}
+		if !d.Before(from) {
+			return d, true
+		}
+		n++
+	}
+}
+
+// Between returns the dates of the rule within [from, to], at most limit of
+// them unless limit is 0.
+func (r Rule) Between(from, to time.Time, limit int) []time.Time {
+	var dates []time.Time
+
+	for d, ok := r.Next(from); ok && !d.After(to); d, ok = r.Next(d.AddDate(0, 0, 1)) {
+		dates = append(dates, d)
+		if len(dates) == limit {
+			break
+		}
+	}
+
+	return dates
+}
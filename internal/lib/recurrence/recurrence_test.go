@@ -0,0 +1,105 @@
+package recurrence_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kochnevns/finances-backend/internal/lib/recurrence"
+)
+
+func date(s string) time.Time {
+	d, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+
+	return d
+}
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		rule  recurrence.Rule
+		valid bool
+	}{
+		{"monthly", recurrence.Rule{Frequency: recurrence.Monthly, Interval: 1, Start: date("2024-01-31")}, true},
+		{"ends on start", recurrence.Rule{Frequency: recurrence.Weekly, Interval: 2, Start: date("2024-01-01"), End: date("2024-01-01")}, true},
+		{"unknown frequency", recurrence.Rule{Frequency: "daily", Interval: 1, Start: date("2024-01-01")}, false},
+		{"zero interval", recurrence.Rule{Frequency: recurrence.Yearly, Start: date("2024-01-01")}, false},
+		{"ends before start", recurrence.Rule{Frequency: recurrence.Monthly, Interval: 1, Start: date("2024-01-02"), End: date("2024-01-01")}, false},
+	} {
+		err := tc.rule.Validate()
+		if tc.valid && err != nil || !tc.valid && !errors.Is(err, recurrence.ErrInvalid) {
+			t.Errorf("%s: %v", tc.name, err)
+		}
+	}
+}
+
+func TestBetween(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		rule     recurrence.Rule
+		from, to string
+		limit    int
+		want     []string
+	}{
+		{
+			name: "month end",
+			rule: recurrence.Rule{Frequency: recurrence.Monthly, Interval: 1, Start: date("2024-01-31")},
+			from: "2024-01-01", to: "2024-05-31",
+			want: []string{"2024-01-31", "2024-02-29", "2024-03-31", "2024-04-30", "2024-05-31"},
+		},
+		{
+			name: "month end from the middle",
+			rule: recurrence.Rule{Frequency: recurrence.Monthly, Interval: 1, Start: date("2023-08-31")},
+			from: "2024-02-01", to: "2024-04-30",
+			want: []string{"2024-02-29", "2024-03-31", "2024-04-30"},
+		},
+		{
+			name: "every other month",
+			rule: recurrence.Rule{Frequency: recurrence.Monthly, Interval: 2, Start: date("2024-01-30")},
+			from: "2024-01-31", to: "2024-12-31",
+			want: []string{"2024-03-30", "2024-05-30", "2024-07-30", "2024-09-30", "2024-11-30"},
+		},
+		{
+			name: "leap day yearly",
+			rule: recurrence.Rule{Frequency: recurrence.Yearly, Interval: 1, Start: date("2024-02-29")},
+			from: "2024-01-01", to: "2028-12-31",
+			want: []string{"2024-02-29", "2025-02-28", "2026-02-28", "2027-02-28", "2028-02-29"},
+		},
+		{
+			name: "weekly",
+			rule: recurrence.Rule{Frequency: recurrence.Weekly, Interval: 2, Start: date("2024-04-01")},
+			from: "2024-04-10", to: "2024-05-15",
+			want: []string{"2024-04-15", "2024-04-29", "2024-05-13"},
+		},
+		{
+			name: "ended",
+			rule: recurrence.Rule{Frequency: recurrence.Monthly, Interval: 1, Start: date("2024-01-15"), End: date("2024-03-15")},
+			from: "2024-01-01", to: "2024-12-31",
+			want: []string{"2024-01-15", "2024-02-15", "2024-03-15"},
+		},
+		{
+			name: "limit",
+			rule: recurrence.Rule{Frequency: recurrence.Weekly, Interval: 1, Start: date("2024-04-01")},
+			from: "2024-04-01", to: "2024-12-31", limit: 2,
+			want: []string{"2024-04-01", "2024-04-08"},
+		},
+		{
+			name: "before start",
+			rule: recurrence.Rule{Frequency: recurrence.Monthly, Interval: 1, Start: date("2024-06-01")},
+			from: "2024-01-01", to: "2024-05-31",
+		},
+	} {
+		var got []string
+		for _, d := range tc.rule.Between(date(tc.from), date(tc.to), tc.limit) {
+			got = append(got, d.Format(time.DateOnly))
+		}
+
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
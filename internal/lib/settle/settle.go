@@ -0,0 +1,218 @@
+// Package settle shares expenses between people and works out who should
+// pay whom to settle up.
+package settle
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+var ErrInvalid = errors.New("invalid sharing")
+
+// percentTolerance is how far the percents of the shares may add up from
+// 100, to allow for thirds written out as 33.33. It has some slack for
+// the sum being off in floating point.
+const percentTolerance = 0.01 + 1e-9
+
+// Share checks the sharing of amount and computes the amount of each
+// share: equal shares differ by a cent at most, the first ones taking the
+// odd cents; percent shares are rounded so that they add up; exact shares
+// must add up to amount. Names are trimmed.
+func Share(amount int64, sharing models.Sharing) (models.Sharing, error) {
+	sharing.PaidBy = strings.TrimSpace(sharing.PaidBy)
+	if sharing.PaidBy == "" {
+		return models.Sharing{}, fmt.Errorf("%w: who paid is required", ErrInvalid)
+	}
+
+	if len(sharing.Shares) == 0 {
+		return models.Sharing{}, fmt.Errorf("%w: participants are required", ErrInvalid)
+	}
+
+	if amount <= 0 {
+		return models.Sharing{}, fmt.Errorf("%w: amount must be positive", ErrInvalid)
+	}
+
+	shares := make([]models.Share, 0, len(sharing.Shares))
+	for _, s := range sharing.Shares {
+		s.Person = strings.TrimSpace(s.Person)
+		if s.Person == "" {
+			return models.Sharing{}, fmt.Errorf("%w: participant name is required", ErrInvalid)
+		}
+		if slices.ContainsFunc(shares, func(o models.Share) bool { return o.Person == s.Person }) {
+			return models.Sharing{}, fmt.Errorf("%w: %q takes part twice", ErrInvalid, s.Person)
+		}
+		shares = append(shares, s)
+	}
+	sharing.Shares = shares
+
+	switch sharing.Method {
+	case models.ShareEqual:
+		n := int64(len(shares))
+		for i := range shares {
+			shares[i].Percent = 0
+			shares[i].Amount = amount / n
+			if int64(i) < amount%n {
+				shares[i].Amount++
+			}
+		}
+	case models.SharePercent:
+		if err := sharePercent(amount, shares); err != nil {
+			return models.Sharing{}, err
+		}
+	case models.ShareExact:
+		var sum int64
+		for i, s := range shares {
+			if s.Amount <= 0 {
+				return models.Sharing{}, fmt.Errorf("%w: share of %q must be positive", ErrInvalid, s.Person)
+			}
+			shares[i].Percent = 0
+			sum += s.Amount
+		}
+		if sum != amount {
+			return models.Sharing{}, fmt.Errorf("%w: shares add up to %d, not the amount %d", ErrInvalid, sum, amount)
+		}
+	default:
+		return models.Sharing{}, fmt.Errorf("%w: unknown method %q", ErrInvalid, sharing.Method)
+	}
+
+	return sharing, nil
+}
+
+// sharePercent rounds the shares down and gives the cents left to the
+// shares with the largest fractions, the first of equal ones.
+func sharePercent(amount int64, shares []models.Share) error {
+	var percents float64
+	for _, s := range shares {
+		if s.Percent <= 0 {
+			return fmt.Errorf("%w: percent of %q must be positive", ErrInvalid, s.Person)
+		}
+		percents += s.Percent
+	}
+
+	if math.Abs(percents-100) > percentTolerance {
+		return fmt.Errorf("%w: percents add up to %g, not 100", ErrInvalid, percents)
+	}
+
+	fractions := make([]float64, len(shares))
+	left := amount
+	for i, s := range shares {
+		exact := float64(amount) * s.Percent / percents
+		shares[i].Amount = int64(math.Floor(exact))
+		fractions[i] = exact - math.Floor(exact)
+		left -= shares[i].Amount
+	}
+
+	order := make([]int, len(shares))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int { return cmp.Compare(fractions[b], fractions[a]) })
+
+	for i := 0; left > 0; i, left = i+1, left-1 {
+		shares[order[i%len(order)]].Amount++
+	}
+
+	return nil
+}
+
+// Balance is what a person is owed in total, negative when they owe.
+type Balance struct {
+	Person string
+	Amount int64
+}
+
+// Net offsets the debts between each two people against each other and
+// returns what is left of them together with the balance of each person.
+// Debts are ordered by debtor and creditor, balances from the largest.
+func Net(debts []models.Debt) ([]models.Debt, []Balance) {
+	type pair struct{ a, b string } // a < b, a owes b when positive
+
+	pairs := map[pair]int64{}
+	balances := map[string]int64{}
+	for _, d := range debts {
+		if d.From == d.To {
+			continue
+		}
+
+		if d.From < d.To {
+			pairs[pair{d.From, d.To}] += d.Amount
+		} else {
+			pairs[pair{d.To, d.From}] -= d.Amount
+		}
+		balances[d.From] -= d.Amount
+		balances[d.To] += d.Amount
+	}
+
+	var net []models.Debt
+	for p, amount := range pairs {
+		switch {
+		case amount > 0:
+			net = append(net, models.Debt{From: p.a, To: p.b, Amount: amount})
+		case amount < 0:
+			net = append(net, models.Debt{From: p.b, To: p.a, Amount: -amount})
+		}
+	}
+	slices.SortFunc(net, func(x, y models.Debt) int {
+		return cmp.Or(cmp.Compare(x.From, y.From), cmp.Compare(x.To, y.To))
+	})
+
+	var list []Balance
+	for person, amount := range balances {
+		if amount != 0 {
+			list = append(list, Balance{Person: person, Amount: amount})
+		}
+	}
+	slices.SortFunc(list, func(x, y Balance) int {
+		return cmp.Or(cmp.Compare(y.Amount, x.Amount), cmp.Compare(x.Person, y.Person))
+	})
+
+	return net, list
+}
+
+// Transfers suggests payments that bring all balances to zero: the one
+// who owes the most pays the one who is owed the most, until everybody is
+// even. That takes fewer transfers than there are people with a balance,
+// but not always the fewest possible: those would settle groups whose
+// balances add up to zero apart, and finding such groups is a subset sum
+// problem, exponential in the number of people.
+func Transfers(balances []Balance) []models.Debt {
+	var creditors, debtors []Balance
+	for _, b := range balances {
+		switch {
+		case b.Amount > 0:
+			creditors = append(creditors, b)
+		case b.Amount < 0:
+			debtors = append(debtors, Balance{Person: b.Person, Amount: -b.Amount})
+		}
+	}
+
+	largest := func(x, y Balance) int {
+		return cmp.Or(cmp.Compare(y.Amount, x.Amount), cmp.Compare(x.Person, y.Person))
+	}
+
+	var transfers []models.Debt
+	for len(creditors) > 0 && len(debtors) > 0 {
+		slices.SortFunc(creditors, largest)
+		slices.SortFunc(debtors, largest)
+
+		amount := min(creditors[0].Amount, debtors[0].Amount)
+		transfers = append(transfers, models.Debt{From: debtors[0].Person, To: creditors[0].Person, Amount: amount})
+
+		creditors[0].Amount -= amount
+		debtors[0].Amount -= amount
+		if creditors[0].Amount == 0 {
+			creditors = creditors[1:]
+		}
+		if debtors[0].Amount == 0 {
+			debtors = debtors[1:]
+		}
+	}
+
+	return transfers
+}
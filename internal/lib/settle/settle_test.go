@@ -0,0 +1,147 @@
+package settle_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/kochnevns/finances-backend/internal/lib/settle"
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+func shares(people ...string) []models.Share {
+	list := make([]models.Share, 0, len(people))
+	for _, p := range people {
+		list = append(list, models.Share{Person: p})
+	}
+
+	return list
+}
+
+func percents(percents ...float64) []models.Share {
+	list := make([]models.Share, 0, len(percents))
+	for i, p := range percents {
+		list = append(list, models.Share{Person: string(rune('A' + i)), Percent: p})
+	}
+
+	return list
+}
+
+func TestShare(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		amount int64
+		method string
+		shares []models.Share
+		want   []int64
+	}{
+		{"equal", 300, models.ShareEqual, shares("A", "B", "C"), []int64{100, 100, 100}},
+		{"equal odd cents", 100, models.ShareEqual, shares("A", "B", "C"), []int64{34, 33, 33}},
+		{"equal fewer cents than people", 2, models.ShareEqual, shares("A", "B", "C"), []int64{1, 1, 0}},
+		{"percent", 1000, models.SharePercent, percents(12.5, 87.5), []int64{125, 875}},
+		{"percent thirds", 100, models.SharePercent, percents(33.33, 33.33, 33.34), []int64{33, 33, 34}},
+		{"percent thirds short of 100", 100, models.SharePercent, percents(33.33, 33.33, 33.33), []int64{34, 33, 33}},
+		{"percent equal fractions", 10, models.SharePercent, percents(15, 85), []int64{2, 8}},
+		{"percent largest fraction", 1001, models.SharePercent, percents(30, 30, 40), []int64{300, 300, 401}},
+		{"exact", 100, models.ShareExact, []models.Share{{Person: "A", Amount: 70}, {Person: "B", Amount: 30}}, []int64{70, 30}},
+	} {
+		shared, err := settle.Share(tc.amount, models.Sharing{PaidBy: "A", Method: tc.method, Shares: tc.shares})
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+
+		var got []int64
+		var sum int64
+		for _, s := range shared.Shares {
+			got = append(got, s.Amount)
+			sum += s.Amount
+		}
+
+		if !slices.Equal(got, tc.want) || sum != tc.amount {
+			t.Errorf("%s: shares %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestShare_Invalid(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		amount  int64
+		sharing models.Sharing
+	}{
+		{"no payer", 100, models.Sharing{PaidBy: " ", Method: models.ShareEqual, Shares: shares("A")}},
+		{"no participants", 100, models.Sharing{PaidBy: "A", Method: models.ShareEqual}},
+		{"zero amount", 0, models.Sharing{PaidBy: "A", Method: models.ShareEqual, Shares: shares("A")}},
+		{"twice", 100, models.Sharing{PaidBy: "A", Method: models.ShareEqual, Shares: shares("A", " A ")}},
+		{"no name", 100, models.Sharing{PaidBy: "A", Method: models.ShareEqual, Shares: shares("A", "")}},
+		{"percents short", 100, models.Sharing{PaidBy: "A", Method: models.SharePercent, Shares: percents(50, 49)}},
+		{"zero percent", 100, models.Sharing{PaidBy: "A", Method: models.SharePercent, Shares: percents(100, 0)}},
+		{"exact short", 100, models.Sharing{PaidBy: "A", Method: models.ShareExact, Shares: []models.Share{{Person: "A", Amount: 90}}}},
+		{"unknown method", 100, models.Sharing{PaidBy: "A", Method: "by weight", Shares: shares("A")}},
+	} {
+		if _, err := settle.Share(tc.amount, tc.sharing); !errors.Is(err, settle.ErrInvalid) {
+			t.Errorf("%s: %v", tc.name, err)
+		}
+	}
+}
+
+func TestNet(t *testing.T) {
+	net, balances := settle.Net([]models.Debt{
+		{From: "Боря", To: "Аня", Amount: 500},
+		{From: "Аня", To: "Боря", Amount: 100},
+		{From: "Вика", To: "Аня", Amount: 340},
+		{From: "Вика", To: "Вика", Amount: 50},
+		{From: "Вика", To: "Боря", Amount: 200},
+		{From: "Боря", To: "Вика", Amount: 200},
+	})
+
+	wantNet := []models.Debt{{From: "Боря", To: "Аня", Amount: 400}, {From: "Вика", To: "Аня", Amount: 340}}
+	if !slices.Equal(net, wantNet) {
+		t.Errorf("net: %+v", net)
+	}
+
+	wantBalances := []settle.Balance{{Person: "Аня", Amount: 740}, {Person: "Вика", Amount: -340}, {Person: "Боря", Amount: -400}}
+	if !slices.Equal(balances, wantBalances) {
+		t.Errorf("balances: %+v", balances)
+	}
+}
+
+func TestTransfers(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		balances []settle.Balance
+		want     []models.Debt
+	}{
+		{"even", nil, nil},
+		{
+			name:     "one creditor",
+			balances: []settle.Balance{{Person: "Аня", Amount: 740}, {Person: "Вика", Amount: -340}, {Person: "Боря", Amount: -400}},
+			want:     []models.Debt{{From: "Боря", To: "Аня", Amount: 400}, {From: "Вика", To: "Аня", Amount: 340}},
+		},
+		{
+			name:     "largest first",
+			balances: []settle.Balance{{Person: "А", Amount: 60}, {Person: "Б", Amount: 40}, {Person: "В", Amount: -70}, {Person: "Г", Amount: -30}},
+			want:     []models.Debt{{From: "В", To: "А", Amount: 60}, {From: "Г", To: "Б", Amount: 30}, {From: "В", To: "Б", Amount: 10}},
+		},
+	} {
+		got := settle.Transfers(tc.balances)
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: %+v, want %+v", tc.name, got, tc.want)
+		}
+
+		left := map[string]int64{}
+		for _, b := range tc.balances {
+			left[b.Person] = b.Amount
+		}
+		for _, d := range got {
+			left[d.From] += d.Amount
+			left[d.To] -= d.Amount
+		}
+		for person, amount := range left {
+			if amount != 0 {
+				t.Errorf("%s: %s is left with %d", tc.name, person, amount)
+			}
+		}
+	}
+}
@@ -0,0 +1,312 @@
+// Package statement reads expenses from bank CSV exports according to an
+// import profile.
+package statement
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kochnevns/finances-backend/internal/lib/categorize"
+	"github.com/kochnevns/finances-backend/internal/lib/currency"
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+var ErrInvalid = errors.New("invalid statement")
+
+// dateFormats are tried in order when the profile has no date format.
+// Dates with slashes are read day first.
+var dateFormats = []string{
+	time.DateOnly,
+	time.DateTime,
+	time.RFC3339,
+	"02.01.2006",
+	"02.01.2006 15:04:05",
+	"02.01.2006 15:04",
+	"02.01.06",
+	"02/01/2006",
+	"02/01/2006 15:04:05",
+	"2006/01/02",
+}
+
+// ValidateProfile checks that the profile names the required columns.
+func ValidateProfile(p models.ImportProfile) error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("%w: profile name is required", ErrInvalid)
+	}
+
+	if p.Date == "" || p.Amount == "" || p.Description == "" {
+		return fmt.Errorf("%w: date, amount and description columns are required", ErrInvalid)
+	}
+
+	if utf8.RuneCountInString(p.Delimiter) > 1 {
+		return fmt.Errorf("%w: delimiter must be one character", ErrInvalid)
+	}
+
+	if p.DecimalSeparator != "" && p.DecimalSeparator != "." && p.DecimalSeparator != "," {
+		return fmt.Errorf("%w: decimal separator must be \".\" or \",\"", ErrInvalid)
+	}
+
+	if p.Multiplier < 0 {
+		return fmt.Errorf("%w: multiplier must be positive", ErrInvalid)
+	}
+
+	return nil
+}
+
+// Read parses the statement. Every data line becomes a row: ImportNew with
+// the expense to save, ImportSkipped for incomes and refunds or ImportInvalid
+// with the reason. Categories are the names assigned by Categorize with the
+// user's categorization rules.
+func Read(r io.Reader, p models.ImportProfile, rules categorize.Rules) ([]models.ImportRow, error) {
+	if err := ValidateProfile(p); err != nil {
+		return nil, err
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	cr.LazyQuotes = true
+	if p.Delimiter != "" {
+		cr.Comma, _ = utf8.DecodeRuneInString(p.Delimiter)
+	}
+
+	var header []string
+	var rows []models.ImportRow
+	for line := 1; ; line++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+		}
+
+		if line <= p.SkipRows {
+			continue
+		}
+
+		if header == nil && !p.NoHeader {
+			header = record
+			continue
+		}
+
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		rows = append(rows, readRow(line, record, header, p, rules))
+	}
+}
+
+func readRow(line int, record, header []string, p models.ImportProfile, rules categorize.Rules) models.ImportRow {
+	row := models.ImportRow{Line: line, Status: models.ImportInvalid}
+
+	field := func(column string) (string, error) {
+		i, err := columnIndex(column, header)
+		if err != nil {
+			return "", err
+		}
+		if i >= len(record) {
+			return "", fmt.Errorf("no column %q", column)
+		}
+
+		return strings.TrimSpace(record[i]), nil
+	}
+
+	var values [3]string
+	for i, column := range []string{p.Date, p.Amount, p.Description} {
+		v, err := field(column)
+		if err != nil {
+			row.Reason = err.Error()
+			return row
+		}
+		values[i] = v
+	}
+
+	date, err := ParseDate(values[0], p.DateFormat)
+	if err != nil {
+		row.Reason = err.Error()
+		return row
+	}
+
+	amount, err := ParseAmount(values[1], p.DecimalSeparator)
+	if err != nil {
+		row.Reason = err.Error()
+		return row
+	}
+
+	if p.ExpensesNegative {
+		amount = -amount
+	}
+
+	multiplier := p.Multiplier
+	if multiplier == 0 {
+		multiplier = 1
+	}
+
+	row.Expense = models.Expense{
+		Date:        date,
+		Amount:      int64(math.Round(amount * multiplier)),
+		Description: values[2],
+	}
+
+	if row.Expense.Amount <= 0 {
+		row.Status = models.ImportSkipped
+		row.Reason = "not an expense"
+		return row
+	}
+
+	if p.Currency != "" {
+		code, err := field(p.Currency)
+		if err != nil {
+			row.Reason = err.Error()
+			return row
+		}
+
+		if code != "" {
+			if row.Expense.Currency, err = currency.Normalize(code); err != nil {
+				row.Reason = err.Error()
+				return row
+			}
+		}
+	}
+
+	var fromColumn string
+	if p.Category != "" {
+		if fromColumn, err = field(p.Category); err != nil {
+			row.Reason = err.Error()
+			return row
+		}
+	}
+
+	row.Expense.Category = Categorize(p, rules, row.Expense, fromColumn)
+	if row.Expense.Category == "" {
+		row.Reason = "no category"
+		return row
+	}
+
+	row.Status = models.ImportNew
+
+	return row
+}
+
+func columnIndex(column string, header []string) (int, error) {
+	for i, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), column) {
+			return i, nil
+		}
+	}
+
+	if n, err := strconv.Atoi(column); err == nil && n > 0 {
+		return n - 1, nil
+	}
+
+	return 0, fmt.Errorf("no column %q", column)
+}
+
+// Categorize returns the category of the first rule of the profile matching
+// the description of the expense, or else of the first of the user's rules
+// matching the expense, or else fromColumn, or else the default category.
+func Categorize(p models.ImportProfile, rules categorize.Rules, e models.Expense, fromColumn string) string {
+	lower := strings.ToLower(e.Description)
+	for _, rule := range p.Rules {
+		if rule.Contains != "" && strings.Contains(lower, strings.ToLower(rule.Contains)) {
+			return rule.Category
+		}
+	}
+
+	if rule, ok := rules.Categorize(e); ok {
+		return rule.Category
+	}
+
+	if fromColumn != "" {
+		return fromColumn
+	}
+
+	return p.DefaultCategory
+}
+
+// ParseDate parses a date in the layout, or in one of the common formats
+// when it is empty, and returns it as YYYY-MM-DD.
+func ParseDate(s, layout string) (string, error) {
+	layouts := dateFormats
+	if layout != "" {
+		layouts = []string{layout}
+	}
+
+	for _, l := range layouts {
+		if t, err := time.Parse(l, s); err == nil {
+			return t.Format(time.DateOnly), nil
+		}
+	}
+
+	return "", fmt.Errorf("bad date %q", s)
+}
+
+// ParseAmount parses amounts like "1 234,56", "1,234.56", "-12,5" or
+// "(99.90)" with the decimal separator, "." when empty. The other one of
+// "." and "," and spaces separate thousands. Currency signs and letters are
+// ignored.
+func ParseAmount(s, decimal string) (float64, error) {
+	if decimal == "" {
+		decimal = "."
+	}
+	thousands := ","
+	if decimal == "," {
+		thousands = "."
+	}
+
+	negative := strings.HasPrefix(strings.TrimSpace(s), "(") || strings.HasSuffix(strings.TrimSpace(s), "-")
+
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case unicode.IsDigit(r), r == '.', r == ',':
+			b.WriteRune(r)
+		case r == '-' || r == '−':
+			negative = true
+		}
+	}
+
+	digits := strings.ReplaceAll(b.String(), thousands, "")
+	if strings.Count(digits, decimal) > 1 {
+		return 0, fmt.Errorf("bad amount %q", s)
+	}
+	digits = strings.Replace(digits, decimal, ".", 1)
+
+	v, err := strconv.ParseFloat(digits, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad amount %q", s)
+	}
+
+	if negative {
+		v = -v
+	}
+
+	return v, nil
+}
+
+// Hash identifies an expense by its date, amount and description, the way
+// duplicates are told apart on import.
+func Hash(e models.Expense) string {
+	date := e.Date
+	if len(date) > len(time.DateOnly) {
+		date = date[:len(time.DateOnly)]
+	}
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf(
+		"%s|%d|%s", date, e.Amount, strings.ToLower(strings.Join(strings.Fields(e.Description), " ")),
+	)))
+
+	return hex.EncodeToString(sum[:])
+}
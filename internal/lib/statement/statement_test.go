@@ -0,0 +1,178 @@
+package statement_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kochnevns/finances-backend/internal/lib/categorize"
+	"github.com/kochnevns/finances-backend/internal/lib/statement"
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+func TestParseAmount(t *testing.T) {
+	for _, tc := range []struct {
+		in, decimal string
+		want        float64
+		bad         bool
+	}{
+		{in: "12.50", want: 12.5},
+		{in: "1,234.56", want: 1234.56},
+		{in: "1 234,56", decimal: ",", want: 1234.56},
+		{in: "1.234,56", decimal: ",", want: 1234.56},
+		{in: "-12,5", decimal: ",", want: -12.5},
+		{in: "(99.90)", want: -99.9},
+		{in: "99.90-", want: -99.9},
+		{in: "−7 ₽", want: -7},
+		{in: "$1,000", want: 1000},
+		{in: "1.2.3", bad: true},
+		{in: "1,2,3", decimal: ",", bad: true},
+		{in: "", bad: true},
+	} {
+		got, err := statement.ParseAmount(tc.in, tc.decimal)
+		switch {
+		case tc.bad && err == nil:
+			t.Errorf("ParseAmount(%q, %q) = %g, want an error", tc.in, tc.decimal, got)
+		case !tc.bad && (err != nil || got != tc.want):
+			t.Errorf("ParseAmount(%q, %q) = %g, %v; want %g", tc.in, tc.decimal, got, err, tc.want)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	for _, tc := range []struct {
+		in, layout, want string
+	}{
+		{in: "2024-04-05", want: "2024-04-05"},
+		{in: "05.04.2024", want: "2024-04-05"},
+		{in: "05.04.2024 13:45", want: "2024-04-05"},
+		{in: "05/04/2024", want: "2024-04-05"},
+		{in: "2024/04/05", want: "2024-04-05"},
+		{in: "04/05/2024", layout: "01/02/2006", want: "2024-04-05"},
+		{in: "05.04.2024", layout: "01/02/2006"},
+		{in: "вчера"},
+	} {
+		got, err := statement.ParseDate(tc.in, tc.layout)
+		if got != tc.want || (err == nil) != (tc.want != "") {
+			t.Errorf("ParseDate(%q, %q) = %q, %v; want %q", tc.in, tc.layout, got, err, tc.want)
+		}
+	}
+}
+
+func TestValidateProfile(t *testing.T) {
+	valid := models.ImportProfile{Name: "банк", Date: "Дата", Amount: "Сумма", Description: "Описание"}
+
+	for name, change := range map[string]func(p *models.ImportProfile){
+		"no name":           func(p *models.ImportProfile) { p.Name = " " },
+		"no amount column":  func(p *models.ImportProfile) { p.Amount = "" },
+		"long delimiter":    func(p *models.ImportProfile) { p.Delimiter = ";;" },
+		"decimal separator": func(p *models.ImportProfile) { p.DecimalSeparator = " " },
+		"multiplier":        func(p *models.ImportProfile) { p.Multiplier = -1 },
+	} {
+		p := valid
+		change(&p)
+		if err := statement.ValidateProfile(p); !errors.Is(err, statement.ErrInvalid) {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	if err := statement.ValidateProfile(valid); err != nil {
+		t.Errorf("valid profile: %v", err)
+	}
+}
+
+func TestRead(t *testing.T) {
+	const csv = `Выписка по карте
+Дата;Сумма;Описание;Категория
+05.04.2024;-1 234,50;Пятёрочка;
+06.04.2024;-300,00;Яндекс Такси;Транспорт
+07.04.2024;5 000,00;Зарплата;
+08.04.2024;-99,90;Аптека;
+
+09.04.2024;много;Кафе;
+`
+
+	p := models.ImportProfile{
+		Name: "банк", Delimiter: ";", SkipRows: 1,
+		Date: "Дата", Amount: "Сумма", Description: "описание", Category: "4",
+		DecimalSeparator: ",", Multiplier: 100, ExpensesNegative: true,
+		Rules:           []models.CategoryRule{{Contains: "пятёрочка", Category: "Продукты"}},
+		DefaultCategory: "Прочее",
+	}
+
+	rules, err := categorize.New([]models.CategorizationRule{{ID: 1, Category: "Здоровье", Contains: "аптека"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := statement.Read(strings.NewReader(csv), p, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		line     int
+		status   string
+		amount   int64
+		category string
+	}{
+		{3, models.ImportNew, 123450, "Продукты"},
+		{4, models.ImportNew, 30000, "Транспорт"},
+		{5, models.ImportSkipped, -500000, ""},
+		{6, models.ImportNew, 9990, "Здоровье"},
+		{7, models.ImportInvalid, 0, ""},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("rows: %+v", rows)
+	}
+
+	for i, w := range want {
+		r := rows[i]
+		if r.Line != w.line || r.Status != w.status || r.Expense.Amount != w.amount || r.Expense.Category != w.category {
+			t.Errorf("row %d: %+v, want %+v", i, r, w)
+		}
+	}
+
+	if rows[0].Expense.Date != "2024-04-05" || rows[0].Expense.Description != "Пятёрочка" {
+		t.Errorf("first row: %+v", rows[0].Expense)
+	}
+}
+
+func TestCategorize(t *testing.T) {
+	p := models.ImportProfile{
+		Rules:           []models.CategoryRule{{Contains: "такси", Category: "Транспорт"}},
+		DefaultCategory: "Прочее",
+	}
+
+	rules, err := categorize.New([]models.CategorizationRule{{ID: 1, Category: "Кафе", Contains: "кофе"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		description, fromColumn, want string
+	}{
+		{"Кофе и такси", "Еда", "Транспорт"},
+		{"Кофе с собой", "Еда", "Кафе"},
+		{"Обед", "Еда", "Еда"},
+		{"Обед", "", "Прочее"},
+	} {
+		got := statement.Categorize(p, rules, models.Expense{Description: tc.description}, tc.fromColumn)
+		if got != tc.want {
+			t.Errorf("Categorize(%q, %q) = %q, want %q", tc.description, tc.fromColumn, got, tc.want)
+		}
+	}
+}
+
+func TestHash(t *testing.T) {
+	a := models.Expense{Date: "2024-04-05", Amount: 100, Description: "Кофе  с собой"}
+	b := models.Expense{Date: "2024-04-05T10:00:00Z", Amount: 100, Description: "кофе с собой"}
+	if statement.Hash(a) != statement.Hash(b) {
+		t.Error("the same expense hashes differently")
+	}
+
+	b.Amount = 101
+	if statement.Hash(a) == statement.Hash(b) {
+		t.Error("different amounts hash the same")
+	}
+}
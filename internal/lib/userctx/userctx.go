@@ -0,0 +1,18 @@
+// Package userctx carries the ID of the calling user through a context.
+package userctx
+
+import "context"
+
+type ctxKey struct{}
+
+// WithUserID returns a copy of ctx that carries the user ID.
+func WithUserID(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, ctxKey{}, userID)
+}
+
+// UserID returns the user ID stored in ctx, if any.
+func UserID(ctx context.Context) (int64, bool) {
+	id, ok := ctx.Value(ctxKey{}).(int64)
+
+	return id, ok
+}
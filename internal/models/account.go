@@ -0,0 +1,35 @@
+package models
+
+// Account is where money is kept, e.g. a card, cash or savings. Its
+// amounts are in its currency.
+type Account struct {
+	ID             int64
+	UserID         int64
+	Name           string
+	Currency       string // ISO 4217 code
+	OpeningBalance int64
+	OpenedOn       string
+	Balance        int64 // the current balance, set by ListAccounts
+}
+
+// Transfer is money moved between accounts. Amount leaves From and
+// ToAmount, in the currency of To, arrives; they differ only between
+// accounts in different currencies.
+type Transfer struct {
+	ID          int64
+	UserID      int64
+	Date        string
+	From        string
+	To          string
+	Amount      int64
+	ToAmount    int64
+	Description string
+}
+
+// BalancePoint is the balance of an account at the end of a day and how
+// much it changed that day.
+type BalancePoint struct {
+	Date    string
+	Change  int64
+	Balance int64
+}
@@ -0,0 +1,14 @@
+package models
+
+// Attachment is a file attached to an expense, e.g. a receipt. Hash names
+// the content in the file store.
+type Attachment struct {
+	ID          int64
+	UserID      int64
+	ExpenseID   int64
+	Name        string
+	ContentType string
+	Size        int64 // in bytes
+	Hash        string
+	CreatedAt   string
+}
@@ -0,0 +1,14 @@
+package models
+
+// Budget is the planned spending of a category in a month, in the base
+// currency. With RollsForward it also applies to the following months until
+// another budget of the category replaces it.
+type Budget struct {
+	UserID       int64
+	CategoryID   int64
+	Category     string
+	Color        string
+	Month        string // YYYY-MM
+	Amount       int64
+	RollsForward bool
+}
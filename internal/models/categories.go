@@ -2,6 +2,9 @@ package models
 
 type Category struct {
 	ID       int64
+	UserID   int64
 	Name     string
-	ImageURL string
+	ImageURL string // stored in the icon column
+	Color    string
+	Archived bool
 }
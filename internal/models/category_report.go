@@ -1,6 +1,7 @@
 package models
 
 type CategoryReport struct {
+	CategoryID int64
 	Name       string
 	Color      string `json:"color"`
 	Amount     int64
@@ -0,0 +1,8 @@
+package models
+
+// ExchangeRate is the price of one unit of Currency in the base currency on Date.
+type ExchangeRate struct {
+	Currency string  `json:"currency"`
+	Date     string  `json:"date"` // YYYY-MM-DD
+	Rate     float64 `json:"rate"`
+}
@@ -1,11 +1,41 @@
 package models
 
 type Expense struct {
-	ID          int64  `json:"id"`
-	Description string `json:"description"`
-	Color       string `json:"color"`
-	Amount      int64  `json:"amount"`
-	Date        string `json:"date"`
-	Category    string `json:"category"`
-	CategoryID  int64  `json:"category_id"`
+	ID          int64    `json:"id"`
+	UserID      int64    `json:"user_id"`
+	Description string   `json:"description"`
+	Color       string   `json:"color"`
+	Amount      int64    `json:"amount"`
+	Currency    string   `json:"currency"` // ISO 4217 code, empty for the base currency
+	Date        string   `json:"date"`
+	Category    string   `json:"category"`
+	CategoryID  int64    `json:"category_id"`
+	DeletedAt   string   `json:"deleted_at,omitempty"`
+	Tags        []string `json:"tags,omitempty"`    // sorted
+	Account     string   `json:"account,omitempty"` // paid from, empty when unknown
+
+	// Splits are the line items of a split expense, loaded by GetExpense.
+	Splits []Split `json:"splits,omitempty"`
+	// Sharing tells who paid a shared expense and who owes what of it,
+	// loaded by GetExpense.
+	Sharing *Sharing `json:"sharing,omitempty"`
+}
+
+// ExpenseDetails are kept beside an expense and saved together with it.
+// Nil tags and splits stay as they are; sharing is replaced only when
+// Reshare is set, nil sharing making the expense the user's alone.
+type ExpenseDetails struct {
+	Tags    []string
+	Splits  []Split
+	Reshare bool
+	Sharing *Sharing
+}
+
+// Split is a line item of an expense with a category of its own. The line
+// items of a split expense add up to its amount.
+type Split struct {
+	CategoryID int64  `json:"category_id"`
+	Category   string `json:"category"`
+	Color      string `json:"color"`
+	Amount     int64  `json:"amount"`
 }
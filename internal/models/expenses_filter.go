@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+const (
+	SortByDate   = "date"
+	SortByAmount = "amount"
+)
+
+// ExpensesFilter selects, orders and pages expenses in ListExpenses.
+type ExpensesFilter struct {
+	UserID      int64
+	Categories  []string  // category names, any of them or of CategoryIDs matches
+	CategoryIDs []int64   // category IDs, any of them or of Categories matches
+	Tags        []string  // tag names, any of them matches
+	From        time.Time // inclusive, zero means unbounded
+	To          time.Time // inclusive, zero means unbounded
+	MinAmount   int64     // 0 means unbounded
+	MaxAmount   int64     // 0 means unbounded
+	Search      []string  // words that must all appear in the description
+	SortBy      string    // SortByDate (default) or SortByAmount
+	Ascending   bool
+	After       *ExpensesCursor // keyset position of the last row of the previous page
+	Limit       int             // 0 means no limit
+
+	// BaseCurrency is the currency the total of matching expenses is
+	// converted to.
+	BaseCurrency string
+}
+
+// ExpensesCursor is a keyset pagination position: the sort key and the ID
+// of the last expense already returned.
+type ExpensesCursor struct {
+	Date   string
+	Amount int64
+	ID     int64
+}
@@ -0,0 +1,26 @@
+package models
+
+// Goal is an amount to save up, by the deadline when there is one. Its
+// amounts are in its currency, that of the linked account if any. The
+// account only sets the currency: what is saved is the contributions, not
+// the balance of the account, which may hold other money too.
+type Goal struct {
+	ID        int64
+	UserID    int64
+	Name      string
+	Target    int64
+	Currency  string // ISO 4217 code
+	Deadline  string // YYYY-MM-DD, empty for none
+	Account   string // where the savings are kept, empty for none; sets the currency
+	CreatedOn string
+	Saved     int64 // the sum of the contributions, set by storage
+}
+
+// Contribution is money put towards a goal, negative when taken back.
+type Contribution struct {
+	ID     int64
+	GoalID int64
+	Date   string
+	Amount int64
+	Note   string
+}
@@ -0,0 +1,54 @@
+package models
+
+// ImportProfile maps the columns of a bank CSV export to expenses. Columns
+// are header names, matched case-insensitively, or 1-based numbers.
+type ImportProfile struct {
+	Name        string `json:"name"`
+	Delimiter   string `json:"delimiter"` // one character, "," when empty
+	SkipRows    int    `json:"skip_rows"` // lines before the header
+	NoHeader    bool   `json:"no_header"` // columns are numbers then
+	Date        string `json:"date"`
+	DateFormat  string `json:"date_format"` // Go layout, common formats are tried when empty
+	Amount      string `json:"amount"`
+	Description string `json:"description"`
+	Category    string `json:"category"` // optional
+	Currency    string `json:"currency"` // optional
+
+	// DecimalSeparator is "." or ",", "." when empty. The other one groups
+	// thousands.
+	DecimalSeparator string `json:"decimal_separator"`
+
+	// Multiplier converts amounts to ledger units, 1 when zero.
+	Multiplier float64 `json:"multiplier"`
+	// ExpensesNegative means spending has a minus sign. Rows with positive
+	// amounts are incomes or refunds then and are skipped.
+	ExpensesNegative bool `json:"expenses_negative"`
+
+	// Rules assign categories by description, the first match wins. The
+	// user's categorization rules, the category column and then
+	// DefaultCategory are used when none matches.
+	Rules           []CategoryRule `json:"rules"`
+	DefaultCategory string         `json:"default_category"`
+}
+
+// CategoryRule assigns Category to descriptions containing Contains,
+// case-insensitively.
+type CategoryRule struct {
+	Contains string `json:"contains"`
+	Category string `json:"category"`
+}
+
+const (
+	ImportNew       = "new"
+	ImportDuplicate = "duplicate"
+	ImportSkipped   = "skipped"
+	ImportInvalid   = "invalid"
+)
+
+// ImportRow is a line of an imported statement and what became of it.
+type ImportRow struct {
+	Line    int
+	Expense Expense
+	Status  string // ImportNew, ImportDuplicate, ImportSkipped or ImportInvalid
+	Reason  string // why the row is skipped or invalid
+}
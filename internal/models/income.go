@@ -0,0 +1,22 @@
+package models
+
+type Income struct {
+	ID          int64
+	UserID      int64
+	Description string
+	Amount      int64
+	Currency    string // ISO 4217 code, empty for the base currency
+	Date        string
+	Source      string
+	SourceID    int64
+	Color       string
+	Account     string // paid to, empty when unknown
+}
+
+// IncomeSource is the category of incomes, e.g. salary or interest.
+type IncomeSource struct {
+	ID     int64
+	UserID int64
+	Name   string
+	Color  string
+}
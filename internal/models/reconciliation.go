@@ -0,0 +1,34 @@
+package models
+
+// Kinds of ledger items, what changes the balance of an account.
+const (
+	ItemExpense  = "expense"
+	ItemIncome   = "income"
+	ItemTransfer = "transfer"
+)
+
+// Reconciliation is a check of an account against the balance of a bank
+// statement on a date. Balance and ClearedBalance are computed from the
+// items dated up to then, all of them and the cleared ones, and stay as they
+// were once it is finished.
+type Reconciliation struct {
+	ID               int64
+	UserID           int64
+	Account          string
+	AccountID        int64
+	Date             string
+	StatementBalance int64
+	Balance          int64
+	ClearedBalance   int64
+	FinishedAt       string // RFC 3339, empty while in progress
+}
+
+// LedgerItem is an expense, income or transfer as it changes the balance
+// of an account: Amount is negative for money leaving it.
+type LedgerItem struct {
+	Kind        string
+	ID          int64
+	Date        string
+	Description string
+	Amount      int64
+}
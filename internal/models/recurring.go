@@ -0,0 +1,20 @@
+package models
+
+// Recurring is a template of an expense repeated by a recurrence rule.
+// NextDate is the first occurrence not turned into an expense yet.
+type Recurring struct {
+	ID          int64
+	UserID      int64
+	Description string
+	Amount      int64
+	Currency    string // empty for the base currency
+	CategoryID  int64
+	Category    string
+	Color       string
+	Frequency   string // recurrence.Weekly, recurrence.Monthly or recurrence.Yearly
+	Interval    int
+	StartDate   string // YYYY-MM-DD, anchors the weekday or the day of the month
+	EndDate     string // YYYY-MM-DD, empty means never
+	NextDate    string // YYYY-MM-DD, empty once the rule has ended
+	Paused      bool
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// CategorizationRule assigns Category to new expenses without one when all
+// of its set conditions hold. Rules with a higher Priority are tried first,
+// older ones first among equals.
+type CategorizationRule struct {
+	ID         int64
+	UserID     int64
+	CategoryID int64
+	Category   string
+	Color      string
+	Priority   int
+	Contains   string         // case-insensitive part of the description
+	Pattern    string         // regular expression matching the description
+	MinAmount  int64          // 0 means unbounded
+	MaxAmount  int64          // 0 means unbounded
+	Weekdays   []time.Weekday // of the expense date, any day when empty
+}
+
+// RuleTest tells how a rule would have categorized recorded expenses.
+type RuleTest struct {
+	Matched      int       // expenses the rule matches
+	SameCategory int       // of them, already in the category of the rule
+	Expenses     []Expense // the first matched expenses, oldest first
+}
@@ -0,0 +1,42 @@
+package models
+
+// Ways to share an expense between its participants.
+const (
+	ShareEqual   = "equal"   // the amount divided evenly
+	SharePercent = "percent" // by the percent of each share
+	ShareExact   = "exact"   // by the amount of each share
+)
+
+// Sharing is how an expense is shared between people. The shares add up
+// to the amount of the expense and are in its currency; the payer may
+// have a share as well.
+type Sharing struct {
+	PaidBy string  `json:"paid_by"`
+	Method string  `json:"method"`
+	Shares []Share `json:"shares"`
+}
+
+type Share struct {
+	Person  string  `json:"person"`
+	Percent float64 `json:"percent,omitempty"` // for SharePercent
+	Amount  int64   `json:"amount"`
+}
+
+// Settlement is money paid back by one person to another.
+type Settlement struct {
+	ID       int64
+	UserID   int64
+	Date     string
+	From     string
+	To       string
+	Amount   int64
+	Currency string // ISO 4217 code, empty for the base currency
+	Note     string
+}
+
+// Debt is what From owes To in the base currency.
+type Debt struct {
+	From   string
+	To     string
+	Amount int64
+}
@@ -0,0 +1,15 @@
+package models
+
+// Tag is a label of expenses and the number of expenses it is on.
+type Tag struct {
+	Name     string
+	Expenses int
+}
+
+// TagReport is the total of the expenses with a tag. An expense with
+// several tags counts towards each of them.
+type TagReport struct {
+	Tag      string
+	Amount   int64
+	Expenses int
+}
@@ -0,0 +1,10 @@
+package models
+
+// OwnerID is the user that owns everything recorded before accounts existed.
+const OwnerID int64 = 1
+
+type User struct {
+	ID        int64
+	Name      string
+	CreatedAt string
+}
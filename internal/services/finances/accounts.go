@@ -0,0 +1,305 @@
+package finances
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+	"github.com/kochnevns/finances-backend/internal/lib/currency"
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+type AccountsManager interface {
+	CreateAccount(ctx context.Context, account models.Account) (int64, error)
+	UpdateAccount(ctx context.Context, account models.Account) error
+	Account(ctx context.Context, userID int64, name string) (models.Account, error)
+	GetAccount(ctx context.Context, userID int64, id int64) (models.Account, error)
+	ListAccounts(ctx context.Context, userID int64) ([]models.Account, error)
+	DeleteAccount(ctx context.Context, userID int64, id int64) error
+	SaveTransfer(ctx context.Context, transfer models.Transfer) (int64, error)
+	DeleteTransfer(ctx context.Context, userID int64, id int64) error
+	ListTransfers(ctx context.Context, userID int64, from, to time.Time) ([]models.Transfer, error)
+	AccountHistory(ctx context.Context, userID int64, accountID int64, from, to time.Time) ([]models.BalancePoint, error)
+}
+
+// SaveAccount creates the account when its ID is zero and otherwise
+// replaces its name, opening balance and date. An empty currency is the
+// base currency and an empty date today. The currency of an account does
+// not change, since its amounts are recorded in it.
+func (f *Finances) SaveAccount(ctx context.Context, a financesgrpc.Account) (financesgrpc.Account, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return financesgrpc.Account{}, err
+	}
+
+	account := models.Account{
+		ID:             a.ID,
+		UserID:         uid,
+		Name:           strings.TrimSpace(a.Name),
+		Currency:       f.baseCurrency,
+		OpeningBalance: a.OpeningBalance,
+		OpenedOn:       a.OpenedOn,
+	}
+
+	if account.Name == "" {
+		return financesgrpc.Account{}, fmt.Errorf("%w: name is required", financesgrpc.ErrInvalidArgument)
+	}
+
+	if a.Currency != "" {
+		var err error
+		if account.Currency, err = currency.Normalize(a.Currency); err != nil {
+			return financesgrpc.Account{}, fmt.Errorf("%w: %w", financesgrpc.ErrInvalidArgument, err)
+		}
+	}
+
+	if account.OpenedOn == "" {
+		account.OpenedOn = time.Now().Format(time.DateOnly)
+	} else if _, err := time.Parse(time.DateOnly, account.OpenedOn); err != nil {
+		return financesgrpc.Account{}, fmt.Errorf("%w: date must be in YYYY-MM-DD format", financesgrpc.ErrInvalidArgument)
+	}
+
+	if a.ID != 0 {
+		stored, err := f.accountsManager.GetAccount(ctx, uid, a.ID)
+		if err != nil {
+			f.log.Error(err.Error())
+			return financesgrpc.Account{}, err
+		}
+
+		if a.Currency != "" && account.Currency != stored.Currency {
+			return financesgrpc.Account{}, fmt.Errorf("%w: the currency of an account does not change", financesgrpc.ErrInvalidArgument)
+		}
+		account.Currency = stored.Currency
+	}
+
+	// Expenses and incomes carry the account name.
+	f.cache.Flush()
+
+	if a.ID == 0 {
+		id, err := f.accountsManager.CreateAccount(ctx, account)
+		if err != nil {
+			f.log.Error(err.Error())
+			return financesgrpc.Account{}, err
+		}
+		account.ID = id
+	} else if err := f.accountsManager.UpdateAccount(ctx, account); err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Account{}, err
+	}
+
+	saved, err := f.accountsManager.GetAccount(ctx, uid, account.ID)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Account{}, err
+	}
+
+	return toAccount(saved), nil
+}
+
+// DeleteAccount deletes the account unless expenses, incomes or transfers
+// refer to it.
+func (f *Finances) DeleteAccount(ctx context.Context, id int64) error {
+	uid, err := userID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := f.accountsManager.DeleteAccount(ctx, uid, id); err != nil {
+		f.log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (f *Finances) Accounts(ctx context.Context) ([]financesgrpc.Account, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := f.accountsManager.ListAccounts(ctx, uid)
+	if err != nil {
+		f.log.Error(err.Error())
+		return nil, err
+	}
+
+	list := make([]financesgrpc.Account, 0, len(accounts))
+	for _, a := range accounts {
+		list = append(list, toAccount(a))
+	}
+
+	return list, nil
+}
+
+// AccountHistory returns the balance of the account at the end of each day
+// within [from, to] it changed on, counting everything before from.
+func (f *Finances) AccountHistory(ctx context.Context, id int64, from, to time.Time) ([]financesgrpc.BalancePoint, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := f.accountsManager.AccountHistory(ctx, uid, id, from, to)
+	if err != nil {
+		f.log.Error(err.Error())
+		return nil, err
+	}
+
+	list := make([]financesgrpc.BalancePoint, 0, len(points))
+	for _, p := range points {
+		list = append(list, financesgrpc.BalancePoint{Date: p.Date, Change: p.Change, Balance: p.Balance})
+	}
+
+	return list, nil
+}
+
+// SaveTransfer records money moved between two accounts. Between accounts
+// in one currency ToAmount is the amount; between different currencies it
+// is required, as what arrived.
+func (f *Finances) SaveTransfer(ctx context.Context, t financesgrpc.Transfer) (financesgrpc.Transfer, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return financesgrpc.Transfer{}, err
+	}
+
+	transfer := models.Transfer{
+		UserID:      uid,
+		Date:        t.Date,
+		From:        strings.TrimSpace(t.From),
+		To:          strings.TrimSpace(t.To),
+		Amount:      t.Amount,
+		ToAmount:    t.ToAmount,
+		Description: strings.TrimSpace(t.Description),
+	}
+
+	if transfer.From == "" || transfer.To == "" || transfer.From == transfer.To {
+		return financesgrpc.Transfer{}, fmt.Errorf("%w: transfer is between two different accounts", financesgrpc.ErrInvalidArgument)
+	}
+
+	if transfer.Amount <= 0 || transfer.ToAmount < 0 {
+		return financesgrpc.Transfer{}, fmt.Errorf("%w: amount must be positive", financesgrpc.ErrInvalidArgument)
+	}
+
+	if transfer.Date == "" {
+		transfer.Date = time.Now().Format(time.DateOnly)
+	} else if _, err := time.Parse(time.DateOnly, transfer.Date); err != nil {
+		return financesgrpc.Transfer{}, fmt.Errorf("%w: date must be in YYYY-MM-DD format", financesgrpc.ErrInvalidArgument)
+	}
+
+	from, err := f.accountsManager.Account(ctx, uid, transfer.From)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Transfer{}, err
+	}
+
+	to, err := f.accountsManager.Account(ctx, uid, transfer.To)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Transfer{}, err
+	}
+
+	switch {
+	case from.Currency == to.Currency && transfer.ToAmount == 0:
+		transfer.ToAmount = transfer.Amount
+	case from.Currency == to.Currency && transfer.ToAmount != transfer.Amount:
+		return financesgrpc.Transfer{}, fmt.Errorf("%w: amounts differ between accounts in %s", financesgrpc.ErrInvalidArgument, from.Currency)
+	case transfer.ToAmount == 0:
+		return financesgrpc.Transfer{}, fmt.Errorf(
+			"%w: the amount in %s arrived from %s is required", financesgrpc.ErrInvalidArgument, to.Currency, from.Currency,
+		)
+	}
+
+	id, err := f.accountsManager.SaveTransfer(ctx, transfer)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Transfer{}, err
+	}
+	transfer.ID = id
+
+	return toTransfer(transfer), nil
+}
+
+func (f *Finances) DeleteTransfer(ctx context.Context, id int64) error {
+	uid, err := userID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := f.accountsManager.DeleteTransfer(ctx, uid, id); err != nil {
+		f.log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (f *Finances) Transfers(ctx context.Context, from, to time.Time) ([]financesgrpc.Transfer, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	transfers, err := f.accountsManager.ListTransfers(ctx, uid, from, to)
+	if err != nil {
+		f.log.Error(err.Error())
+		return nil, err
+	}
+
+	list := make([]financesgrpc.Transfer, 0, len(transfers))
+	for _, t := range transfers {
+		list = append(list, toTransfer(t))
+	}
+
+	return list, nil
+}
+
+// accountCurrency checks that an expense or income in the currency code,
+// empty for none given, can be linked to the named account and returns the
+// currency to record it in: that of the account.
+func (f *Finances) accountCurrency(ctx context.Context, uid int64, name, code string) (string, error) {
+	account, err := f.accountsManager.Account(ctx, uid, name)
+	if err != nil {
+		f.log.Error(err.Error())
+		return "", err
+	}
+
+	if code != "" && code != account.Currency {
+		return "", fmt.Errorf("%w: account %q is in %s", financesgrpc.ErrInvalidArgument, name, account.Currency)
+	}
+
+	return account.Currency, nil
+}
+
+// accountName converts a stored account name, nil for none.
+func accountName(name string) *string {
+	if name == "" {
+		return nil
+	}
+
+	return &name
+}
+
+func toAccount(a models.Account) financesgrpc.Account {
+	return financesgrpc.Account{
+		ID:             a.ID,
+		Name:           a.Name,
+		Currency:       a.Currency,
+		OpeningBalance: a.OpeningBalance,
+		OpenedOn:       a.OpenedOn,
+		Balance:        a.Balance,
+	}
+}
+
+func toTransfer(t models.Transfer) financesgrpc.Transfer {
+	return financesgrpc.Transfer{
+		ID:          t.ID,
+		Date:        t.Date,
+		From:        t.From,
+		To:          t.To,
+		Amount:      t.Amount,
+		ToAmount:    t.ToAmount,
+		Description: t.Description,
+	}
+}
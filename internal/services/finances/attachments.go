@@ -0,0 +1,273 @@
+package finances
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+	"github.com/kochnevns/finances-backend/internal/models"
+	"github.com/kochnevns/finances-backend/internal/storage/blobs"
+)
+
+// maxAttachmentName is the longest file name kept, in characters.
+const maxAttachmentName = 255
+
+type AttachmentsManager interface {
+	SaveAttachment(ctx context.Context, a models.Attachment) (int64, error)
+	GetAttachment(ctx context.Context, userID int64, id int64) (models.Attachment, error)
+	ListAttachments(ctx context.Context, userID int64, expenseID int64) ([]models.Attachment, error)
+	DeleteAttachment(ctx context.Context, userID int64, id int64) (string, error)
+	PurgeOrphanAttachments(ctx context.Context) ([]string, error)
+	BlobUsed(ctx context.Context, hash string) (bool, error)
+}
+
+// BlobStore keeps the content of attachments by its hash.
+type BlobStore interface {
+	Stage(r io.Reader, maxSize int64) (*blobs.Pending, error)
+	Open(hash string) (io.ReadCloser, error)
+	Remove(hash string) error
+}
+
+// AttachmentLimits are the largest file accepted, in bytes, and the MIME
+// types of the files accepted.
+type AttachmentLimits struct {
+	MaxSize int64
+	Types   []string
+}
+
+// heicBrands are the major brands of the "ftyp" box that start HEIC
+// images, which http.DetectContentType does not know.
+var heicBrands = []string{"heic", "heix", "hevc", "hevx", "heim", "heis"}
+
+// UploadAttachment attaches the file read from r to the live expense. The
+// type is sniffed from the content; the one declared by the client is not
+// trusted.
+func (f *Finances) UploadAttachment(
+	ctx context.Context, expenseID int64, name string, r io.Reader,
+) (financesgrpc.Attachment, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return financesgrpc.Attachment{}, err
+	}
+
+	name = attachmentName(name)
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return financesgrpc.Attachment{}, err
+	}
+	head = head[:n]
+
+	if n == 0 {
+		return financesgrpc.Attachment{}, fmt.Errorf("%w: file is empty", financesgrpc.ErrInvalidArgument)
+	}
+
+	detected := detectType(head)
+
+	if !slices.Contains(f.attachmentLimits.Types, detected) {
+		return financesgrpc.Attachment{}, fmt.Errorf("%w: files of type %q are not accepted", financesgrpc.ErrInvalidArgument, detected)
+	}
+
+	// The expense is checked again when the attachment is recorded; this
+	// only saves storing files of missing expenses.
+	if _, err := f.expensesProvider.GetExpense(ctx, uid, expenseID); err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Attachment{}, err
+	}
+
+	// The upload is read outside of the lock, so a slow client holds up no
+	// one else.
+	pending, err := f.blobs.Stage(io.MultiReader(bytes.NewReader(head), r), f.attachmentLimits.MaxSize)
+	if errors.Is(err, blobs.ErrTooLarge) {
+		return financesgrpc.Attachment{}, fmt.Errorf("%w: files must be at most %d bytes", financesgrpc.ErrInvalidArgument, f.attachmentLimits.MaxSize)
+	}
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Attachment{}, err
+	}
+	defer pending.Discard() // nolint: errcheck
+
+	a := models.Attachment{
+		UserID: uid, ExpenseID: expenseID, Name: name, ContentType: detected, Size: pending.Size, Hash: pending.Hash,
+	}
+
+	if a.ID, err = f.storeAttachment(ctx, a, pending); err != nil {
+		return financesgrpc.Attachment{}, err
+	}
+
+	saved, err := f.attachmentsManager.GetAttachment(ctx, uid, a.ID)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Attachment{}, err
+	}
+
+	return toAttachment(saved), nil
+}
+
+// storeAttachment commits the staged content and records the attachment to
+// it. The content is removed again when recording fails and no other
+// attachment has it.
+func (f *Finances) storeAttachment(ctx context.Context, a models.Attachment, pending *blobs.Pending) (int64, error) {
+	f.blobsMu.Lock()
+	defer f.blobsMu.Unlock()
+
+	if err := pending.Commit(); err != nil {
+		f.log.Error(err.Error())
+		return 0, err
+	}
+
+	id, err := f.attachmentsManager.SaveAttachment(ctx, a)
+	if err != nil {
+		f.log.Error(err.Error())
+		if used, usedErr := f.attachmentsManager.BlobUsed(ctx, a.Hash); usedErr == nil && !used {
+			f.removeBlobs([]string{a.Hash})
+		}
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// OpenAttachment returns the attachment and its content, which the caller
+// closes.
+func (f *Finances) OpenAttachment(ctx context.Context, id int64) (financesgrpc.Attachment, io.ReadCloser, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return financesgrpc.Attachment{}, nil, err
+	}
+
+	a, err := f.attachmentsManager.GetAttachment(ctx, uid, id)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Attachment{}, nil, err
+	}
+
+	content, err := f.blobs.Open(a.Hash)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Attachment{}, nil, err
+	}
+
+	return toAttachment(a), content, nil
+}
+
+// Attachments lists the attachments of the expense in the order they were
+// added, also for expenses in the trash.
+func (f *Finances) Attachments(ctx context.Context, expenseID int64) ([]financesgrpc.Attachment, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	attachments, err := f.attachmentsManager.ListAttachments(ctx, uid, expenseID)
+	if err != nil {
+		f.log.Error(err.Error())
+		return nil, err
+	}
+
+	list := make([]financesgrpc.Attachment, 0, len(attachments))
+	for _, a := range attachments {
+		list = append(list, toAttachment(a))
+	}
+
+	return list, nil
+}
+
+// DeleteAttachment removes the attachment, and its file unless another
+// attachment has the same content.
+func (f *Finances) DeleteAttachment(ctx context.Context, id int64) error {
+	uid, err := userID(ctx)
+	if err != nil {
+		return err
+	}
+
+	f.blobsMu.Lock()
+	defer f.blobsMu.Unlock()
+
+	hash, err := f.attachmentsManager.DeleteAttachment(ctx, uid, id)
+	if err != nil {
+		f.log.Error(err.Error())
+		return err
+	}
+
+	if hash != "" {
+		f.removeBlobs([]string{hash})
+	}
+
+	return nil
+}
+
+// purgeAttachments removes the attachments of expenses purged from the
+// trash together with their files.
+func (f *Finances) purgeAttachments(ctx context.Context) error {
+	f.blobsMu.Lock()
+	defer f.blobsMu.Unlock()
+
+	hashes, err := f.attachmentsManager.PurgeOrphanAttachments(ctx)
+	if err != nil {
+		f.log.Error(err.Error())
+		return err
+	}
+
+	f.removeBlobs(hashes)
+
+	return nil
+}
+
+// removeBlobs deletes files no attachment refers to anymore. A file left
+// behind wastes space only, so failures are logged and not returned.
+func (f *Finances) removeBlobs(hashes []string) {
+	for _, hash := range hashes {
+		if err := f.blobs.Remove(hash); err != nil {
+			f.log.Error(err.Error())
+		}
+	}
+}
+
+// detectType tells the MIME type of a file by its first bytes.
+func detectType(head []byte) string {
+	detected, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if detected == "application/octet-stream" && len(head) >= 12 &&
+		string(head[4:8]) == "ftyp" && slices.Contains(heicBrands, string(head[8:12])) {
+		return "image/heic"
+	}
+
+	return detected
+}
+
+// attachmentName keeps the base name of the uploaded file, shortened to
+// maxAttachmentName characters.
+func attachmentName(name string) string {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, `\`, "/")))
+	if name == "." || name == "/" || name == "" {
+		return "attachment"
+	}
+
+	for utf8.RuneCountInString(name) > maxAttachmentName {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+
+	return name
+}
+
+func toAttachment(a models.Attachment) financesgrpc.Attachment {
+	return financesgrpc.Attachment{
+		ID:          a.ID,
+		ExpenseID:   a.ExpenseID,
+		Name:        a.Name,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		CreatedAt:   a.CreatedAt,
+	}
+}
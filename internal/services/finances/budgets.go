@@ -0,0 +1,136 @@
+package finances
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+// monthLayout is the format of budget months.
+const monthLayout = "2006-01"
+
+type BudgetsManager interface {
+	SetBudget(ctx context.Context, budget models.Budget) error
+	DeleteBudget(ctx context.Context, userID int64, categoryID int64, month string) error
+	EffectiveBudgets(ctx context.Context, userID int64, month string) ([]models.Budget, error)
+}
+
+// SetBudget creates or replaces the budget of the category for the month.
+// With rollsForward it also applies to the following months until another
+// budget of the category is set.
+func (f *Finances) SetBudget(ctx context.Context, categoryID int64, month string, amount int64, rollsForward bool) error {
+	uid, err := userID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := time.Parse(monthLayout, month); err != nil {
+		return fmt.Errorf("%w: month must be in YYYY-MM format", financesgrpc.ErrInvalidArgument)
+	}
+
+	if amount <= 0 {
+		return fmt.Errorf("%w: budget amount must be positive", financesgrpc.ErrInvalidArgument)
+	}
+
+	f.cache.Flush()
+
+	err = f.budgetsManager.SetBudget(ctx, models.Budget{
+		UserID:       uid,
+		CategoryID:   categoryID,
+		Month:        month,
+		Amount:       amount,
+		RollsForward: rollsForward,
+	})
+	if err != nil {
+		f.log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// DeleteBudget removes the budget set for the category in the month. An
+// earlier budget that rolls forward applies to the month again.
+func (f *Finances) DeleteBudget(ctx context.Context, categoryID int64, month string) error {
+	uid, err := userID(ctx)
+	if err != nil {
+		return err
+	}
+
+	f.cache.Flush()
+
+	if err := f.budgetsManager.DeleteBudget(ctx, uid, categoryID, month); err != nil {
+		f.log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Budgets returns the budget of each category that applies to the month.
+func (f *Finances) Budgets(ctx context.Context, month string) ([]financesgrpc.Budget, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := time.Parse(monthLayout, month); err != nil {
+		return nil, fmt.Errorf("%w: month must be in YYYY-MM format", financesgrpc.ErrInvalidArgument)
+	}
+
+	budgets, err := f.budgetsManager.EffectiveBudgets(ctx, uid, month)
+	if err != nil {
+		f.log.Error(err.Error())
+		return nil, err
+	}
+
+	list := make([]financesgrpc.Budget, 0, len(budgets))
+	for _, b := range budgets {
+		list = append(list, financesgrpc.Budget{
+			CategoryID:   b.CategoryID,
+			Category:     b.Category,
+			Color:        b.Color,
+			Month:        b.Month,
+			Amount:       b.Amount,
+			RollsForward: b.RollsForward,
+		})
+	}
+
+	return list, nil
+}
+
+// budgetMonth returns the month of a report window when it covers exactly
+// one calendar month, the only window budgets are planned for.
+func budgetMonth(from, to time.Time) (string, bool) {
+	if from.Day() != 1 || !to.Equal(from.AddDate(0, 1, -1)) {
+		return "", false
+	}
+
+	return from.Format(monthLayout), true
+}
+
+// withBudgets sets the budget figures of the report categories. Categories
+// with a budget and nothing spent yet are added to the report.
+func withBudgets(report []financesgrpc.CategoryReport, ids []int64, budgets []models.Budget) []financesgrpc.CategoryReport {
+	for _, b := range budgets {
+		i := 0
+		for i < len(ids) && ids[i] != b.CategoryID {
+			i++
+		}
+
+		if i == len(ids) {
+			report = append(report, financesgrpc.CategoryReport{Category: b.Category, Color: b.Color})
+			ids = append(ids, b.CategoryID)
+		}
+
+		c := &report[i]
+		c.Budget = b.Amount
+		c.Remaining = b.Amount - c.Amount
+		c.PercentUsed = c.Amount * 100 / b.Amount
+	}
+
+	return report
+}
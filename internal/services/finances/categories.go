@@ -0,0 +1,131 @@
+package finances
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+type CategoriesManager interface {
+	GetCategoryById(ctx context.Context, userID int64, id int64) (*models.Category, error)
+	CreateCategory(ctx context.Context, category models.Category) (int64, error)
+	UpdateCategory(ctx context.Context, category models.Category) error
+	SetCategoryArchived(ctx context.Context, userID int64, id int64, archived bool, at time.Time) error
+	MergeCategories(ctx context.Context, userID int64, fromID, toID int64) error
+}
+
+func (f *Finances) CreateCategory(ctx context.Context, name, icon, color string) (financesgrpc.Category, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return financesgrpc.Category{}, err
+	}
+
+	category := models.Category{
+		UserID:   uid,
+		Name:     name,
+		ImageURL: icon,
+		Color:    color,
+	}
+
+	id, err := f.categoriesManager.CreateCategory(ctx, category)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Category{}, err
+	}
+
+	category.ID = id
+
+	return toCategory(category), nil
+}
+
+func (f *Finances) EditCategory(ctx context.Context, id int64, name, icon, color string) (financesgrpc.Category, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return financesgrpc.Category{}, err
+	}
+
+	category, err := f.categoriesManager.GetCategoryById(ctx, uid, id)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Category{}, err
+	}
+
+	if name != "" {
+		category.Name = name
+	}
+	if icon != "" {
+		category.ImageURL = icon
+	}
+	if color != "" {
+		category.Color = color
+	}
+
+	f.cache.Flush()
+	if name != "" {
+		f.suggester.forget(category.UserID)
+	}
+
+	if err := f.categoriesManager.UpdateCategory(ctx, *category); err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Category{}, err
+	}
+
+	return toCategory(*category), nil
+}
+
+func (f *Finances) ArchiveCategory(ctx context.Context, id int64) error {
+	return f.setCategoryArchived(ctx, id, true)
+}
+
+func (f *Finances) UnarchiveCategory(ctx context.Context, id int64) error {
+	return f.setCategoryArchived(ctx, id, false)
+}
+
+func (f *Finances) setCategoryArchived(ctx context.Context, id int64, archived bool) error {
+	uid, err := userID(ctx)
+	if err != nil {
+		return err
+	}
+
+	f.cache.Flush()
+
+	if err := f.categoriesManager.SetCategoryArchived(ctx, uid, id, archived, time.Now()); err != nil {
+		f.log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (f *Finances) MergeCategories(ctx context.Context, fromID, toID int64) error {
+	uid, err := userID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if fromID == toID {
+		return fmt.Errorf("%w: a category cannot be merged into itself", financesgrpc.ErrInvalidArgument)
+	}
+
+	f.cache.Flush()
+	f.suggester.forget(uid)
+
+	if err := f.categoriesManager.MergeCategories(ctx, uid, fromID, toID); err != nil {
+		f.log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func toCategory(c models.Category) financesgrpc.Category {
+	return financesgrpc.Category{
+		ID:    c.ID,
+		Name:  c.Name,
+		Icon:  c.ImageURL,
+		Color: c.Color,
+	}
+}
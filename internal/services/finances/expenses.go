@@ -0,0 +1,288 @@
+package finances
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+	"github.com/kochnevns/finances-backend/internal/lib/currency"
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+// SaveExpense creates the expense when its ID is zero. Otherwise it applies
+// the non-zero fields to the stored expense, like ExpenseEdit. An empty
+// currency means the base currency for new expenses; an empty category is
+// the one of the largest line item or else assigned by the categorization
+// rules. Line items must add up to the amount, so the amount of a split
+// expense changes only together with its line items. Shared expenses are
+// shared anew when their amount changes. Expenses paid from an account are
+// in its currency, which new ones take when none is given.
+func (f *Finances) SaveExpense(ctx context.Context, e financesgrpc.Expense) (financesgrpc.Expense, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return financesgrpc.Expense{}, err
+	}
+
+	var tags []string
+	if e.Tags != nil {
+		var err error
+		if tags, err = normalizeTags(e.Tags); err != nil {
+			return financesgrpc.Expense{}, err
+		}
+	}
+
+	var code string
+	if e.Currency != "" {
+		var err error
+		if code, err = currency.Normalize(e.Currency); err != nil {
+			return financesgrpc.Expense{}, fmt.Errorf("%w: %w", financesgrpc.ErrInvalidArgument, err)
+		}
+	}
+
+	expense := models.Expense{ID: e.ID, UserID: uid}
+	var before *models.Expense
+	if e.ID != 0 {
+		var err error
+		if expense, err = f.expensesProvider.GetExpense(ctx, uid, e.ID); err != nil {
+			f.log.Error(err.Error())
+			return financesgrpc.Expense{}, err
+		}
+		stored := expense
+		before = &stored
+	}
+
+	if e.Description != "" {
+		expense.Description = e.Description
+	}
+	if e.Amount != 0 {
+		expense.Amount = e.Amount
+	}
+	if e.Date != "" {
+		expense.Date = e.Date
+	}
+	if e.Category != "" {
+		expense.Category = e.Category
+	}
+	if code != "" {
+		expense.Currency = code
+	}
+	if e.Account != nil {
+		expense.Account = strings.TrimSpace(*e.Account)
+	}
+
+	if expense.Account != "" {
+		given := expense.Currency
+		if given == "" && e.ID != 0 {
+			given = f.baseCurrency
+		}
+
+		var err error
+		if expense.Currency, err = f.accountCurrency(ctx, uid, expense.Account, given); err != nil {
+			return financesgrpc.Expense{}, err
+		}
+	}
+
+	var splits []models.Split
+	if e.Splits != nil {
+		var err error
+		if splits, err = fromSplits(e.Splits, expense.Amount); err != nil {
+			return financesgrpc.Expense{}, err
+		}
+	} else if before != nil && len(before.Splits) > 0 && expense.Amount != before.Amount {
+		return financesgrpc.Expense{}, errSplitAmount
+	}
+
+	var sharing *models.Sharing
+	switch {
+	case e.Sharing != nil:
+		var err error
+		if sharing, err = share(expense.Amount, *e.Sharing); err != nil {
+			return financesgrpc.Expense{}, err
+		}
+	case before != nil && before.Sharing != nil && expense.Amount != before.Amount:
+		var err error
+		if sharing, err = reshare(expense.Amount, *before.Sharing); err != nil {
+			return financesgrpc.Expense{}, err
+		}
+	}
+
+	if e.ID == 0 && expense.Category == "" && len(splits) > 0 {
+		expense.Category = largestSplit(splits)
+	}
+
+	if e.ID == 0 && expense.Category == "" {
+		if err := f.assignCategory(ctx, &expense); err != nil {
+			return financesgrpc.Expense{}, err
+		}
+	}
+
+	details := models.ExpenseDetails{
+		Tags:    tags,
+		Splits:  splits,
+		Reshare: e.Sharing != nil || sharing != nil,
+		Sharing: sharing,
+	}
+
+	f.cache.Flush()
+
+	if e.ID == 0 {
+		id, err := f.expenseSaver.SaveExpense(ctx, expense, details)
+		if err != nil {
+			f.log.Error(err.Error())
+			return financesgrpc.Expense{}, err
+		}
+		expense.ID = id
+	} else if err := f.expenseUpdater.UpdateExpense(ctx, expense, details); err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Expense{}, err
+	}
+
+	saved, err := f.expensesProvider.GetExpense(ctx, uid, expense.ID)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Expense{}, err
+	}
+	f.suggester.learn(uid, before, &saved)
+
+	return f.toExpense(saved), nil
+}
+
+// toExpense converts a stored expense, spelling out the base currency of
+// expenses recorded without one.
+func (f *Finances) toExpense(e models.Expense) financesgrpc.Expense {
+	code := e.Currency
+	if code == "" {
+		code = f.baseCurrency
+	}
+
+	return financesgrpc.Expense{
+		ID:          e.ID,
+		Description: e.Description,
+		Amount:      e.Amount,
+		Currency:    code,
+		Date:        e.Date,
+		Category:    e.Category,
+		Color:       e.Color,
+		DeletedAt:   e.DeletedAt,
+		Tags:        e.Tags,
+		Splits:      toSplits(e.Splits),
+		Sharing:     toSharing(e.Sharing),
+		Account:     accountName(e.Account),
+	}
+}
+
+func (f *Finances) SearchExpenses(
+	ctx context.Context, query financesgrpc.ExpensesQuery,
+) ([]financesgrpc.Expense, int64, string, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	tags, err := normalizeTags(query.Tags)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	filter := models.ExpensesFilter{
+		UserID:      uid,
+		Categories:  query.Categories,
+		CategoryIDs: query.CategoryIDs,
+		Tags:        tags,
+		From:        query.From,
+		To:          query.To,
+		MinAmount:   query.MinAmount,
+		MaxAmount:   query.MaxAmount,
+		Search:      strings.Fields(query.Search),
+		SortBy:      query.SortBy,
+		Ascending:   query.Ascending,
+
+		BaseCurrency: f.baseCurrency,
+	}
+
+	switch filter.SortBy {
+	case "":
+		filter.SortBy = models.SortByDate
+	case models.SortByDate, models.SortByAmount:
+	default:
+		return nil, 0, "", fmt.Errorf("%w: unknown sort field %q", financesgrpc.ErrInvalidArgument, query.SortBy)
+	}
+
+	if query.Cursor != "" {
+		cursor, err := decodeCursor(query.Cursor, filter.SortBy)
+		if err != nil {
+			return nil, 0, "", err
+		}
+		filter.After = cursor
+	}
+
+	if query.Limit > 0 {
+		// One extra row tells whether there is a next page.
+		filter.Limit = query.Limit + 1
+	}
+
+	l, total, err := f.expensesProvider.ListExpenses(ctx, filter)
+	if err != nil {
+		f.log.Error(err.Error())
+		return nil, 0, "", err
+	}
+
+	var next string
+	if query.Limit > 0 && len(l) > query.Limit {
+		l = l[:query.Limit]
+		next = encodeCursor(l[len(l)-1], filter.SortBy)
+	}
+
+	list := make([]financesgrpc.Expense, 0, len(l))
+	for _, e := range l {
+		list = append(list, f.toExpense(e))
+	}
+
+	return list, int64(total), next, nil
+}
+
+// encodeCursor packs the sort field, its value and the ID of the last
+// expense of a page into an opaque string.
+func encodeCursor(last models.Expense, sortBy string) string {
+	key := last.Date
+	if sortBy == models.SortByAmount {
+		key = strconv.FormatInt(last.Amount, 10)
+	}
+
+	raw := fmt.Sprintf("%s|%s|%d", sortBy, key, last.ID)
+
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string, sortBy string) (*models.ExpensesCursor, error) {
+	invalid := fmt.Errorf("%w: malformed cursor", financesgrpc.ErrInvalidArgument)
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, invalid
+	}
+
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 || parts[0] != sortBy {
+		return nil, invalid
+	}
+
+	id, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, invalid
+	}
+
+	c := &models.ExpensesCursor{ID: id}
+	if sortBy == models.SortByAmount {
+		if c.Amount, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+			return nil, invalid
+		}
+	} else {
+		c.Date = parts[1]
+	}
+
+	return c, nil
+}
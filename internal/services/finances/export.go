@@ -0,0 +1,50 @@
+package finances
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+	"github.com/kochnevns/finances-backend/internal/lib/export"
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+// ExportExpenses writes the expenses within the inclusive date range to w in
+// the format, oldest first. Only the named categories are exported when any
+// are given. Expenses go with their tags and accounts but without their line
+// items, sharing or attachments. Nothing is written when the format is unknown.
+func (f *Finances) ExportExpenses(ctx context.Context, w io.Writer, format string, from, to time.Time, categories []string) error {
+	uid, err := userID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := export.ContentTypes[format]; !ok {
+		return fmt.Errorf("%w: unknown export format %q", financesgrpc.ErrInvalidArgument, format)
+	}
+
+	if !from.IsZero() && !to.IsZero() && from.After(to) {
+		return fmt.Errorf("%w: from is after to", financesgrpc.ErrInvalidArgument)
+	}
+
+	ew, err := export.New(w, format)
+	if err != nil {
+		return err
+	}
+
+	filter := models.ExpensesFilter{
+		UserID:     uid,
+		Categories: categories,
+		From:       from,
+		To:         to,
+	}
+
+	if err := f.expensesProvider.EachExpense(ctx, filter, ew.Write); err != nil {
+		f.log.Error(err.Error())
+		return err
+	}
+
+	return ew.Close()
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
 	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
 	"github.com/kochnevns/finances-backend/internal/imcache"
+	"github.com/kochnevns/finances-backend/internal/lib/userctx"
 	"github.com/kochnevns/finances-backend/internal/models"
 )
 
@@ -15,52 +17,122 @@ type Finances struct {
 	log                      *slog.Logger
 	expenseSaver             ExpensesSaver
 	expenseUpdater           ExpenseUpdater
+	expenseDeleter           ExpenseDeleter
 	expensesProvider         ExpensesProvider
 	categoriesReportProvider CategoriesReportProvider
 	categoriesProvider       CategoriesProvider
+	categoriesManager        CategoriesManager
+	ratesManager             RatesManager
+	budgetsManager           BudgetsManager
+	recurringManager         RecurringManager
+	incomesManager           IncomesManager
+	importManager            ImportManager
+	rulesManager             RulesManager
+	tagsManager              TagsManager
+	sharingManager           SharingManager
+	attachmentsManager       AttachmentsManager
+	accountsManager          AccountsManager
+	reconciliationsManager   ReconciliationsManager
+	goalsManager             GoalsManager
+	suggester                *suggester
 	cache                    *imcache.IMCache
+	trashRetention           time.Duration
+	blobs                    BlobStore
+	blobsMu                  sync.Mutex // orders storing and removing files with recording attachments
+	attachmentLimits         AttachmentLimits
+	baseCurrency             string
 }
 
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLSaver
 type ExpensesSaver interface {
-	SaveExpense(ctx context.Context, expense models.Expense) error
+	SaveExpense(ctx context.Context, expense models.Expense, details models.ExpenseDetails) (int64, error)
 }
 
 type ExpenseUpdater interface {
-	UpdateExpense(ctx context.Context, expense models.Expense) error
+	UpdateExpense(ctx context.Context, expense models.Expense, details models.ExpenseDetails) error
 }
 
 type ExpensesProvider interface {
-	ListExpenses(ctx context.Context, category string, month, year int64) ([]models.Expense, int, error)
+	GetExpense(ctx context.Context, userID int64, id int64) (models.Expense, error)
+	ListExpenses(ctx context.Context, filter models.ExpensesFilter) ([]models.Expense, int, error)
+	EachExpense(ctx context.Context, filter models.ExpensesFilter, fn func(models.Expense) error) error
 }
 
 type CategoriesProvider interface {
-	ListCategories(ctx context.Context) ([]models.Category, error)
+	ListCategories(ctx context.Context, userID int64) ([]models.Category, error)
 }
 
 type CategoriesReportProvider interface {
-	ListCategoriesReport(ctx context.Context, filter string, month int, year int) ([]models.CategoryReport, error)
-	Total(filter string, month int, year int) (int64, error)
-	MedianAndMiddle(ctx context.Context, month int, year int) (int64, int64, error)
+	ListCategoriesReport(ctx context.Context, userID int64, from, to time.Time, base string) ([]models.CategoryReport, error)
+	Total(ctx context.Context, userID int64, from, to time.Time, base string) (int64, error)
+	MedianAndMiddle(ctx context.Context, userID int64, from, to time.Time, base string) (int64, int64, error)
+}
+
+// userID returns the ID of the calling user. Calls that carry no identity
+// are refused; jobs acting for every user pass the IDs explicitly.
+func userID(ctx context.Context) (int64, error) {
+	id, ok := userctx.UserID(ctx)
+	if !ok {
+		return 0, financesgrpc.ErrUnauthenticated
+	}
+
+	return id, nil
 }
 
 func New(
 	log *slog.Logger,
 	expenseSaver ExpensesSaver, // TODO: use mock
 	expensesUpdater ExpenseUpdater,
+	expenseDeleter ExpenseDeleter,
 	expensesProvider ExpensesProvider, // TODO: use mock
 	categoriesReportProvider CategoriesReportProvider,
 	categoriesProvider CategoriesProvider, // TODO: use mock
+	categoriesManager CategoriesManager,
+	ratesManager RatesManager,
+	budgetsManager BudgetsManager,
+	recurringManager RecurringManager,
+	incomesManager IncomesManager,
+	importManager ImportManager,
+	rulesManager RulesManager,
+	tagsManager TagsManager,
+	sharingManager SharingManager,
+	attachmentsManager AttachmentsManager,
+	accountsManager AccountsManager,
+	reconciliationsManager ReconciliationsManager,
+	goalsManager GoalsManager,
 	cache *imcache.IMCache,
+	trashRetention time.Duration,
+	blobs BlobStore,
+	attachmentLimits AttachmentLimits,
+	baseCurrency string, // reports and totals are converted to it
 ) *Finances {
 	return &Finances{
 		expenseSaver:             expenseSaver,
 		expenseUpdater:           expensesUpdater,
+		expenseDeleter:           expenseDeleter,
 		expensesProvider:         expensesProvider,
 		categoriesReportProvider: categoriesReportProvider,
 		categoriesProvider:       categoriesProvider,
+		categoriesManager:        categoriesManager,
+		ratesManager:             ratesManager,
+		budgetsManager:           budgetsManager,
+		recurringManager:         recurringManager,
+		incomesManager:           incomesManager,
+		importManager:            importManager,
+		rulesManager:             rulesManager,
+		tagsManager:              tagsManager,
+		sharingManager:           sharingManager,
+		attachmentsManager:       attachmentsManager,
+		accountsManager:          accountsManager,
+		reconciliationsManager:   reconciliationsManager,
+		goalsManager:             goalsManager,
+		suggester:                newSuggester(),
 		log:                      log,
 		cache:                    cache,
+		trashRetention:           trashRetention,
+		blobs:                    blobs,
+		attachmentLimits:         attachmentLimits,
+		baseCurrency:             baseCurrency,
 	}
 }
 
@@ -72,38 +144,96 @@ func (f *Finances) Expense(
 	Category string, // "food", "groceries", "transport", "misc"
 	Id int64,
 ) (err error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return err
+	}
+
 	expense := models.Expense{
 		ID:          Id,
+		UserID:      uid,
 		Description: Description,
 		Amount:      Amount,
 		Date:        Date,
 		Category:    Category,
 	}
 
+	if Id == 0 && Category == "" {
+		if err := f.assignCategory(ctx, &expense); err != nil {
+			return err
+		}
+	}
+
 	f.cache.Flush()
 
 	if Id == 0 {
-		err := f.expenseSaver.SaveExpense(ctx, expense)
+		_, err := f.expenseSaver.SaveExpense(ctx, expense, models.ExpenseDetails{})
 		if err != nil {
 			f.log.Error(err.Error())
 			return err
 		}
+		f.suggester.learn(expense.UserID, nil, &expense)
 	} else {
-		if err := f.expenseUpdater.UpdateExpense(ctx, expense); err != nil {
+		// The gRPC contract has no currency, keep the recorded one.
+		stored, err := f.expensesProvider.GetExpense(ctx, expense.UserID, Id)
+		if err != nil {
+			f.log.Error(err.Error())
+			return err
+		}
+		expense.Currency = stored.Currency
+		expense.Account = stored.Account
+
+		if len(stored.Splits) > 0 && expense.Amount != stored.Amount {
+			return errSplitAmount
+		}
+
+		var sharing *models.Sharing
+		if stored.Sharing != nil && expense.Amount != stored.Amount {
+			if sharing, err = reshare(expense.Amount, *stored.Sharing); err != nil {
+				return err
+			}
+		}
+
+		details := models.ExpenseDetails{Reshare: sharing != nil, Sharing: sharing}
+		if err := f.expenseUpdater.UpdateExpense(ctx, expense, details); err != nil {
 			f.log.Error(err.Error())
 			return err
 		}
+		f.suggester.learn(expense.UserID, &stored, &expense)
 	}
 
 	return nil
 }
 
+// ExpenseEdit applies a partial update to the expense with the given ID.
+// Zero values (empty strings, zero amount) mean "leave the field as is".
+func (f *Finances) ExpenseEdit(
+	ctx context.Context,
+	ID int64,
+	Description string,
+	Amount int64, // in cents
+	Date string, // YYYY-MM-DD
+	Category string,
+) (financesgrpc.Expense, error) {
+	return f.SaveExpense(ctx, financesgrpc.Expense{
+		ID:          ID,
+		Description: Description,
+		Amount:      Amount,
+		Date:        Date,
+		Category:    Category,
+	})
+}
+
 func (f *Finances) ExpensesList(
 	ctx context.Context, category string, month int64, year int64,
 ) (list []financesgrpc.Expense, total int64, err error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
 
-	cacheKeyList := fmt.Sprintf("list;%s;%d;%d", category, month, year)
-	cacheKeyTotal := fmt.Sprintf("total;%s;%d;%d", category, month, year)
+	cacheKeyList := fmt.Sprintf("list;%d;%s;%d;%d", uid, category, month, year)
+	cacheKeyTotal := fmt.Sprintf("total;%d;%s;%d;%d", uid, category, month, year)
 
 	x, foundList := f.cache.Get(cacheKeyList)
 	tot, foundTotal := f.cache.Get(cacheKeyTotal)
@@ -118,7 +248,19 @@ func (f *Finances) ExpensesList(
 
 	f.log.Info("Cache miss")
 
-	l, t, err := f.expensesProvider.ListExpenses(ctx, category, month, year)
+	from, to := listPeriod(month, year, time.Now())
+
+	filter := models.ExpensesFilter{
+		UserID:       uid,
+		From:         from,
+		To:           to,
+		BaseCurrency: f.baseCurrency,
+	}
+	if category != "" {
+		filter.Categories = []string{category}
+	}
+
+	l, t, err := f.expensesProvider.ListExpenses(ctx, filter)
 
 	if err != nil {
 		f.log.Error(err.Error())
@@ -126,14 +268,7 @@ func (f *Finances) ExpensesList(
 	}
 
 	for _, e := range l {
-		list = append(list, financesgrpc.Expense{
-			ID:          int64(e.ID),
-			Description: e.Description,
-			Amount:      e.Amount,
-			Date:        e.Date,
-			Category:    e.Category,
-			Color:       e.Color,
-		})
+		list = append(list, f.toExpense(e))
 	}
 
 	f.cache.Set(cacheKeyList, &list, time.Hour)
@@ -142,8 +277,33 @@ func (f *Finances) ExpensesList(
 	return list, int64(t), nil
 }
 
+// listPeriod returns the inclusive window of ExpensesList. Zero month and
+// year mean the current month, zero month alone means the whole year.
+func listPeriod(month int64, year int64, now time.Time) (time.Time, time.Time) {
+	if year == 0 {
+		year = int64(now.Year())
+		if month == 0 {
+			month = int64(now.Month())
+		}
+	}
+
+	if month == 0 {
+		return time.Date(int(year), time.January, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(int(year), time.December, 31, 0, 0, 0, 0, time.UTC)
+	}
+
+	from := time.Date(int(year), time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+
+	return from, from.AddDate(0, 1, -1)
+}
+
 func (f *Finances) CategoriesList(ctx context.Context) ([]financesgrpc.Category, error) {
-	categories, err := f.categoriesProvider.ListCategories(ctx)
+	uid, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := f.categoriesProvider.ListCategories(ctx, uid)
 	if err != nil {
 		f.log.Error(err.Error())
 	}
@@ -151,8 +311,10 @@ func (f *Finances) CategoriesList(ctx context.Context) ([]financesgrpc.Category,
 	var list []financesgrpc.Category
 	for _, c := range categories {
 		list = append(list, financesgrpc.Category{
-			Name: c.Name,
-			ID:   c.ID,
+			Name:  c.Name,
+			ID:    c.ID,
+			Icon:  c.ImageURL,
+			Color: c.Color,
 		})
 	}
 
@@ -160,23 +322,43 @@ func (f *Finances) CategoriesList(ctx context.Context) ([]financesgrpc.Category,
 
 }
 
-func (f *Finances) CreateCategory(ctx context.Context, _ string) (string, error) { return "", nil }
-
+// Report builds a report for the window selected by rf: the calendar month,
+// the calendar year, or the 7 days ending today (or on the last day of the
+// month when month and year point to the past).
 func (f *Finances) Report(ctx context.Context, rf financesgrpc.ReportFilter, month int, year int) (int64, int64, int64, []financesgrpc.CategoryReport, error) {
-	cts, err := f.categoriesReportProvider.ListCategoriesReport(ctx, rf.String(), month, year)
+	from, to, err := reportPeriod(rf, month, year, time.Now())
+	if err != nil {
+		f.log.Error(err.Error())
+		return 0, 0, 0, nil, err
+	}
+
+	return f.RangeReport(ctx, from, to)
+}
+
+// RangeReport builds a report for expenses dated within [from, to]. Amounts
+// are converted to the base currency at the rate on the expense date. When
+// the window is a calendar month, categories carry their budget for it.
+func (f *Finances) RangeReport(ctx context.Context, from, to time.Time) (int64, int64, int64, []financesgrpc.CategoryReport, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return 0, 0, 0, nil, err
+	}
+
+	cts, err := f.categoriesReportProvider.ListCategoriesReport(ctx, uid, from, to, f.baseCurrency)
 
 	if err != nil {
 		f.log.Error(err.Error())
 		return 0, 0, 0, nil, err
 	}
 
-	total, err := f.categoriesReportProvider.Total(rf.String(), month, year)
+	total, err := f.categoriesReportProvider.Total(ctx, uid, from, to, f.baseCurrency)
 	if err != nil {
 		f.log.Error(err.Error())
 		return 0, 0, 0, nil, err
 	}
 
 	var cts2 []financesgrpc.CategoryReport
+	var ids []int64
 
 	for _, ct := range cts {
 		cts2 = append(cts2, financesgrpc.CategoryReport{
@@ -184,9 +366,20 @@ func (f *Finances) Report(ctx context.Context, rf financesgrpc.ReportFilter, mon
 			Amount:   ct.Amount,
 			Color:    ct.Color,
 		})
+		ids = append(ids, ct.CategoryID)
+	}
+
+	if month, ok := budgetMonth(from, to); ok {
+		budgets, err := f.budgetsManager.EffectiveBudgets(ctx, uid, month)
+		if err != nil {
+			f.log.Error(err.Error())
+			return 0, 0, 0, nil, err
+		}
+
+		cts2 = withBudgets(cts2, ids, budgets)
 	}
 
-	middle, median, err := f.categoriesReportProvider.MedianAndMiddle(ctx, month, year)
+	middle, median, err := f.categoriesReportProvider.MedianAndMiddle(ctx, uid, from, to, f.baseCurrency)
 
 	if err != nil {
 		return 0, 0, 0, nil, err
@@ -194,3 +387,27 @@ func (f *Finances) Report(ctx context.Context, rf financesgrpc.ReportFilter, mon
 
 	return total, middle, median, cts2, nil
 }
+
+// reportPeriod returns the inclusive date window of a report. A week is the
+// last seven days of the month, or up to today while the month is running.
+func reportPeriod(rf financesgrpc.ReportFilter, month int, year int, now time.Time) (time.Time, time.Time, error) {
+	firstDay := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := firstDay.AddDate(0, 1, -1)
+
+	switch rf {
+	case financesgrpc.Month:
+		return firstDay, lastDay, nil
+	case financesgrpc.Year:
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC), nil
+	case financesgrpc.Week:
+		end := lastDay
+		if today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC); today.Before(end) {
+			end = today
+		}
+
+		return end.AddDate(0, 0, -6), end, nil
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: unknown report filter %q", financesgrpc.ErrInvalidArgument, rf)
+	}
+}
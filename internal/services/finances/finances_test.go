@@ -0,0 +1,1966 @@
+package finances_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/kochnevns/finances-backend/db"
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+	"github.com/kochnevns/finances-backend/internal/imcache"
+	"github.com/kochnevns/finances-backend/internal/lib/userctx"
+	"github.com/kochnevns/finances-backend/internal/models"
+	"github.com/kochnevns/finances-backend/internal/services/finances"
+	"github.com/kochnevns/finances-backend/internal/storage"
+	"github.com/kochnevns/finances-backend/internal/storage/blobs"
+	"github.com/kochnevns/finances-backend/internal/storage/sqlite"
+)
+
+var testAttachmentLimits = finances.AttachmentLimits{MaxSize: 1024, Types: []string{"image/png", "image/heic", "application/pdf"}}
+
+// newTestFinances creates the service on top of a freshly migrated database
+// with the given fixtures applied.
+func newTestFinances(t *testing.T, fixtures string) *finances.Finances {
+	t.Helper()
+
+	return newTestFinancesIn(t, t.TempDir(), fixtures)
+}
+
+// newTestFinancesIn is newTestFinances keeping the database and the
+// attachments in dir, for tests that look at them directly.
+func newTestFinancesIn(t *testing.T, dir string, fixtures string) *finances.Finances {
+	t.Helper()
+
+	path := filepath.Join(dir, "expenses.db.sqlite")
+
+	storage, err := sqlite.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = storage.Stop() })
+
+	if _, err := storage.Migrate(context.Background(), db.Migrations(), db.Seeds()); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Exec(fixtures); err != nil {
+		t.Fatal(err)
+	}
+
+	blobStore, err := blobs.New(filepath.Join(dir, "attachments"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	return finances.New(log, storage, storage, storage, storage, storage, storage, storage, storage, storage, storage, storage, storage, storage, storage, storage, storage, storage, storage, storage,
+		imcache.NewIMCache(), time.Hour, blobStore, testAttachmentLimits, "RUB")
+}
+
+const hostileFixtures = `
+	DELETE FROM Categories;
+	INSERT INTO Categories (id, name) VALUES (1, 'Моти'), (2, 'Моти''');
+	INSERT INTO Expenses (date, description, amount, category_id) VALUES
+		('2024-04-01', 'корм', 100, 1),
+		('2024-04-02', 'кавычка', 7, 2);
+`
+
+func ids(list []financesgrpc.Expense) []int64 {
+	var res []int64
+	for _, e := range list {
+		res = append(res, e.ID)
+	}
+	slices.Sort(res)
+
+	return res
+}
+
+const editFixtures = `
+	DELETE FROM Categories;
+	INSERT INTO Categories (id, name) VALUES (1, 'Моти'), (2, 'Моти''');
+	INSERT INTO Expenses (id, date, description, amount, category_id) VALUES (1, '2024-04-03', 'обед', 300, 1);
+`
+
+func TestExpenseEdit_Unknown(t *testing.T) {
+	f := newTestFinances(t, editFixtures)
+
+	if _, err := f.ExpenseEdit(userctx.WithUserID(context.Background(), models.OwnerID), 2, "ужин", 0, "", ""); !errors.Is(err, storage.ErrExpenseNotFound) {
+		t.Errorf("unknown expense: err = %v", err)
+	}
+	if _, err := f.ExpenseEdit(userctx.WithUserID(context.Background(), models.OwnerID), 1, "", 0, "", "нет такой"); !errors.Is(err, storage.ErrCategoryNotFound) {
+		t.Errorf("unknown category: err = %v", err)
+	}
+}
+
+func TestExpensesList_HostileCategoryNames(t *testing.T) {
+	f := newTestFinances(t, hostileFixtures)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+
+	tests := []struct {
+		name      string
+		category  string
+		wantIDs   []int64
+		wantTotal int64
+	}{
+		{name: "quote is part of the name", category: "Моти'", wantIDs: []int64{2}, wantTotal: 7},
+		{name: "tautology", category: "' OR '1'='1"},
+		{name: "comment out the rest", category: "Моти' --"},
+		{name: "stacked statement", category: "'; DROP TABLE Expenses; --"},
+		{name: "union", category: "' UNION SELECT 1, 2, 3, 4, 5, 6, 7, 8 --"},
+		{name: "like wildcard", category: "%"},
+		{name: "backslash", category: `\`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, total, err := f.ExpensesList(ctx, tt.category, 4, 2024)
+			if err != nil {
+				t.Fatalf("ExpensesList(%q) error: %v", tt.category, err)
+			}
+
+			if got := ids(list); !slices.Equal(got, tt.wantIDs) {
+				t.Errorf("ExpensesList(%q) ids = %v, want %v", tt.category, got, tt.wantIDs)
+			}
+
+			if total != tt.wantTotal {
+				t.Errorf("ExpensesList(%q) total = %d, want %d", tt.category, total, tt.wantTotal)
+			}
+		})
+	}
+
+	list, total, err := f.ExpensesList(ctx, "", 4, 2024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := ids(list); !slices.Equal(got, []int64{1, 2}) || total != 107 {
+		t.Errorf("expenses changed after hostile requests: ids = %v, total = %d", got, total)
+	}
+}
+
+func TestSearchExpenses_SeveralCategories(t *testing.T) {
+	f := newTestFinances(t, hostileFixtures)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+
+	tests := []struct {
+		name    string
+		query   financesgrpc.ExpensesQuery
+		wantIDs []int64
+	}{
+		{
+			name:    "names",
+			query:   financesgrpc.ExpensesQuery{Categories: []string{"Моти", "Моти'"}},
+			wantIDs: []int64{1, 2},
+		},
+		{
+			name:    "hostile name next to a real one",
+			query:   financesgrpc.ExpensesQuery{Categories: []string{"Моти", "') OR 1=1 --"}},
+			wantIDs: []int64{1},
+		},
+		{
+			name:    "ids",
+			query:   financesgrpc.ExpensesQuery{CategoryIDs: []int64{2, 42}},
+			wantIDs: []int64{2},
+		},
+		{
+			name:    "names and ids",
+			query:   financesgrpc.ExpensesQuery{Categories: []string{"Моти"}, CategoryIDs: []int64{2}},
+			wantIDs: []int64{1, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, _, _, err := f.SearchExpenses(ctx, tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := ids(list); !slices.Equal(got, tt.wantIDs) {
+				t.Errorf("ids = %v, want %v", got, tt.wantIDs)
+			}
+		})
+	}
+}
+
+// pagingFixtures have expenses sharing dates and amounts, so pages break
+// within ties on the sort key.
+const pagingFixtures = `
+	DELETE FROM Categories;
+	INSERT INTO Categories (id, name) VALUES (1, 'Моти');
+	INSERT INTO Expenses (id, date, description, amount, category_id) VALUES
+		(1, '2024-04-01', 'Корм для КОШКИ', 100, 1),
+		(2, '2024-04-01', 'врач', 300, 1),
+		(3, '2024-04-02', 'корм', 100, 1),
+		(4, '2024-04-02', '100% сок', 200, 1),
+		(5, '2024-04-02', 'Кофе', 100, 1),
+		(6, '2024-04-03', 'игрушка', 300, 1),
+		(7, '2024-04-01', 'лоток', 200, 1),
+		(8, '2024-12-31', 'ёлка', 400, 1),
+		(9, '2023-12-31', 'ёлка', 400, 1),
+		(10, '2025-01-01', 'салют', 400, 1);
+`
+
+func TestSearchExpenses_Paging(t *testing.T) {
+	f := newTestFinances(t, pagingFixtures)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+	april := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		sortBy    string
+		ascending bool
+		want      []int64
+	}{
+		{sortBy: "", want: []int64{6, 5, 4, 3, 7, 2, 1}},
+		{sortBy: models.SortByDate, ascending: true, want: []int64{1, 2, 7, 3, 4, 5, 6}},
+		{sortBy: models.SortByAmount, want: []int64{6, 2, 7, 4, 5, 3, 1}},
+		{sortBy: models.SortByAmount, ascending: true, want: []int64{1, 3, 5, 4, 7, 2, 6}},
+	} {
+		for _, limit := range []int{1, 2, 3, 7} {
+			name := fmt.Sprintf("sort %q ascending %v by %d", tc.sortBy, tc.ascending, limit)
+			query := financesgrpc.ExpensesQuery{
+				From: april, To: april.AddDate(0, 1, -1), SortBy: tc.sortBy, Ascending: tc.ascending, Limit: limit,
+			}
+
+			var got []int64
+			for pages := 0; pages <= len(tc.want); pages++ {
+				list, total, next, err := f.SearchExpenses(ctx, query)
+				if err != nil {
+					t.Fatalf("%s: %v", name, err)
+				}
+				if total != 1300 {
+					t.Errorf("%s: total %d on page %d, want 1300", name, total, pages)
+				}
+				for _, e := range list {
+					got = append(got, e.ID)
+				}
+				if next == "" {
+					break
+				}
+				query.Cursor = next
+			}
+
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("%s: paged through %v, want %v", name, got, tc.want)
+			}
+		}
+	}
+}
+
+func TestSearchExpenses_Cursor(t *testing.T) {
+	f := newTestFinances(t, pagingFixtures)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+
+	_, _, byAmount, err := f.SearchExpenses(ctx, financesgrpc.ExpensesQuery{SortBy: models.SortByAmount, Limit: 2})
+	if err != nil || byAmount == "" {
+		t.Fatalf("cursor %q, %v", byAmount, err)
+	}
+
+	for name, query := range map[string]financesgrpc.ExpensesQuery{
+		"cursor of another sort": {SortBy: models.SortByDate, Cursor: byAmount, Limit: 2},
+		"cursor of the default":  {Cursor: byAmount, Limit: 2},
+		"malformed cursor":       {SortBy: models.SortByAmount, Cursor: "not a cursor", Limit: 2},
+		"unknown sort":           {SortBy: "description"},
+	} {
+		if _, _, _, err := f.SearchExpenses(ctx, query); !errors.Is(err, financesgrpc.ErrInvalidArgument) {
+			t.Errorf("%s: err = %v, want ErrInvalidArgument", name, err)
+		}
+	}
+}
+
+func TestSearchExpenses_Filters(t *testing.T) {
+	f := newTestFinances(t, pagingFixtures)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+
+	for _, tc := range []struct {
+		name    string
+		query   financesgrpc.ExpensesQuery
+		wantIDs []int64
+	}{
+		{name: "min amount", query: financesgrpc.ExpensesQuery{MinAmount: 300}, wantIDs: []int64{2, 6, 8, 9, 10}},
+		{name: "max amount", query: financesgrpc.ExpensesQuery{MaxAmount: 100}, wantIDs: []int64{1, 3, 5}},
+		{name: "amount range", query: financesgrpc.ExpensesQuery{MinAmount: 150, MaxAmount: 250}, wantIDs: []int64{4, 7}},
+		{name: "casefold", query: financesgrpc.ExpensesQuery{Search: "КОРМ"}, wantIDs: []int64{1, 3}},
+		{name: "all words", query: financesgrpc.ExpensesQuery{Search: "корм кошки"}, wantIDs: []int64{1}},
+		{name: "ё", query: financesgrpc.ExpensesQuery{Search: "ЁЛКА"}, wantIDs: []int64{8, 9}},
+		{name: "like wildcard", query: financesgrpc.ExpensesQuery{Search: "%"}, wantIDs: []int64{4}},
+	} {
+		list, _, _, err := f.SearchExpenses(ctx, tc.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if got := ids(list); !slices.Equal(got, tc.wantIDs) {
+			t.Errorf("%s: ids = %v, want %v", tc.name, got, tc.wantIDs)
+		}
+	}
+}
+
+func TestExpensesList_Period(t *testing.T) {
+	f := newTestFinances(t, pagingFixtures)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+
+	for _, tc := range []struct {
+		month, year int64
+		wantIDs     []int64
+		wantTotal   int64
+	}{
+		{month: 4, year: 2024, wantIDs: []int64{1, 2, 3, 4, 5, 6, 7}, wantTotal: 1300},
+		{month: 0, year: 2024, wantIDs: []int64{1, 2, 3, 4, 5, 6, 7, 8}, wantTotal: 1700},
+		{month: 12, year: 2023, wantIDs: []int64{9}, wantTotal: 400},
+	} {
+		list, total, err := f.ExpensesList(ctx, "", tc.month, tc.year)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ids(list); !slices.Equal(got, tc.wantIDs) || total != tc.wantTotal {
+			t.Errorf("%d/%d: ids = %v, total = %d; want %v, %d", tc.month, tc.year, got, total, tc.wantIDs, tc.wantTotal)
+		}
+	}
+}
+
+func TestListPeriod(t *testing.T) {
+	now := time.Date(2026, 10, 16, 15, 4, 5, 0, time.UTC)
+
+	for _, tc := range []struct {
+		month, year int64
+		from, to    string
+	}{
+		{month: 4, year: 2024, from: "2024-04-01", to: "2024-04-30"},
+		{month: 2, year: 2024, from: "2024-02-01", to: "2024-02-29"},
+		{month: 0, year: 2024, from: "2024-01-01", to: "2024-12-31"},
+		{month: 0, year: 0, from: "2026-10-01", to: "2026-10-31"},
+		{month: 3, year: 0, from: "2026-03-01", to: "2026-03-31"},
+	} {
+		from, to := finances.ListPeriod(tc.month, tc.year, now)
+		if got, want := from.Format(time.DateOnly)+".."+to.Format(time.DateOnly), tc.from+".."+tc.to; got != want {
+			t.Errorf("%d/%d: %s, want %s", tc.month, tc.year, got, want)
+		}
+	}
+}
+
+func TestExpensesList_ScopedToUser(t *testing.T) {
+	f := newTestFinances(t, hostileFixtures+`
+		INSERT INTO Users (id, name, created_at) VALUES (2, 'friend', '2024-04-01T00:00:00Z');
+		INSERT INTO Categories (id, name, user_id) VALUES (3, 'Моти', 2);
+		INSERT INTO Expenses (date, description, amount, category_id, user_id) VALUES
+			('2024-04-03', 'чужое', 500, 3, 2);
+	`)
+
+	owner := userctx.WithUserID(context.Background(), models.OwnerID)
+	friend := userctx.WithUserID(context.Background(), 2)
+
+	list, total, err := f.ExpensesList(owner, "Моти", 4, 2024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(list); !slices.Equal(got, []int64{1}) || total != 100 {
+		t.Errorf("owner: ids = %v, total = %d, want [1], 100", got, total)
+	}
+
+	list, total, err = f.ExpensesList(friend, "Моти", 4, 2024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(list); !slices.Equal(got, []int64{3}) || total != 500 {
+		t.Errorf("friend: ids = %v, total = %d, want [3], 500", got, total)
+	}
+
+	if _, err := f.ExpenseEdit(friend, 1, "захват", 0, "", ""); err == nil {
+		t.Error("friend edited an expense of the owner")
+	}
+
+	if _, _, err := f.ExpensesList(context.Background(), "Моти", 4, 2024); !errors.Is(err, financesgrpc.ErrUnauthenticated) {
+		t.Errorf("without identity: err = %v, want ErrUnauthenticated", err)
+	}
+}
+
+func TestMergeCategories_IntoItself(t *testing.T) {
+	f := newTestFinances(t, hostileFixtures)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+
+	if err := f.MergeCategories(ctx, 1, 1); !errors.Is(err, financesgrpc.ErrInvalidArgument) {
+		t.Fatalf("err = %v, want ErrInvalidArgument", err)
+	}
+
+	if _, total, err := f.ExpensesList(ctx, "Моти", 4, 2024); err != nil || total != 100 {
+		t.Errorf("after merge into itself: total = %d, %v; want 100", total, err)
+	}
+}
+
+func TestCategories_EditArchiveAndMerge(t *testing.T) {
+	f := newTestFinances(t, hostileFixtures+`
+		INSERT INTO Categories (id, name, color) VALUES (3, 'Такси', '#000');
+	`)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+
+	listed := func() []string {
+		t.Helper()
+
+		categories, err := f.CategoriesList(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var names []string
+		for _, c := range categories {
+			names = append(names, c.Name)
+		}
+		slices.Sort(names)
+
+		return names
+	}
+
+	edited, err := f.EditCategory(ctx, 3, "Кафе", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (financesgrpc.Category{ID: 3, Name: "Кафе", Color: "#000"}); edited != want {
+		t.Errorf("edited %+v, want %+v", edited, want)
+	}
+	if _, err := f.EditCategory(ctx, 3, "Моти", "", ""); !errors.Is(err, storage.ErrCategoryExists) {
+		t.Errorf("renamed to a taken name: %v", err)
+	}
+	if _, err := f.EditCategory(ctx, 99, "Нет", "", ""); !errors.Is(err, storage.ErrCategoryNotFound) {
+		t.Errorf("edited a missing category: %v", err)
+	}
+
+	if err := f.ArchiveCategory(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := listed(), []string{"Моти", "Моти'"}; !slices.Equal(got, want) {
+		t.Errorf("listed after archiving %v, want %v", got, want)
+	}
+	if err := f.UnarchiveCategory(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := listed(), []string{"Кафе", "Моти", "Моти'"}; !slices.Equal(got, want) {
+		t.Errorf("listed after unarchiving %v, want %v", got, want)
+	}
+	if err := f.ArchiveCategory(ctx, 99); !errors.Is(err, storage.ErrCategoryNotFound) {
+		t.Errorf("archived a missing category: %v", err)
+	}
+
+	for _, b := range []struct {
+		category int64
+		month    string
+		amount   int64
+	}{
+		{category: 1, month: "2024-04", amount: 50},
+		{category: 2, month: "2024-03", amount: 1000},
+		{category: 2, month: "2024-04", amount: 200},
+	} {
+		if err := f.SetBudget(ctx, b.category, b.month, b.amount, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := f.MergeCategories(ctx, 2, 1); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := listed(), []string{"Кафе", "Моти"}; !slices.Equal(got, want) {
+		t.Errorf("listed after merging %v, want %v", got, want)
+	}
+
+	list, _, err := f.ExpensesList(ctx, "Моти", 4, 2024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(list); !slices.Equal(got, []int64{1, 2}) {
+		t.Errorf("expenses of the merged category: %v", got)
+	}
+
+	// The March budget moves over, the target keeps its own for April.
+	for month, want := range map[string]int64{"2024-03": 1000, "2024-04": 50} {
+		budgets, err := f.Budgets(ctx, month)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(budgets) != 1 || budgets[0].CategoryID != 1 || budgets[0].Amount != want {
+			t.Errorf("%s budgets: %+v, want %d for Моти", month, budgets, want)
+		}
+	}
+
+	if err := f.MergeCategories(ctx, 2, 1); !errors.Is(err, storage.ErrCategoryNotFound) {
+		t.Errorf("merged a removed category: %v", err)
+	}
+}
+
+func TestReportPeriod(t *testing.T) {
+	date := func(s string) time.Time {
+		d, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return d
+	}
+	later := time.Date(2026, 10, 16, 15, 4, 5, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name     string
+		rf       financesgrpc.ReportFilter
+		month    int
+		year     int
+		now      time.Time
+		from, to string
+	}{
+		{name: "month", rf: financesgrpc.Month, month: 4, year: 2024, now: later, from: "2024-04-01", to: "2024-04-30"},
+		{name: "leap february", rf: financesgrpc.Month, month: 2, year: 2024, now: later, from: "2024-02-01", to: "2024-02-29"},
+		{name: "year", rf: financesgrpc.Year, year: 2024, now: later, from: "2024-01-01", to: "2024-12-31"},
+		{name: "year ignores the month", rf: financesgrpc.Year, month: 4, year: 2024, now: later, from: "2024-01-01", to: "2024-12-31"},
+		{name: "week of a past month", rf: financesgrpc.Week, month: 4, year: 2024, now: later, from: "2024-04-24", to: "2024-04-30"},
+		{name: "week of the running month", rf: financesgrpc.Week, month: 4, year: 2024, now: time.Date(2024, 4, 10, 23, 59, 0, 0, time.UTC), from: "2024-04-04", to: "2024-04-10"},
+		{name: "week early in the month", rf: financesgrpc.Week, month: 4, year: 2024, now: date("2024-04-03"), from: "2024-03-28", to: "2024-04-03"},
+	} {
+		from, to, err := finances.ReportPeriod(tc.rf, tc.month, tc.year, tc.now)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if !from.Equal(date(tc.from)) || !to.Equal(date(tc.to)) {
+			t.Errorf("%s: %s..%s, want %s..%s", tc.name, from.Format(time.DateOnly), to.Format(time.DateOnly), tc.from, tc.to)
+		}
+	}
+
+	if _, _, err := finances.ReportPeriod("day", 4, 2024, later); !errors.Is(err, financesgrpc.ErrInvalidArgument) {
+		t.Errorf("unknown filter: err = %v, want ErrInvalidArgument", err)
+	}
+}
+
+func TestReport_TotalsAverageAndMedian(t *testing.T) {
+	f := newTestFinances(t, `
+		DELETE FROM Categories;
+		INSERT INTO Categories (id, name) VALUES (1, 'Моти'), (2, 'Моти''');
+		INSERT INTO Expenses (date, description, amount, category_id) VALUES
+			('2024-01-15', 'корм', 1000, 1),
+			('2024-04-01', 'корм', 100, 1),
+			('2024-04-20', 'игрушка', 300, 2),
+			('2024-04-24', 'корм', 50, 1),
+			('2024-04-24', 'игрушка', 30, 2),
+			('2024-04-30', 'врач', 500, 1),
+			('2025-01-01', 'корм', 999, 1);
+	`)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+
+	// The average and the median are of the days with expenses.
+	for _, tc := range []struct {
+		rf                     financesgrpc.ReportFilter
+		month                  int
+		total, average, median int64
+	}{
+		{rf: financesgrpc.Week, month: 4, total: 580, average: 290, median: 500},
+		{rf: financesgrpc.Month, month: 4, total: 980, average: 245, median: 300},
+		{rf: financesgrpc.Year, total: 1980, average: 396, median: 300},
+	} {
+		total, average, median, _, err := f.Report(ctx, tc.rf, tc.month, 2024)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.rf, err)
+		}
+		if total != tc.total || average != tc.average || median != tc.median {
+			t.Errorf("%s: total %d, average %d, median %d; want %d, %d, %d",
+				tc.rf, total, average, median, tc.total, tc.average, tc.median)
+		}
+	}
+
+	if _, _, _, _, err := f.Report(ctx, "day", 4, 2024); !errors.Is(err, financesgrpc.ErrInvalidArgument) {
+		t.Errorf("unknown filter: err = %v, want ErrInvalidArgument", err)
+	}
+}
+
+func TestArchivedCategories_TakeNoNewRecords(t *testing.T) {
+	f := newTestFinances(t, hostileFixtures)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+
+	// Records made before Моти' is archived may stay in it.
+	split, err := f.SaveExpense(ctx, financesgrpc.Expense{Description: "чек", Amount: 100, Date: "2024-04-03",
+		Splits: []financesgrpc.Split{{Category: "Моти", Amount: 60}, {Category: "Моти'", Amount: 40}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recurring := map[string]financesgrpc.Recurring{}
+	rules := map[string]financesgrpc.CategorizationRule{}
+	for _, category := range []string{"Моти", "Моти'"} {
+		r, err := f.CreateRecurring(ctx, financesgrpc.Recurring{
+			Description: "аренда", Amount: 500, Category: category, Frequency: "monthly", StartDate: "2099-01-01",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		recurring[category] = r
+
+		rule, err := f.SaveRule(ctx, financesgrpc.CategorizationRule{Category: category, Contains: "зоо"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules[category] = rule
+	}
+
+	if err := f.ArchiveCategory(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		write    func() error
+		archived bool
+	}{
+		{"new expense", func() error {
+			_, err := f.SaveExpense(ctx, financesgrpc.Expense{Description: "корм", Amount: 10, Date: "2024-04-05", Category: "Моти'"})
+			return err
+		}, true},
+		{"expense moved in", func() error {
+			_, err := f.SaveExpense(ctx, financesgrpc.Expense{ID: 1, Category: "Моти'"})
+			return err
+		}, true},
+		{"expense kept in", func() error {
+			_, err := f.SaveExpense(ctx, financesgrpc.Expense{ID: 2, Description: "кавычки"})
+			return err
+		}, false},
+		{"new expense over gRPC", func() error { return f.Expense(ctx, "корм", 10, "2024-04-05", "Моти'", 0) }, true},
+		{"gRPC expense moved in", func() error { return f.Expense(ctx, "корм", 100, "2024-04-01", "Моти'", 1) }, true},
+		{"gRPC expense kept in", func() error { return f.Expense(ctx, "кавычка", 7, "2024-04-02", "Моти'", 2) }, false},
+		{"edit moved in", func() error { _, err := f.ExpenseEdit(ctx, 1, "", 0, "", "Моти'"); return err }, true},
+		{"edit kept in", func() error { _, err := f.ExpenseEdit(ctx, 2, "", 8, "", ""); return err }, false},
+		{"new line item", func() error {
+			_, err := f.SaveExpense(ctx, financesgrpc.Expense{Description: "чек", Amount: 100, Date: "2024-04-05",
+				Splits: []financesgrpc.Split{{Category: "Моти", Amount: 50}, {Category: "Моти'", Amount: 50}}})
+			return err
+		}, true},
+		{"line item kept in", func() error {
+			_, err := f.SaveExpense(ctx, financesgrpc.Expense{ID: split.ID,
+				Splits: []financesgrpc.Split{{Category: "Моти", Amount: 50}, {Category: "Моти'", Amount: 50}}})
+			return err
+		}, false},
+		{"new recurring", func() error {
+			_, err := f.CreateRecurring(ctx, financesgrpc.Recurring{
+				Description: "вода", Amount: 50, Category: "Моти'", Frequency: "weekly", StartDate: "2099-01-01",
+			})
+			return err
+		}, true},
+		{"recurring moved in", func() error {
+			_, err := f.EditRecurring(ctx, financesgrpc.Recurring{ID: recurring["Моти"].ID, Category: "Моти'"})
+			return err
+		}, true},
+		{"recurring kept in", func() error {
+			_, err := f.EditRecurring(ctx, financesgrpc.Recurring{ID: recurring["Моти'"].ID, Amount: 600})
+			return err
+		}, false},
+		{"new rule", func() error {
+			_, err := f.SaveRule(ctx, financesgrpc.CategorizationRule{Category: "Моти'", Contains: "корм"})
+			return err
+		}, true},
+		{"rule moved in", func() error {
+			_, err := f.SaveRule(ctx, financesgrpc.CategorizationRule{ID: rules["Моти"].ID, Category: "Моти'", Contains: "зоо"})
+			return err
+		}, true},
+		{"rule kept in", func() error {
+			_, err := f.SaveRule(ctx, financesgrpc.CategorizationRule{ID: rules["Моти'"].ID, Category: "Моти'", Contains: "зоопарк"})
+			return err
+		}, false},
+	} {
+		err := tc.write()
+		if tc.archived && !errors.Is(err, storage.ErrCategoryArchived) {
+			t.Errorf("%s: err = %v, want ErrCategoryArchived", tc.name, err)
+		}
+		if !tc.archived && err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		}
+	}
+}
+
+func TestRangeReport_ConvertsAtRateOnExpenseDate(t *testing.T) {
+	f := newTestFinances(t, hostileFixtures+`
+		INSERT INTO Expenses (date, description, amount, currency, category_id) VALUES
+			('2024-04-03', 'такси', 10, 'USD', 1),
+			('2024-04-10', 'такси', 10, 'USD', 1),
+			('2024-03-31', 'рано', 10, 'USD', 1);
+		INSERT INTO ExchangeRates (currency, date, rate) VALUES
+			('USD', '2024-04-01', 90), ('USD', '2024-04-09', 100);
+	`)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+	from, to := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)
+
+	total, _, _, _, err := f.RangeReport(ctx, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(100 + 7 + 900 + 1000); total != want {
+		t.Errorf("total = %d, want %d", total, want)
+	}
+
+	list, _, err := f.ExpensesList(ctx, "", 4, 2024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range list {
+		if e.ID > 2 && (e.Amount != 10 || e.Currency != "USD") {
+			t.Errorf("list shows %d %s, want the original 10 USD", e.Amount, e.Currency)
+		}
+	}
+
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	if _, _, _, _, err := f.RangeReport(ctx, march, march.AddDate(0, 1, -1)); !errors.Is(err, storage.ErrRateNotFound) {
+		t.Errorf("report before the first rate: err = %v, want ErrRateNotFound", err)
+	}
+
+	// Rates convert the ledgers of everyone, so other users cannot set them.
+	friend := userctx.WithUserID(context.Background(), 2)
+	rate := []financesgrpc.ExchangeRate{{Currency: "USD", Date: "2024-03-01", Rate: 1}}
+	if err := f.SetRates(friend, rate); !errors.Is(err, financesgrpc.ErrPermissionDenied) {
+		t.Errorf("friend set rates: err = %v, want ErrPermissionDenied", err)
+	}
+	if err := f.SetRates(ctx, rate); err != nil {
+		t.Errorf("owner set rates: %v", err)
+	}
+}
+
+func TestRangeReport_Budgets(t *testing.T) {
+	f := newTestFinances(t, hostileFixtures+`
+		INSERT INTO Categories (id, name) VALUES (3, 'Такси');
+	`)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+	april := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, b := range []struct {
+		category     int64
+		month        string
+		amount       int64
+		rollsForward bool
+	}{
+		{category: 1, month: "2024-02", amount: 50, rollsForward: true},
+		{category: 2, month: "2024-03", amount: 1000},
+		{category: 3, month: "2024-04", amount: 300},
+	} {
+		if err := f.SetBudget(ctx, b.category, b.month, b.amount, b.rollsForward); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, _, _, report, err := f.RangeReport(ctx, april, april.AddDate(0, 1, -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]financesgrpc.CategoryReport{}
+	for _, c := range report {
+		got[c.Category] = c
+	}
+
+	want := map[string]financesgrpc.CategoryReport{
+		"Моти":  {Category: "Моти", Amount: 100, Budget: 50, Remaining: -50, PercentUsed: 200},
+		"Моти'": {Category: "Моти'", Amount: 7},
+		"Такси": {Category: "Такси", Budget: 300, Remaining: 300},
+	}
+	for name, w := range want {
+		if g := got[name]; g != w {
+			t.Errorf("%s: got %+v, want %+v", name, g, w)
+		}
+		if over := got[name].OverBudget(); over != (name == "Моти") {
+			t.Errorf("%s: over budget %v", name, over)
+		}
+	}
+
+	_, _, _, report, err = f.RangeReport(ctx, april, april.AddDate(0, 0, 6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range report {
+		if c.Budget != 0 {
+			t.Errorf("week report carries the budget of %s", c.Category)
+		}
+	}
+
+	if err := f.SetBudget(ctx, 1, "2024-4", 50, false); !errors.Is(err, financesgrpc.ErrInvalidArgument) {
+		t.Errorf("malformed month: err = %v, want ErrInvalidArgument", err)
+	}
+}
+
+func TestRunRecurring_CatchesUpOnce(t *testing.T) {
+	f := newTestFinances(t, hostileFixtures)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+
+	now := time.Now().UTC()
+	start := time.Date(now.Year(), now.Month()-2, 1, 0, 0, 0, 0, time.UTC)
+
+	r, err := f.CreateRecurring(ctx, financesgrpc.Recurring{
+		Description: "аренда",
+		Amount:      500,
+		Category:    "Моти",
+		Frequency:   "monthly",
+		StartDate:   start.Format(time.DateOnly),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for run, want := range []int64{3, 0} {
+		saved, err := f.RunRecurring(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved != want {
+			t.Errorf("run %d saved %d expenses, want %d", run+1, saved, want)
+		}
+	}
+
+	list, total, _, err := f.SearchExpenses(ctx, financesgrpc.ExpensesQuery{From: start, Search: "аренда"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 || total != 1500 {
+		t.Errorf("got %d expenses totalling %d, want 3 totalling 1500", len(list), total)
+	}
+
+	dates, err := f.RecurringPreview(ctx, r.ID, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{start.AddDate(0, 3, 0).Format(time.DateOnly), start.AddDate(0, 4, 0).Format(time.DateOnly)}; !slices.Equal(dates, want) {
+		t.Errorf("preview = %v, want %v", dates, want)
+	}
+}
+
+func TestRecurringPreview_EndOfMonth(t *testing.T) {
+	f := newTestFinances(t, hostileFixtures)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+
+	r, err := f.CreateRecurring(ctx, financesgrpc.Recurring{
+		Description: "телефон",
+		Amount:      300,
+		Category:    "Моти",
+		Frequency:   "monthly",
+		StartDate:   "2099-01-31",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dates, err := f.RecurringPreview(ctx, r.ID, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"2099-01-31", "2099-02-28", "2099-03-31"}; !slices.Equal(dates, want) {
+		t.Errorf("preview = %v, want %v", dates, want)
+	}
+
+	if _, err := f.RecurringPreview(ctx, r.ID, 1<<40); !errors.Is(err, financesgrpc.ErrInvalidArgument) {
+		t.Errorf("huge count: err = %v, want ErrInvalidArgument", err)
+	}
+
+	end := "2099-02-28"
+	for _, tt := range []struct {
+		name    string
+		endDate *string
+		want    string
+	}{
+		{"set", &end, end},
+		{"kept when nil", nil, end},
+		{"cleared when empty", new(string), ""},
+	} {
+		edited, err := f.EditRecurring(ctx, financesgrpc.Recurring{ID: r.ID, EndDate: tt.endDate})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if *edited.EndDate != tt.want {
+			t.Errorf("%s: end date = %q, want %q", tt.name, *edited.EndDate, tt.want)
+		}
+	}
+
+	if _, err := f.EditRecurring(ctx, financesgrpc.Recurring{ID: r.ID, Frequency: "daily"}); !errors.Is(err, financesgrpc.ErrInvalidArgument) {
+		t.Errorf("unknown frequency: err = %v, want ErrInvalidArgument", err)
+	}
+}
+
+func TestIncomeReport_PerSource(t *testing.T) {
+	f := newTestFinances(t, hostileFixtures+`
+		INSERT INTO ExchangeRates (currency, date, rate) VALUES ('USD', '2024-04-01', 90);
+	`)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+	from, to := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)
+
+	for _, name := range []string{"зарплата", "фриланс"} {
+		if _, err := f.CreateIncomeSource(ctx, name, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, in := range []financesgrpc.Income{
+		{Amount: 1000, Date: "2024-04-05", Source: "зарплата"},
+		{Amount: 2, Currency: "usd", Date: "2024-04-20", Source: "фриланс"},
+		{Amount: 5000, Date: "2024-05-05", Source: "зарплата"},
+	} {
+		if _, err := f.SaveIncome(ctx, in); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	total, sources, err := f.IncomeReport(ctx, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1180 || len(sources) != 2 {
+		t.Errorf("total = %d over %d sources, want 1180 over 2", total, len(sources))
+	}
+
+	if _, err := f.SaveIncome(ctx, financesgrpc.Income{Amount: 1, Date: "2024-04-05", Source: "клад"}); !errors.Is(err, storage.ErrIncomeSourceNotFound) {
+		t.Errorf("unknown source: err = %v, want ErrIncomeSourceNotFound", err)
+	}
+}
+
+func TestIncomeSources_EditAndDelete(t *testing.T) {
+	f := newTestFinances(t, "")
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+
+	var ids []int64
+	for _, name := range []string{"зарплата", "фриланс", "кешбэк"} {
+		source, err := f.CreateIncomeSource(ctx, name, "#000")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, source.ID)
+	}
+
+	income, err := f.SaveIncome(ctx, financesgrpc.Income{Amount: 1000, Date: "2024-04-05", Source: "зарплата"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edited, err := f.EditIncomeSource(ctx, ids[0], "оклад", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edited.Name != "оклад" || edited.Color != "#000" {
+		t.Errorf("edited: %+v", edited)
+	}
+
+	from, to := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)
+	incomes, _, err := f.IncomesList(ctx, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(incomes) != 1 || incomes[0].ID != income.ID || incomes[0].Source != "оклад" {
+		t.Errorf("incomes after the rename: %+v", incomes)
+	}
+
+	for _, tc := range []struct {
+		name string
+		err  error
+		run  func() error
+	}{
+		{"rename to a taken name", storage.ErrIncomeSourceExists, func() error {
+			_, err := f.EditIncomeSource(ctx, ids[1], "оклад", "")
+			return err
+		}},
+		{"edit unknown", storage.ErrIncomeSourceNotFound, func() error {
+			_, err := f.EditIncomeSource(ctx, 100, "клад", "")
+			return err
+		}},
+		{"delete with incomes", storage.ErrIncomeSourceInUse, func() error { return f.DeleteIncomeSource(ctx, ids[0]) }},
+		{"delete unknown", storage.ErrIncomeSourceNotFound, func() error { return f.DeleteIncomeSource(ctx, 100) }},
+		{"delete unused", nil, func() error { return f.DeleteIncomeSource(ctx, ids[1]) }},
+		{"delete again", storage.ErrIncomeSourceNotFound, func() error { return f.DeleteIncomeSource(ctx, ids[1]) }},
+	} {
+		if err := tc.run(); !errors.Is(err, tc.err) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.err)
+		}
+	}
+
+	sources, err := f.IncomeSourcesList(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != 2 || sources[0].Name != "кешбэк" || sources[1].Name != "оклад" {
+		t.Errorf("sources: %+v", sources)
+	}
+
+	other := userctx.WithUserID(context.Background(), models.OwnerID+1)
+	if err := f.DeleteIncomeSource(other, ids[2]); !errors.Is(err, storage.ErrIncomeSourceNotFound) {
+		t.Errorf("another user's source: err = %v", err)
+	}
+}
+
+func TestImportExpenses_DryRunAndDuplicates(t *testing.T) {
+	f := newTestFinances(t, hostileFixtures)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+
+	err := f.SaveImportProfile(ctx, financesgrpc.ImportProfile{
+		Name:             "bank",
+		Delimiter:        ";",
+		Date:             "Дата операции",
+		Amount:           "Сумма",
+		Description:      "Описание",
+		DecimalSeparator: ",",
+		ExpensesNegative: true,
+		Rules:            []financesgrpc.CategoryRule{{Contains: "кавыч", Category: "Моти'"}},
+		DefaultCategory:  "Моти",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const csv = "Дата операции;Сумма;Описание\n" +
+		"01.04.2024;-100,00;Корм\n" +
+		"02.04.2024;-7;Кавычка\n" +
+		"03.04.2024;-1 234,50;Зоомагазин\n" +
+		"04.04.2024;5000;Зарплата\n" +
+		"05.04.2024;много;Ошибка\n"
+
+	// The user's rules apply where the rules of the profile do not.
+	if _, err := f.SaveRule(ctx, financesgrpc.CategorizationRule{Category: "Моти'", Contains: "зоо"}); err != nil {
+		t.Fatal(err)
+	}
+
+	dry, err := f.ImportExpenses(ctx, strings.NewReader(csv), "bank", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dry.Imported != 1 || dry.Duplicates != 2 || dry.Skipped != 1 || dry.Invalid != 1 {
+		t.Errorf("dry run: %+v", dry)
+	}
+
+	res, err := f.ImportExpenses(ctx, strings.NewReader(csv), "bank", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Imported != 1 || res.Rows[2].Expense.Amount != 1235 || res.Rows[2].Expense.Category != "Моти'" {
+		t.Errorf("import: %+v", res)
+	}
+
+	again, err := f.ImportExpenses(ctx, strings.NewReader(csv), "bank", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Imported != 0 || again.Duplicates != 3 {
+		t.Errorf("second import: %+v", again)
+	}
+
+	// Archived categories take no new expenses.
+	if err := f.ArchiveCategory(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	archived, err := f.ImportExpenses(ctx, strings.NewReader("Дата операции;Сумма;Описание\n06.04.2024;-5;Зоопарк\n"), "bank", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archived.Invalid != 1 {
+		t.Errorf("import into an archived category: %+v", archived)
+	}
+}
+
+func TestExportExpenses_RoundTrip(t *testing.T) {
+	f := newTestFinances(t, hostileFixtures+`
+		INSERT INTO Expenses (date, description, amount, currency, category_id) VALUES
+			('2024-04-03', 'чек, "с кавычками"', 12, 'USD', 2),
+			('2024-05-01', 'в мае', 1, '', 1);
+		INSERT INTO Accounts (id, user_id, name, currency, opened_on) VALUES (1, 1, 'Карта', 'USD', '2024-01-01');
+		INSERT INTO Tags (id, user_id, name) VALUES (1, 1, 'чек'), (2, 1, 'вклад');
+		INSERT INTO ExpenseTags (expense_id, tag_id) VALUES (3, 1), (3, 2);
+		UPDATE Expenses SET account_id = 1 WHERE id = 3;
+	`)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+
+	from, to := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)
+
+	var csv strings.Builder
+	if err := f.ExportExpenses(ctx, &csv, "csv", from, to, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "date,amount,currency,category,color,description,tags,account\n" +
+		"2024-04-01,100,,Моти,,корм,,\n" +
+		"2024-04-02,7,,Моти',,кавычка,,\n" +
+		"2024-04-03,12,USD,Моти',,\"чек, \"\"с кавычками\"\"\",\"вклад,чек\",Карта\n"
+	if csv.String() != want {
+		t.Errorf("csv export:\n%s\nwant:\n%s", csv.String(), want)
+	}
+
+	res, err := f.ImportExpenses(ctx, strings.NewReader(csv.String()), "export", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Duplicates != 3 || res.Imported != 0 || res.Invalid != 0 {
+		t.Errorf("import of the export: %+v", res)
+	}
+
+	var xlsx bytes.Buffer
+	if err := f.ExportExpenses(ctx, &xlsx, "xlsx", from, to, []string{"Моти'"}); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(xlsx.Bytes()), int64(xlsx.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sheet, err := zr.Open("xl/worksheets/sheet1.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Rows []struct {
+			Cells []struct {
+				Text  string `xml:"is>t"`
+				Value string `xml:"v"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.NewDecoder(sheet).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Rows) != 3 || doc.Rows[2].Cells[1].Value != "12" || doc.Rows[2].Cells[5].Text != `чек, "с кавычками"` {
+		t.Errorf("xlsx sheet: %+v", doc.Rows)
+	}
+
+	if err := f.ExportExpenses(ctx, io.Discard, "pdf", from, to, nil); !errors.Is(err, financesgrpc.ErrInvalidArgument) {
+		t.Errorf("unknown format: %v", err)
+	}
+}
+
+func TestExpenseEdit_PartialUpdate(t *testing.T) {
+	f := newTestFinances(t, hostileFixtures)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+
+	if _, err := f.SaveAccount(ctx, financesgrpc.Account{Name: "Карта", OpenedOn: "2024-03-31"}); err != nil {
+		t.Fatal(err)
+	}
+
+	card := "Карта"
+	base := financesgrpc.Expense{Description: "обед", Amount: 300, Date: "2024-04-03", Category: "Моти", Tags: []string{"еда"}, Account: &card,
+		Sharing: &financesgrpc.Sharing{PaidBy: "Аня", Method: models.ShareEqual, Shares: []financesgrpc.Share{{Person: "Аня"}, {Person: "Боря"}}}}
+
+	shared := func(amounts ...int64) *financesgrpc.Sharing {
+		return &financesgrpc.Sharing{PaidBy: "Аня", Method: models.ShareEqual,
+			Shares: []financesgrpc.Share{{Person: "Аня", Amount: amounts[0]}, {Person: "Боря", Amount: amounts[1]}}}
+	}
+
+	for _, tc := range []struct {
+		name string
+		edit func(id int64) (financesgrpc.Expense, error)
+		want func(e *financesgrpc.Expense)
+	}{
+		{"no fields", func(id int64) (financesgrpc.Expense, error) { return f.ExpenseEdit(ctx, id, "", 0, "", "") },
+			func(e *financesgrpc.Expense) {}},
+		{"description", func(id int64) (financesgrpc.Expense, error) { return f.ExpenseEdit(ctx, id, "ужин", 0, "", "") },
+			func(e *financesgrpc.Expense) { e.Description = "ужин" }},
+		{"amount", func(id int64) (financesgrpc.Expense, error) { return f.ExpenseEdit(ctx, id, "", 500, "", "") },
+			func(e *financesgrpc.Expense) { e.Amount, e.Sharing = 500, shared(250, 250) }},
+		{"date", func(id int64) (financesgrpc.Expense, error) { return f.ExpenseEdit(ctx, id, "", 0, "2024-04-05", "") },
+			func(e *financesgrpc.Expense) { e.Date = "2024-04-05" }},
+		{"category", func(id int64) (financesgrpc.Expense, error) { return f.ExpenseEdit(ctx, id, "", 0, "", "Моти'") },
+			func(e *financesgrpc.Expense) { e.Category = "Моти'" }},
+		{"nil tags", func(id int64) (financesgrpc.Expense, error) { return f.SaveExpense(ctx, financesgrpc.Expense{ID: id}) },
+			func(e *financesgrpc.Expense) {}},
+		{"empty tags", func(id int64) (financesgrpc.Expense, error) {
+			return f.SaveExpense(ctx, financesgrpc.Expense{ID: id, Tags: []string{}})
+		}, func(e *financesgrpc.Expense) { e.Tags = nil }},
+		{"empty account", func(id int64) (financesgrpc.Expense, error) {
+			return f.SaveExpense(ctx, financesgrpc.Expense{ID: id, Account: new(string)})
+		}, func(e *financesgrpc.Expense) { e.Account = nil }},
+		{"no shares", func(id int64) (financesgrpc.Expense, error) {
+			return f.SaveExpense(ctx, financesgrpc.Expense{ID: id, Sharing: &financesgrpc.Sharing{}})
+		}, func(e *financesgrpc.Expense) { e.Sharing = nil }},
+	} {
+		stored, err := f.SaveExpense(ctx, base)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := stored
+		tc.want(&want)
+
+		saved, err := tc.edit(stored.ID)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(saved, want) {
+			t.Errorf("%s: saved %+v, want %+v", tc.name, saved, want)
+		}
+	}
+}
+
+func TestSaveExpense_CategorizationRules(t *testing.T) {
+	f := newTestFinances(t, hostileFixtures)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+
+	_, err := f.SaveExpense(ctx, financesgrpc.Expense{Description: "x", Amount: 1, Date: "2024-04-05", Category: "нет такой"})
+	if !errors.Is(err, storage.ErrCategoryNotFound) {
+		t.Fatalf("unknown category: %v", err)
+	}
+
+	rules := []financesgrpc.CategorizationRule{
+		{Category: "Моти", Contains: "КОРМ"},
+		{Category: "Моти'", Priority: 5, Pattern: `^корм`, MinAmount: 1000},
+		{Category: "Моти'", Weekdays: []time.Weekday{time.Saturday, time.Sunday}, MaxAmount: 10},
+	}
+	for _, r := range rules {
+		if _, err := f.SaveRule(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := f.SaveRule(ctx, financesgrpc.CategorizationRule{Category: "Моти"}); !errors.Is(err, financesgrpc.ErrInvalidArgument) {
+		t.Errorf("rule without conditions: %v", err)
+	}
+
+	for _, tc := range []struct {
+		expense  financesgrpc.Expense
+		category string
+	}{
+		{financesgrpc.Expense{Description: "корм большой", Amount: 2000, Date: "2024-04-03"}, "Моти'"},
+		{financesgrpc.Expense{Description: "Сухой корм", Amount: 2000, Date: "2024-04-03"}, "Моти"},
+		{financesgrpc.Expense{Description: "кофе", Amount: 5, Date: "2024-04-06"}, "Моти'"},
+		{financesgrpc.Expense{Description: "кофе", Amount: 5, Date: "2024-04-06", Category: "Моти"}, "Моти"},
+	} {
+		saved, err := f.SaveExpense(ctx, tc.expense)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved.Category != tc.category {
+			t.Errorf("%+v: category %q, want %q", tc.expense, saved.Category, tc.category)
+		}
+	}
+
+	_, err = f.SaveExpense(ctx, financesgrpc.Expense{Description: "кофе", Amount: 5, Date: "2024-04-05"})
+	if !errors.Is(err, financesgrpc.ErrInvalidArgument) {
+		t.Errorf("no matching rule: %v", err)
+	}
+
+	test, err := f.TestRule(ctx, financesgrpc.CategorizationRule{Category: "Моти", Contains: "корм"}, time.Time{}, time.Time{}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if test.Matched != 3 || test.SameCategory != 2 || len(test.Expenses) != 1 || test.Expenses[0].Date != "2024-04-01" {
+		t.Errorf("rule test: %+v", test)
+	}
+}
+
+func TestSuggestCategories_LearnsOnSave(t *testing.T) {
+	f := newTestFinances(t, hostileFixtures+`
+		INSERT INTO Expenses (date, description, amount, category_id) VALUES
+			('2024-04-03', 'Корм для кота', 300, 1),
+			('2024-04-04', 'корма и миска', 500, 1),
+			('2024-04-05', 'кот в мешке', 50, 2);
+	`)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+
+	suggest := func(description string) []financesgrpc.CategorySuggestion {
+		t.Helper()
+
+		list, err := f.SuggestCategories(ctx, description, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return list
+	}
+
+	list := suggest("корм коту")
+	if len(list) != 2 || list[0].Category != "Моти" || list[0].Confidence <= list[1].Confidence {
+		t.Errorf("suggestions: %+v", list)
+	}
+
+	if list := suggest("такси"); len(list) != 0 {
+		t.Errorf("unknown words: %+v", list)
+	}
+
+	saved, err := f.SaveExpense(ctx, financesgrpc.Expense{Description: "Такси домой", Amount: 10, Date: "2024-04-06", Category: "Моти'"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list := suggest("такси"); len(list) == 0 || list[0].Category != "Моти'" {
+		t.Errorf("after save: %+v", list)
+	}
+
+	if _, err := f.SaveExpense(ctx, financesgrpc.Expense{ID: saved.ID, Category: "Моти"}); err != nil {
+		t.Fatal(err)
+	}
+	if list := suggest("такси"); len(list) == 0 || list[0].Category != "Моти" {
+		t.Errorf("after edit: %+v", list)
+	}
+}
+
+func TestTags_SearchAndReport(t *testing.T) {
+	f := newTestFinances(t, hostileFixtures)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+
+	save := func(e financesgrpc.Expense) financesgrpc.Expense {
+		t.Helper()
+
+		saved, err := f.SaveExpense(ctx, e)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return saved
+	}
+
+	hotel := save(financesgrpc.Expense{Description: "отель", Amount: 500, Date: "2024-04-10", Category: "Моти",
+		Tags: []string{" Vacation-2024", "work-reimbursable", "vacation-2024"}})
+	if !slices.Equal(hotel.Tags, []string{"vacation-2024", "work-reimbursable"}) {
+		t.Errorf("saved tags: %q", hotel.Tags)
+	}
+
+	save(financesgrpc.Expense{Description: "сувенир", Amount: 30, Date: "2024-04-11", Category: "Моти'", Tags: []string{"vacation-2024", "gift"}})
+
+	if kept := save(financesgrpc.Expense{ID: hotel.ID, Amount: 450}); !slices.Equal(kept.Tags, hotel.Tags) {
+		t.Errorf("tags after edit without tags: %q", kept.Tags)
+	}
+
+	if _, err := f.SaveExpense(ctx, financesgrpc.Expense{ID: hotel.ID, Tags: []string{"a,b"}}); !errors.Is(err, financesgrpc.ErrInvalidArgument) {
+		t.Errorf("tag with comma: %v", err)
+	}
+
+	list, total, _, err := f.SearchExpenses(ctx, financesgrpc.ExpensesQuery{Tags: []string{"GIFT", "work-reimbursable"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || total != 480 {
+		t.Errorf("search by tags: %+v, total %d", list, total)
+	}
+
+	report, err := f.TagsReport(ctx, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []financesgrpc.TagReport{
+		{Tag: "vacation-2024", Amount: 480, Expenses: 2},
+		{Tag: "work-reimbursable", Amount: 450, Expenses: 1},
+		{Tag: "gift", Amount: 30, Expenses: 1},
+	}
+	if !slices.Equal(report, want) {
+		t.Errorf("tags report: %+v", report)
+	}
+
+	if cleared := save(financesgrpc.Expense{ID: hotel.ID, Tags: []string{}}); len(cleared.Tags) != 0 {
+		t.Errorf("cleared tags: %q", cleared.Tags)
+	}
+
+	tags, err := f.Tags(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(tags, []financesgrpc.Tag{{Name: "gift", Expenses: 1}, {Name: "vacation-2024", Expenses: 1}}) {
+		t.Errorf("tags: %+v", tags)
+	}
+}
+
+func TestRangeReport_SplitExpenses(t *testing.T) {
+	f := newTestFinances(t, hostileFixtures)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+
+	saved, err := f.SaveExpense(ctx, financesgrpc.Expense{Description: "гипермаркет", Amount: 1000, Date: "2024-04-05",
+		Splits: []financesgrpc.Split{{Category: "Моти'", Amount: 300}, {Category: "Моти", Amount: 700}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Category != "Моти" || len(saved.Splits) != 2 || saved.Splits[0].Amount != 700 {
+		t.Errorf("saved split expense: %+v", saved)
+	}
+
+	for name, e := range map[string]financesgrpc.Expense{
+		"sum":         {ID: saved.ID, Splits: []financesgrpc.Split{{Category: "Моти", Amount: 300}, {Category: "Моти'", Amount: 300}}},
+		"one item":    {ID: saved.ID, Splits: []financesgrpc.Split{{Category: "Моти", Amount: 1000}}},
+		"amount":      {ID: saved.ID, Amount: 1200},
+		"negative":    {ID: saved.ID, Splits: []financesgrpc.Split{{Category: "Моти", Amount: 1100}, {Category: "Моти'", Amount: -100}}},
+		"no category": {ID: saved.ID, Splits: []financesgrpc.Split{{Amount: 500}, {Category: "Моти'", Amount: 500}}},
+	} {
+		if _, err := f.SaveExpense(ctx, e); !errors.Is(err, financesgrpc.ErrInvalidArgument) {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	_, err = f.SaveExpense(ctx, financesgrpc.Expense{ID: saved.ID, Splits: []financesgrpc.Split{{Category: "Моти", Amount: 500}, {Category: "нет", Amount: 500}}})
+	if !errors.Is(err, storage.ErrCategoryNotFound) {
+		t.Errorf("unknown line item category: %v", err)
+	}
+
+	total, _, _, categories, err := f.RangeReport(ctx, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1107 {
+		t.Errorf("total: %d", total)
+	}
+
+	amounts := map[string]int64{}
+	for _, c := range categories {
+		amounts[c.Category] = c.Amount
+	}
+	if amounts["Моти"] != 800 || amounts["Моти'"] != 307 {
+		t.Errorf("categories: %+v", categories)
+	}
+
+	single, err := f.SaveExpense(ctx, financesgrpc.Expense{ID: saved.ID, Amount: 900, Splits: []financesgrpc.Split{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if single.Splits != nil || single.Amount != 900 {
+		t.Errorf("unsplit expense: %+v", single)
+	}
+}
+
+func TestSharedExpenses_SettleUp(t *testing.T) {
+	f := newTestFinances(t, hostileFixtures)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+
+	save := func(e financesgrpc.Expense) financesgrpc.Expense {
+		t.Helper()
+
+		saved, err := f.SaveExpense(ctx, e)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return saved
+	}
+
+	groceries := save(financesgrpc.Expense{Description: "продукты", Amount: 900, Date: "2024-04-05", Category: "Моти",
+		Sharing: &financesgrpc.Sharing{PaidBy: "Аня", Method: models.ShareEqual, Shares: []financesgrpc.Share{{Person: "Аня"}, {Person: "Боря"}, {Person: "Вика"}}}})
+	save(financesgrpc.Expense{Description: "такси", Amount: 1000, Date: "2024-04-06", Category: "Моти",
+		Sharing: &financesgrpc.Sharing{PaidBy: "Боря", Method: models.SharePercent, Shares: []financesgrpc.Share{{Person: "Боря", Percent: 50}, {Person: "Вика", Percent: 50}}}})
+	save(financesgrpc.Expense{Description: "ужин", Amount: 100, Date: "2024-04-07", Category: "Моти",
+		Sharing: &financesgrpc.Sharing{PaidBy: "Вика", Method: models.ShareExact, Shares: []financesgrpc.Share{{Person: "Аня", Amount: 60}, {Person: "Вика", Amount: 40}}}})
+
+	thirds := save(financesgrpc.Expense{Description: "кофе", Amount: 100, Date: "2024-04-08", Category: "Моти",
+		Sharing: &financesgrpc.Sharing{PaidBy: "Аня", Method: models.SharePercent, Shares: []financesgrpc.Share{{Person: "Аня", Percent: 33.33}, {Person: "Боря", Percent: 33.33}, {Person: "Вика", Percent: 33.34}}}})
+	if s := thirds.Sharing.Shares; s[0].Amount != 33 || s[1].Amount != 33 || s[2].Amount != 34 {
+		t.Errorf("percent shares: %+v", s)
+	}
+	save(financesgrpc.Expense{ID: thirds.ID, Sharing: &financesgrpc.Sharing{}})
+
+	if resized := save(financesgrpc.Expense{ID: groceries.ID, Amount: 1200}); resized.Sharing == nil || resized.Sharing.Shares[2].Amount != 400 {
+		t.Errorf("reshared expense: %+v", resized.Sharing)
+	}
+
+	_, err := f.SaveExpense(ctx, financesgrpc.Expense{Description: "билеты", Amount: 100, Date: "2024-04-09", Category: "Моти",
+		Sharing: &financesgrpc.Sharing{PaidBy: "Аня", Method: models.ShareExact, Shares: []financesgrpc.Share{{Person: "Боря", Amount: 90}}}})
+	if !errors.Is(err, financesgrpc.ErrInvalidArgument) {
+		t.Errorf("exact shares short of the amount: %v", err)
+	}
+
+	debts, balances, err := f.SharedBalances(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantDebts := []financesgrpc.Debt{{From: "Боря", To: "Аня", Amount: 400}, {From: "Вика", To: "Аня", Amount: 340}, {From: "Вика", To: "Боря", Amount: 500}}
+	if !slices.Equal(debts, wantDebts) {
+		t.Errorf("debts: %+v", debts)
+	}
+	wantBalances := []financesgrpc.Balance{{Person: "Аня", Amount: 740}, {Person: "Боря", Amount: 100}, {Person: "Вика", Amount: -840}}
+	if !slices.Equal(balances, wantBalances) {
+		t.Errorf("balances: %+v", balances)
+	}
+
+	transfers, err := f.SettleUp(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []financesgrpc.Debt{{From: "Вика", To: "Аня", Amount: 740}, {From: "Вика", To: "Боря", Amount: 100}}; !slices.Equal(transfers, want) {
+		t.Errorf("transfers: %+v", transfers)
+	}
+
+	if _, err := f.SaveSettlement(ctx, financesgrpc.Settlement{Date: "2024-04-10", From: "Вика", To: "Аня", Amount: 740}); err != nil {
+		t.Fatal(err)
+	}
+
+	transfers, err = f.SettleUp(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []financesgrpc.Debt{{From: "Вика", To: "Боря", Amount: 100}}; !slices.Equal(transfers, want) {
+		t.Errorf("transfers after settlement: %+v", transfers)
+	}
+}
+
+func TestAttachments(t *testing.T) {
+	f := newTestFinances(t, hostileFixtures)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+
+	pdf := []byte("%PDF-1.4\n% receipt\n")
+	upload := func(expenseID int64, name string, content []byte) (financesgrpc.Attachment, error) {
+		return f.UploadAttachment(ctx, expenseID, name, bytes.NewReader(content))
+	}
+
+	receipt, err := upload(1, `C:\scans\чек.pdf`, pdf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.Name != "чек.pdf" || receipt.ContentType != "application/pdf" || receipt.Size != int64(len(pdf)) {
+		t.Errorf("uploaded: %+v", receipt)
+	}
+
+	copied, err := upload(2, "copy.pdf", pdf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	heic := append([]byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00"), make([]byte, 16)...)
+	if photo, err := upload(2, "IMG_0001.HEIC", heic); err != nil || photo.ContentType != "image/heic" {
+		t.Errorf("heic: %+v, %v", photo, err)
+	}
+
+	for name, err := range map[string]error{
+		"text":      func() error { _, err := upload(1, "note.png", []byte("just text")); return err }(),
+		"not heic":  func() error { _, err := upload(1, "fake.heic", []byte("\x00\x00\x00\x18ftypjunk")); return err }(),
+		"too large": func() error { _, err := upload(1, "big.pdf", append(pdf, make([]byte, 1024)...)); return err }(),
+		"empty":     func() error { _, err := upload(1, "empty.pdf", nil); return err }(),
+	} {
+		if !errors.Is(err, financesgrpc.ErrInvalidArgument) {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	if _, err := upload(99, "receipt.pdf", pdf); !errors.Is(err, storage.ErrExpenseNotFound) {
+		t.Errorf("missing expense: %v", err)
+	}
+
+	list, err := f.Attachments(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0] != receipt {
+		t.Errorf("attachments: %+v", list)
+	}
+
+	if err := f.DeleteAttachment(ctx, receipt.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.DeleteAttachment(ctx, receipt.ID); !errors.Is(err, storage.ErrAttachmentNotFound) {
+		t.Errorf("deleted twice: %v", err)
+	}
+
+	// The same content is stored once and stays while attached elsewhere.
+	_, content, err := f.OpenAttachment(ctx, copied.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer content.Close()
+
+	if data, err := io.ReadAll(content); err != nil || !bytes.Equal(data, pdf) {
+		t.Errorf("downloaded %q, %v", data, err)
+	}
+}
+
+func TestAccounts_TransfersAndBalance(t *testing.T) {
+	f := newTestFinances(t, hostileFixtures)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+	april := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, a := range []financesgrpc.Account{
+		{Name: "Карта", OpeningBalance: 1000, OpenedOn: "2024-03-31"},
+		{Name: "Наличные", OpenedOn: "2024-03-31"},
+		{Name: "Доллары", Currency: "usd", OpenedOn: "2024-03-31"},
+	} {
+		if _, err := f.SaveAccount(ctx, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	card := "Карта"
+	lunch, err := f.SaveExpense(ctx, financesgrpc.Expense{Description: "обед", Amount: 300, Date: "2024-04-03", Category: "Моти", Account: &card})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lunch.Account == nil || *lunch.Account != card || lunch.Currency != "RUB" {
+		t.Errorf("expense paid from the card: %+v", lunch)
+	}
+
+	_, err = f.SaveExpense(ctx, financesgrpc.Expense{Description: "обед", Amount: 3, Currency: "USD", Date: "2024-04-03", Category: "Моти", Account: &card})
+	if !errors.Is(err, financesgrpc.ErrInvalidArgument) {
+		t.Errorf("expense in another currency than the account: %v", err)
+	}
+
+	if _, err := f.CreateIncomeSource(ctx, "зарплата", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.SaveIncome(ctx, financesgrpc.Income{Amount: 500, Date: "2024-04-04", Source: "зарплата", Account: &card}); err != nil {
+		t.Fatal(err)
+	}
+
+	before, _, _, _, err := f.RangeReport(ctx, april, april.AddDate(0, 1, -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.SaveTransfer(ctx, financesgrpc.Transfer{Date: "2024-04-05", From: "Карта", To: "Наличные", Amount: 200}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.SaveTransfer(ctx, financesgrpc.Transfer{Date: "2024-04-05", From: "Карта", To: "Доллары", Amount: 900})
+	if !errors.Is(err, financesgrpc.ErrInvalidArgument) {
+		t.Errorf("transfer between currencies without the amount arrived: %v", err)
+	}
+
+	after, _, _, _, err := f.RangeReport(ctx, april, april.AddDate(0, 1, -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before != 100+7+300 || after != before {
+		t.Errorf("total = %d before the transfer and %d after, want %d", before, after, 100+7+300)
+	}
+
+	accounts, err := f.Accounts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := map[string]financesgrpc.Account{}
+	for _, a := range accounts {
+		byName[a.Name] = a
+	}
+	if byName["Карта"].Balance != 1000 || byName["Наличные"].Balance != 200 || byName["Доллары"].Balance != 0 {
+		t.Errorf("accounts: %+v", accounts)
+	}
+
+	history, err := f.AccountHistory(ctx, byName["Карта"].ID, april.AddDate(0, 0, 3), april.AddDate(0, 1, -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []financesgrpc.BalancePoint{{Date: "2024-04-04", Change: 500, Balance: 1200}, {Date: "2024-04-05", Change: -200, Balance: 1000}}
+	if !slices.Equal(history, want) {
+		t.Errorf("history: %+v", history)
+	}
+
+	if _, err := f.SaveExpense(ctx, financesgrpc.Expense{Description: "до открытия", Amount: 40, Date: "2024-03-30", Category: "Моти", Account: &card}); err != nil {
+		t.Fatal(err)
+	}
+	history, err = f.AccountHistory(ctx, byName["Карта"].ID, april.AddDate(0, -1, 0), april.AddDate(0, 1, -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 3 || history[0].Date != "2024-04-03" || history[2].Balance != 1000 {
+		t.Errorf("history with an expense before the account was opened: %+v", history)
+	}
+	if accounts, err = f.Accounts(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range accounts {
+		if a.Name == "Карта" && a.Balance != 1000 {
+			t.Errorf("balance with an expense before the account was opened: %d", a.Balance)
+		}
+	}
+
+	unlinked, err := f.SaveExpense(ctx, financesgrpc.Expense{ID: lunch.ID, Account: new(string)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unlinked.Account != nil {
+		t.Errorf("unlinked expense: %+v", unlinked)
+	}
+
+	if err := f.DeleteAccount(ctx, byName["Наличные"].ID); !errors.Is(err, storage.ErrAccountInUse) {
+		t.Errorf("deleting an account with transfers: %v", err)
+	}
+}
+
+func TestReconciliation_ClearAndFinish(t *testing.T) {
+	f := newTestFinances(t, hostileFixtures)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+
+	if _, err := f.SaveAccount(ctx, financesgrpc.Account{Name: "Карта", OpeningBalance: 1000, OpenedOn: "2024-03-31"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.CreateIncomeSource(ctx, "зарплата", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	card := "Карта"
+	lunch, err := f.SaveExpense(ctx, financesgrpc.Expense{Description: "обед", Amount: 300, Date: "2024-04-03", Category: "Моти", Account: &card})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.SaveExpense(ctx, financesgrpc.Expense{Description: "ужин", Amount: 50, Date: "2024-04-06", Category: "Моти", Account: &card}); err != nil {
+		t.Fatal(err)
+	}
+	salary, err := f.SaveIncome(ctx, financesgrpc.Income{Description: "аванс", Amount: 500, Date: "2024-04-04", Source: "зарплата", Account: &card})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := f.StartReconciliation(ctx, financesgrpc.Reconciliation{Account: card, Date: "2024-04-05", StatementBalance: 1200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Balance != 1200 || r.ClearedBalance != 1000 || r.Difference != 200 {
+		t.Errorf("started reconciliation: %+v", r)
+	}
+
+	_, uncleared, err := f.Reconciliation(ctx, r.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []financesgrpc.LedgerItem{
+		{Kind: models.ItemExpense, ID: lunch.ID, Date: "2024-04-03", Description: "обед", Amount: -300},
+		{Kind: models.ItemIncome, ID: salary.ID, Date: "2024-04-04", Description: "аванс", Amount: 500},
+	}
+	if !slices.Equal(uncleared, want) {
+		t.Errorf("uncleared: %+v", uncleared)
+	}
+
+	if _, err := f.FinishReconciliation(ctx, r.ID); !errors.Is(err, financesgrpc.ErrUnreconciled) {
+		t.Errorf("finishing with items left to clear: %v", err)
+	}
+
+	if err := f.SetCleared(ctx, []financesgrpc.LedgerItem{{Kind: models.ItemExpense, ID: 999}}, true); !errors.Is(err, storage.ErrExpenseNotFound) {
+		t.Errorf("clearing an unknown expense: %v", err)
+	}
+
+	// Items are cleared all together or not at all.
+	mixed := append(slices.Clone(uncleared), financesgrpc.LedgerItem{Kind: models.ItemTransfer, ID: 999})
+	if err := f.SetCleared(ctx, mixed, true); !errors.Is(err, storage.ErrTransferNotFound) {
+		t.Errorf("clearing an unknown transfer: %v", err)
+	}
+	if _, left, err := f.Reconciliation(ctx, r.ID); err != nil || len(left) != len(uncleared) {
+		t.Errorf("uncleared after a failed clear: %+v, %v", left, err)
+	}
+	if err := f.SetCleared(ctx, uncleared, true); err != nil {
+		t.Fatal(err)
+	}
+
+	finished, err := f.FinishReconciliation(ctx, r.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if finished.Difference != 0 || finished.ClearedBalance != 1200 || finished.FinishedAt == "" {
+		t.Errorf("finished reconciliation: %+v", finished)
+	}
+
+	if _, uncleared, err = f.Reconciliation(ctx, r.ID); err != nil || len(uncleared) != 0 {
+		t.Errorf("uncleared after finishing: %+v, %v", uncleared, err)
+	}
+
+	// Items edited later do not change a finished reconciliation.
+	if err := f.SetCleared(ctx, want[:1], false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.SaveExpense(ctx, financesgrpc.Expense{Description: "забыл", Amount: 10, Date: "2024-04-01", Category: "Моти", Account: &card}); err != nil {
+		t.Fatal(err)
+	}
+
+	later, _, err := f.Reconciliation(ctx, r.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if later.Balance != 1200 || later.ClearedBalance != 1200 {
+		t.Errorf("finished reconciliation after edits: %+v", later)
+	}
+}
+
+func TestGoals_Progress(t *testing.T) {
+	f := newTestFinances(t, hostileFixtures)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+	today := time.Now()
+	date := func(days int) string { return today.AddDate(0, 0, days).Format(time.DateOnly) }
+
+	if _, err := f.SaveAccount(ctx, financesgrpc.Account{Name: "Вклад"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := f.SaveGoal(ctx, financesgrpc.Goal{Name: "ноутбук", Target: 150000, Currency: "USD", Account: "Вклад"})
+	if !errors.Is(err, financesgrpc.ErrInvalidArgument) {
+		t.Errorf("goal in another currency than its account: %v", err)
+	}
+
+	laptop, err := f.SaveGoal(ctx, financesgrpc.Goal{Name: "ноутбук", Target: 150000, Deadline: date(300), Account: "Вклад"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if laptop.Currency != "RUB" {
+		t.Errorf("goal currency = %q, want that of the account", laptop.Currency)
+	}
+
+	for _, days := range []int{-60, -30, 0} {
+		if _, err := f.Contribute(ctx, financesgrpc.Contribution{GoalID: laptop.ID, Date: date(days), Amount: 10000}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := f.Contribute(ctx, financesgrpc.Contribution{GoalID: 999, Amount: 10000}); !errors.Is(err, storage.ErrGoalNotFound) {
+		t.Errorf("contributing to an unknown goal: %v", err)
+	}
+
+	_, p, err := f.GoalProgress(ctx, laptop.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Saved != 30000 || p.Remaining != 120000 || p.Percent != 20 {
+		t.Errorf("progress: %+v", p)
+	}
+	// 120000 over the 10 months to the deadline; 30000 over the 60 days
+	// since saving started.
+	if p.MonthlyRequired != 12000 || p.MonthlyPace < 15000 || p.MonthlyPace > 15500 {
+		t.Errorf("monthly required = %d, pace = %d", p.MonthlyRequired, p.MonthlyPace)
+	}
+	if p.ProjectedOn <= date(0) || p.ProjectedOn > laptop.Deadline || !p.OnTrack {
+		t.Errorf("projected on %s, deadline %s, on track %v", p.ProjectedOn, laptop.Deadline, p.OnTrack)
+	}
+
+	if _, err := f.Contribute(ctx, financesgrpc.Contribution{GoalID: laptop.ID, Date: date(0), Amount: 120000}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, p, err = f.GoalProgress(ctx, laptop.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Remaining != 0 || p.Percent != 100 || p.MonthlyRequired != 0 || p.ProjectedOn != date(0) || !p.OnTrack {
+		t.Errorf("progress of a reached goal: %+v", p)
+	}
+}
+
+func TestTrash_DeleteRestoreAndPurge(t *testing.T) {
+	dir := t.TempDir()
+	f := newTestFinancesIn(t, dir, hostileFixtures)
+	ctx := userctx.WithUserID(context.Background(), models.OwnerID)
+	april := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	saved, err := f.SaveExpense(ctx, financesgrpc.Expense{
+		Description: "ужин", Amount: 100, Date: "2024-04-03", Tags: []string{"кафе"},
+		Splits:  []financesgrpc.Split{{Category: "Моти", Amount: 60}, {Category: "Моти'", Amount: 40}},
+		Sharing: &financesgrpc.Sharing{PaidBy: "Аня", Method: models.ShareEqual, Shares: []financesgrpc.Share{{Person: "Аня"}, {Person: "Боря"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The receipt is attached to the deleted expense only, the shared copy
+	// to another expense too.
+	receipt := []byte("%PDF-1.4\n% receipt\n")
+	shared := []byte("%PDF-1.4\n% shared\n")
+	for _, a := range []struct {
+		expenseID int64
+		content   []byte
+	}{{saved.ID, receipt}, {saved.ID, shared}, {1, shared}} {
+		if _, err := f.UploadAttachment(ctx, a.expenseID, "receipt.pdf", bytes.NewReader(a.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	visible := func(want []int64, wantTotal int64) {
+		t.Helper()
+
+		list, _, err := f.ExpensesList(ctx, "", 4, 2024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ids(list); !slices.Equal(got, want) {
+			t.Errorf("listed %v, want %v", got, want)
+		}
+
+		found, _, _, err := f.SearchExpenses(ctx, financesgrpc.ExpensesQuery{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ids(found); !slices.Equal(got, want) {
+			t.Errorf("found %v, want %v", got, want)
+		}
+
+		total, _, _, _, err := f.RangeReport(ctx, april, april.AddDate(0, 1, -1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != wantTotal {
+			t.Errorf("report total %d, want %d", total, wantTotal)
+		}
+	}
+
+	trash := func(want []int64) {
+		t.Helper()
+
+		deleted, err := f.TrashList(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ids(deleted); !slices.Equal(got, want) {
+			t.Errorf("trash %v, want %v", got, want)
+		}
+	}
+
+	visible([]int64{1, 2, saved.ID}, 207)
+
+	if err := f.DeleteExpense(ctx, saved.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.DeleteExpense(ctx, saved.ID); !errors.Is(err, storage.ErrExpenseNotFound) {
+		t.Errorf("deleted twice: %v", err)
+	}
+	visible([]int64{1, 2}, 107)
+	trash([]int64{saved.ID})
+
+	if err := f.RestoreExpense(ctx, saved.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.RestoreExpense(ctx, saved.ID); !errors.Is(err, storage.ErrExpenseNotFound) {
+		t.Errorf("restored twice: %v", err)
+	}
+	visible([]int64{1, 2, saved.ID}, 207)
+	trash(nil)
+
+	// The expense deleted long ago is purged, the one deleted just now stays
+	// restorable.
+	for _, id := range []int64{saved.ID, 2} {
+		if err := f.DeleteExpense(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	conn, err := sql.Open("sqlite3", filepath.Join(dir, "expenses.db.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Exec("UPDATE Expenses SET deleted_at = '2024-05-01T00:00:00Z' WHERE id = ?", saved.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if purged, err := f.PurgeTrash(ctx); err != nil || purged != 1 {
+		t.Fatalf("purged %d, %v", purged, err)
+	}
+	trash([]int64{2})
+
+	for _, table := range []string{"ExpenseTags", "ExpenseSplits", "SharedExpenses", "ExpenseShares", "Attachments"} {
+		var left int
+		if err := conn.QueryRow("SELECT count(*) FROM "+table+" WHERE expense_id = ?", saved.ID).Scan(&left); err != nil {
+			t.Fatal(err)
+		}
+		if left != 0 {
+			t.Errorf("%s: %d rows of the purged expense", table, left)
+		}
+	}
+
+	for content, kept := range map[string]bool{string(receipt): false, string(shared): true} {
+		hash := fmt.Sprintf("%x", sha256.Sum256([]byte(content)))
+		_, err := os.Stat(filepath.Join(dir, "attachments", hash[:2], hash))
+		if kept != (err == nil) {
+			t.Errorf("blob %q: kept %v, stat %v", content, kept, err)
+		}
+	}
+
+	if err := f.RestoreExpense(ctx, saved.ID); !errors.Is(err, storage.ErrExpenseNotFound) {
+		t.Errorf("restored after purge: %v", err)
+	}
+}
@@ -0,0 +1,281 @@
+package finances
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+	"github.com/kochnevns/finances-backend/internal/lib/currency"
+	"github.com/kochnevns/finances-backend/internal/lib/goals"
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+type GoalsManager interface {
+	CreateGoal(ctx context.Context, goal models.Goal) (int64, error)
+	UpdateGoal(ctx context.Context, goal models.Goal) error
+	GetGoal(ctx context.Context, userID int64, id int64) (models.Goal, error)
+	ListGoals(ctx context.Context, userID int64) ([]models.Goal, error)
+	DeleteGoal(ctx context.Context, userID int64, id int64) error
+	SaveContribution(ctx context.Context, userID int64, c models.Contribution) (int64, error)
+	DeleteContribution(ctx context.Context, userID int64, id int64) error
+	ListContributions(ctx context.Context, userID int64, goalID int64) ([]models.Contribution, error)
+}
+
+// SaveGoal creates the goal when its ID is zero and otherwise replaces its
+// name, target, deadline and account. A goal is in the currency of the
+// account it is kept in, else in the one given or the base currency, and
+// keeps it. The account is not used otherwise: what is saved is the sum
+// of the contributions.
+func (f *Finances) SaveGoal(ctx context.Context, g financesgrpc.Goal) (financesgrpc.Goal, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return financesgrpc.Goal{}, err
+	}
+
+	goal := models.Goal{
+		ID:        g.ID,
+		UserID:    uid,
+		Name:      strings.TrimSpace(g.Name),
+		Target:    g.Target,
+		Currency:  f.baseCurrency,
+		Deadline:  g.Deadline,
+		Account:   strings.TrimSpace(g.Account),
+		CreatedOn: time.Now().Format(time.DateOnly),
+	}
+
+	if goal.Name == "" {
+		return financesgrpc.Goal{}, fmt.Errorf("%w: name is required", financesgrpc.ErrInvalidArgument)
+	}
+
+	if goal.Target <= 0 {
+		return financesgrpc.Goal{}, fmt.Errorf("%w: target must be positive", financesgrpc.ErrInvalidArgument)
+	}
+
+	if goal.Deadline != "" {
+		if _, err := time.Parse(time.DateOnly, goal.Deadline); err != nil {
+			return financesgrpc.Goal{}, fmt.Errorf("%w: deadline must be in YYYY-MM-DD format", financesgrpc.ErrInvalidArgument)
+		}
+	}
+
+	var code string
+	if g.Currency != "" {
+		var err error
+		if code, err = currency.Normalize(g.Currency); err != nil {
+			return financesgrpc.Goal{}, fmt.Errorf("%w: %w", financesgrpc.ErrInvalidArgument, err)
+		}
+	}
+
+	if g.ID != 0 {
+		stored, err := f.goalsManager.GetGoal(ctx, uid, g.ID)
+		if err != nil {
+			f.log.Error(err.Error())
+			return financesgrpc.Goal{}, err
+		}
+
+		if code != "" && code != stored.Currency {
+			return financesgrpc.Goal{}, fmt.Errorf("%w: the currency of a goal does not change", financesgrpc.ErrInvalidArgument)
+		}
+		code = stored.Currency
+	}
+
+	switch {
+	case goal.Account != "":
+		var err error
+		if goal.Currency, err = f.accountCurrency(ctx, uid, goal.Account, code); err != nil {
+			return financesgrpc.Goal{}, err
+		}
+	case code != "":
+		goal.Currency = code
+	}
+
+	if g.ID == 0 {
+		id, err := f.goalsManager.CreateGoal(ctx, goal)
+		if err != nil {
+			f.log.Error(err.Error())
+			return financesgrpc.Goal{}, err
+		}
+		goal.ID = id
+	} else if err := f.goalsManager.UpdateGoal(ctx, goal); err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Goal{}, err
+	}
+
+	saved, err := f.goalsManager.GetGoal(ctx, uid, goal.ID)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Goal{}, err
+	}
+
+	return toGoal(saved), nil
+}
+
+// DeleteGoal deletes the goal with its contributions.
+func (f *Finances) DeleteGoal(ctx context.Context, id int64) error {
+	uid, err := userID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := f.goalsManager.DeleteGoal(ctx, uid, id); err != nil {
+		f.log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (f *Finances) Goals(ctx context.Context) ([]financesgrpc.Goal, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := f.goalsManager.ListGoals(ctx, uid)
+	if err != nil {
+		f.log.Error(err.Error())
+		return nil, err
+	}
+
+	rsp := make([]financesgrpc.Goal, 0, len(list))
+	for _, g := range list {
+		rsp = append(rsp, toGoal(g))
+	}
+
+	return rsp, nil
+}
+
+// GoalProgress returns how far the goal is today, what is left to save per
+// month to meet its deadline and when it is reached at the recent pace.
+func (f *Finances) GoalProgress(ctx context.Context, id int64) (financesgrpc.Goal, financesgrpc.GoalProgress, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return financesgrpc.Goal{}, financesgrpc.GoalProgress{}, err
+	}
+
+	goal, err := f.goalsManager.GetGoal(ctx, uid, id)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Goal{}, financesgrpc.GoalProgress{}, err
+	}
+
+	contributions, err := f.goalsManager.ListContributions(ctx, uid, id)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Goal{}, financesgrpc.GoalProgress{}, err
+	}
+
+	p, err := goals.Compute(goal, contributions, time.Now())
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Goal{}, financesgrpc.GoalProgress{}, err
+	}
+
+	return toGoal(goal), financesgrpc.GoalProgress{
+		Saved:           p.Saved,
+		Remaining:       p.Remaining,
+		Percent:         p.Percent,
+		MonthlyRequired: p.MonthlyRequired,
+		MonthlyPace:     p.MonthlyPace,
+		ProjectedOn:     p.ProjectedOn,
+		OnTrack:         p.OnTrack,
+	}, nil
+}
+
+// Contribute records money put towards a goal, or taken back when the
+// amount is negative, on its date, today when empty.
+func (f *Finances) Contribute(ctx context.Context, c financesgrpc.Contribution) (financesgrpc.Contribution, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return financesgrpc.Contribution{}, err
+	}
+
+	contribution := models.Contribution{
+		GoalID: c.GoalID,
+		Date:   c.Date,
+		Amount: c.Amount,
+		Note:   strings.TrimSpace(c.Note),
+	}
+
+	if contribution.Amount == 0 {
+		return financesgrpc.Contribution{}, fmt.Errorf("%w: amount is required", financesgrpc.ErrInvalidArgument)
+	}
+
+	if contribution.Date == "" {
+		contribution.Date = time.Now().Format(time.DateOnly)
+	} else if _, err := time.Parse(time.DateOnly, contribution.Date); err != nil {
+		return financesgrpc.Contribution{}, fmt.Errorf("%w: date must be in YYYY-MM-DD format", financesgrpc.ErrInvalidArgument)
+	}
+
+	id, err := f.goalsManager.SaveContribution(ctx, uid, contribution)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Contribution{}, err
+	}
+	contribution.ID = id
+
+	return toContribution(contribution), nil
+}
+
+func (f *Finances) DeleteContribution(ctx context.Context, id int64) error {
+	uid, err := userID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := f.goalsManager.DeleteContribution(ctx, uid, id); err != nil {
+		f.log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (f *Finances) Contributions(ctx context.Context, goalID int64) ([]financesgrpc.Contribution, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Contributions of a goal of someone else are not found rather than none.
+	if _, err := f.goalsManager.GetGoal(ctx, uid, goalID); err != nil {
+		f.log.Error(err.Error())
+		return nil, err
+	}
+
+	list, err := f.goalsManager.ListContributions(ctx, uid, goalID)
+	if err != nil {
+		f.log.Error(err.Error())
+		return nil, err
+	}
+
+	rsp := make([]financesgrpc.Contribution, 0, len(list))
+	for _, c := range list {
+		rsp = append(rsp, toContribution(c))
+	}
+
+	return rsp, nil
+}
+
+func toGoal(g models.Goal) financesgrpc.Goal {
+	return financesgrpc.Goal{
+		ID:       g.ID,
+		Name:     g.Name,
+		Target:   g.Target,
+		Currency: g.Currency,
+		Deadline: g.Deadline,
+		Account:  g.Account,
+		Saved:    g.Saved,
+	}
+}
+
+func toContribution(c models.Contribution) financesgrpc.Contribution {
+	return financesgrpc.Contribution{
+		ID:     c.ID,
+		GoalID: c.GoalID,
+		Date:   c.Date,
+		Amount: c.Amount,
+		Note:   c.Note,
+	}
+}
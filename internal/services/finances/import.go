@@ -0,0 +1,176 @@
+package finances
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+	"github.com/kochnevns/finances-backend/internal/lib/export"
+	"github.com/kochnevns/finances-backend/internal/lib/statement"
+	"github.com/kochnevns/finances-backend/internal/models"
+	"github.com/kochnevns/finances-backend/internal/storage"
+)
+
+type ImportManager interface {
+	SaveImportProfile(ctx context.Context, userID int64, profile models.ImportProfile) error
+	ImportProfile(ctx context.Context, userID int64, name string) (models.ImportProfile, error)
+	ListImportProfiles(ctx context.Context, userID int64) ([]models.ImportProfile, error)
+	ImportExpenses(ctx context.Context, userID int64, rows []models.ImportRow, dryRun bool) ([]models.ImportRow, error)
+}
+
+// SaveImportProfile creates or replaces the import profile with the same name.
+func (f *Finances) SaveImportProfile(ctx context.Context, p financesgrpc.ImportProfile) error {
+	uid, err := userID(ctx)
+	if err != nil {
+		return err
+	}
+
+	profile := fromImportProfile(p)
+	if err := statement.ValidateProfile(profile); err != nil {
+		return fmt.Errorf("%w: %w", financesgrpc.ErrInvalidArgument, err)
+	}
+
+	if err := f.importManager.SaveImportProfile(ctx, uid, profile); err != nil {
+		f.log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (f *Finances) ImportProfiles(ctx context.Context) ([]financesgrpc.ImportProfile, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles, err := f.importManager.ListImportProfiles(ctx, uid)
+	if err != nil {
+		f.log.Error(err.Error())
+		return nil, err
+	}
+
+	list := make([]financesgrpc.ImportProfile, 0, len(profiles))
+	for _, p := range profiles {
+		list = append(list, toImportProfile(p))
+	}
+
+	return list, nil
+}
+
+// ImportExpenses reads a bank CSV export with the saved profile and saves
+// the expenses that are not recorded yet. Rows the profile assigns no
+// category by its rules are categorized by the user's rules first. With dryRun nothing is saved and
+// the result tells what would be. CSV exports of the ledger are read with
+// the "export" profile unless one is saved under that name.
+func (f *Finances) ImportExpenses(ctx context.Context, csv io.Reader, profile string, dryRun bool) (financesgrpc.ImportResult, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return financesgrpc.ImportResult{}, err
+	}
+
+	p, err := f.importManager.ImportProfile(ctx, uid, profile)
+	if errors.Is(err, storage.ErrImportProfileNotFound) && profile == export.Profile.Name {
+		p, err = export.Profile, nil
+	}
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.ImportResult{}, err
+	}
+
+	rules, err := f.rules(ctx, uid)
+	if err != nil {
+		return financesgrpc.ImportResult{}, err
+	}
+
+	rows, err := statement.Read(csv, p, rules)
+	if err != nil {
+		return financesgrpc.ImportResult{}, fmt.Errorf("%w: %w", financesgrpc.ErrInvalidArgument, err)
+	}
+
+	if !dryRun {
+		f.cache.Flush()
+		f.suggester.forget(uid)
+	}
+
+	rows, err = f.importManager.ImportExpenses(ctx, uid, rows, dryRun)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.ImportResult{}, err
+	}
+
+	result := financesgrpc.ImportResult{Rows: make([]financesgrpc.ImportRow, 0, len(rows))}
+	for _, row := range rows {
+		switch row.Status {
+		case models.ImportNew:
+			result.Imported++
+		case models.ImportDuplicate:
+			result.Duplicates++
+		case models.ImportSkipped:
+			result.Skipped++
+		default:
+			result.Invalid++
+		}
+
+		result.Rows = append(result.Rows, financesgrpc.ImportRow{
+			Line:    row.Line,
+			Expense: f.toExpense(row.Expense),
+			Status:  row.Status,
+			Reason:  row.Reason,
+		})
+	}
+
+	return result, nil
+}
+
+func fromImportProfile(p financesgrpc.ImportProfile) models.ImportProfile {
+	profile := models.ImportProfile{
+		Name:             p.Name,
+		Delimiter:        p.Delimiter,
+		SkipRows:         p.SkipRows,
+		NoHeader:         p.NoHeader,
+		Date:             p.Date,
+		DateFormat:       p.DateFormat,
+		Amount:           p.Amount,
+		Description:      p.Description,
+		Category:         p.Category,
+		Currency:         p.Currency,
+		DecimalSeparator: p.DecimalSeparator,
+		Multiplier:       p.Multiplier,
+		ExpensesNegative: p.ExpensesNegative,
+		DefaultCategory:  p.DefaultCategory,
+	}
+
+	for _, r := range p.Rules {
+		profile.Rules = append(profile.Rules, models.CategoryRule{Contains: r.Contains, Category: r.Category})
+	}
+
+	return profile
+}
+
+func toImportProfile(p models.ImportProfile) financesgrpc.ImportProfile {
+	profile := financesgrpc.ImportProfile{
+		Name:             p.Name,
+		Delimiter:        p.Delimiter,
+		SkipRows:         p.SkipRows,
+		NoHeader:         p.NoHeader,
+		Date:             p.Date,
+		DateFormat:       p.DateFormat,
+		Amount:           p.Amount,
+		Description:      p.Description,
+		Category:         p.Category,
+		Currency:         p.Currency,
+		DecimalSeparator: p.DecimalSeparator,
+		Multiplier:       p.Multiplier,
+		ExpensesNegative: p.ExpensesNegative,
+		DefaultCategory:  p.DefaultCategory,
+	}
+
+	for _, r := range p.Rules {
+		profile.Rules = append(profile.Rules, financesgrpc.CategoryRule{Contains: r.Contains, Category: r.Category})
+	}
+
+	return profile
+}
@@ -0,0 +1,289 @@
+package finances
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+	"github.com/kochnevns/finances-backend/internal/lib/currency"
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+type IncomesManager interface {
+	CreateIncomeSource(ctx context.Context, source models.IncomeSource) (int64, error)
+	ListIncomeSources(ctx context.Context, userID int64) ([]models.IncomeSource, error)
+	GetIncomeSource(ctx context.Context, userID int64, id int64) (models.IncomeSource, error)
+	UpdateIncomeSource(ctx context.Context, source models.IncomeSource) error
+	DeleteIncomeSource(ctx context.Context, userID int64, id int64) error
+	SaveIncome(ctx context.Context, income models.Income) (int64, error)
+	UpdateIncome(ctx context.Context, income models.Income) error
+	GetIncome(ctx context.Context, userID int64, id int64) (models.Income, error)
+	DeleteIncome(ctx context.Context, userID int64, id int64) error
+	ListIncomes(ctx context.Context, userID int64, from, to time.Time) ([]models.Income, error)
+	ListIncomeSourcesReport(ctx context.Context, userID int64, from, to time.Time, base string) ([]models.CategoryReport, error)
+}
+
+func (f *Finances) CreateIncomeSource(ctx context.Context, name, color string) (financesgrpc.IncomeSource, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return financesgrpc.IncomeSource{}, err
+	}
+
+	source := models.IncomeSource{UserID: uid, Name: name, Color: color}
+
+	id, err := f.incomesManager.CreateIncomeSource(ctx, source)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.IncomeSource{}, err
+	}
+
+	return financesgrpc.IncomeSource{ID: id, Name: name, Color: color}, nil
+}
+
+func (f *Finances) IncomeSourcesList(ctx context.Context) ([]financesgrpc.IncomeSource, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sources, err := f.incomesManager.ListIncomeSources(ctx, uid)
+	if err != nil {
+		f.log.Error(err.Error())
+		return nil, err
+	}
+
+	list := make([]financesgrpc.IncomeSource, 0, len(sources))
+	for _, s := range sources {
+		list = append(list, financesgrpc.IncomeSource{ID: s.ID, Name: s.Name, Color: s.Color})
+	}
+
+	return list, nil
+}
+
+// EditIncomeSource applies the non-empty name and color to the source.
+func (f *Finances) EditIncomeSource(ctx context.Context, id int64, name, color string) (financesgrpc.IncomeSource, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return financesgrpc.IncomeSource{}, err
+	}
+
+	source, err := f.incomesManager.GetIncomeSource(ctx, uid, id)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.IncomeSource{}, err
+	}
+
+	if name != "" {
+		source.Name = name
+	}
+	if color != "" {
+		source.Color = color
+	}
+
+	f.cache.Flush()
+
+	if err := f.incomesManager.UpdateIncomeSource(ctx, source); err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.IncomeSource{}, err
+	}
+
+	return financesgrpc.IncomeSource{ID: source.ID, Name: source.Name, Color: source.Color}, nil
+}
+
+// DeleteIncomeSource deletes a source no income is recorded from.
+func (f *Finances) DeleteIncomeSource(ctx context.Context, id int64) error {
+	uid, err := userID(ctx)
+	if err != nil {
+		return err
+	}
+
+	f.cache.Flush()
+
+	if err := f.incomesManager.DeleteIncomeSource(ctx, uid, id); err != nil {
+		f.log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// SaveIncome creates the income when its ID is zero and otherwise applies
+// its non-zero fields to the stored one, like SaveExpense. Incomes paid to
+// an account are in its currency.
+func (f *Finances) SaveIncome(ctx context.Context, in financesgrpc.Income) (financesgrpc.Income, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return financesgrpc.Income{}, err
+	}
+
+	var code string
+	if in.Currency != "" {
+		var err error
+		if code, err = currency.Normalize(in.Currency); err != nil {
+			return financesgrpc.Income{}, fmt.Errorf("%w: %w", financesgrpc.ErrInvalidArgument, err)
+		}
+	}
+
+	if in.Date != "" {
+		if _, err := time.Parse(time.DateOnly, in.Date); err != nil {
+			return financesgrpc.Income{}, fmt.Errorf("%w: date must be in YYYY-MM-DD format", financesgrpc.ErrInvalidArgument)
+		}
+	}
+
+	if in.Amount < 0 {
+		return financesgrpc.Income{}, fmt.Errorf("%w: amount must be positive", financesgrpc.ErrInvalidArgument)
+	}
+
+	income := models.Income{ID: in.ID, UserID: uid}
+	if in.ID != 0 {
+		var err error
+		if income, err = f.incomesManager.GetIncome(ctx, uid, in.ID); err != nil {
+			f.log.Error(err.Error())
+			return financesgrpc.Income{}, err
+		}
+	}
+
+	if d := strings.TrimSpace(in.Description); d != "" {
+		income.Description = d
+	}
+	if in.Amount != 0 {
+		income.Amount = in.Amount
+	}
+	if in.Date != "" {
+		income.Date = in.Date
+	}
+	if in.Source != "" {
+		income.Source = in.Source
+	}
+	if code != "" {
+		income.Currency = code
+	}
+	if in.Account != nil {
+		income.Account = strings.TrimSpace(*in.Account)
+	}
+
+	if income.Account != "" {
+		given := income.Currency
+		if given == "" && in.ID != 0 {
+			given = f.baseCurrency
+		}
+
+		var err error
+		if income.Currency, err = f.accountCurrency(ctx, uid, income.Account, given); err != nil {
+			return financesgrpc.Income{}, err
+		}
+	}
+
+	if income.Amount == 0 || income.Date == "" || income.Source == "" {
+		return financesgrpc.Income{}, fmt.Errorf("%w: amount, date and source are required", financesgrpc.ErrInvalidArgument)
+	}
+
+	f.cache.Flush()
+
+	if in.ID == 0 {
+		id, err := f.incomesManager.SaveIncome(ctx, income)
+		if err != nil {
+			f.log.Error(err.Error())
+			return financesgrpc.Income{}, err
+		}
+		income.ID = id
+	} else if err := f.incomesManager.UpdateIncome(ctx, income); err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Income{}, err
+	}
+
+	saved, err := f.incomesManager.GetIncome(ctx, uid, income.ID)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Income{}, err
+	}
+
+	return f.toIncome(saved), nil
+}
+
+func (f *Finances) DeleteIncome(ctx context.Context, id int64) error {
+	uid, err := userID(ctx)
+	if err != nil {
+		return err
+	}
+
+	f.cache.Flush()
+
+	if err := f.incomesManager.DeleteIncome(ctx, uid, id); err != nil {
+		f.log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// IncomesList returns incomes dated within [from, to] and their total in
+// the base currency.
+func (f *Finances) IncomesList(ctx context.Context, from, to time.Time) ([]financesgrpc.Income, int64, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	incomes, err := f.incomesManager.ListIncomes(ctx, uid, from, to)
+	if err != nil {
+		f.log.Error(err.Error())
+		return nil, 0, err
+	}
+
+	total, _, err := f.IncomeReport(ctx, from, to)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	list := make([]financesgrpc.Income, 0, len(incomes))
+	for _, i := range incomes {
+		list = append(list, f.toIncome(i))
+	}
+
+	return list, total, nil
+}
+
+// IncomeReport sums incomes dated within [from, to] in total and per source,
+// converted to the base currency at the rate on the income date.
+func (f *Finances) IncomeReport(ctx context.Context, from, to time.Time) (int64, []financesgrpc.CategoryReport, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	sources, err := f.incomesManager.ListIncomeSourcesReport(ctx, uid, from, to, f.baseCurrency)
+	if err != nil {
+		f.log.Error(err.Error())
+		return 0, nil, err
+	}
+
+	var total int64
+	report := make([]financesgrpc.CategoryReport, 0, len(sources))
+	for _, s := range sources {
+		total += s.Amount
+		report = append(report, financesgrpc.CategoryReport{Category: s.Name, Color: s.Color, Amount: s.Amount})
+	}
+
+	return total, report, nil
+}
+
+func (f *Finances) toIncome(i models.Income) financesgrpc.Income {
+	code := i.Currency
+	if code == "" {
+		code = f.baseCurrency
+	}
+
+	return financesgrpc.Income{
+		ID:          i.ID,
+		Description: i.Description,
+		Amount:      i.Amount,
+		Currency:    code,
+		Date:        i.Date,
+		Source:      i.Source,
+		Color:       i.Color,
+		Account:     accountName(i.Account),
+	}
+}
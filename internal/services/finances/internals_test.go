@@ -0,0 +1,7 @@
+package finances
+
+// Unexported helpers exposed to the tests of the package.
+var (
+	ListPeriod   = listPeriod
+	ReportPeriod = reportPeriod
+)
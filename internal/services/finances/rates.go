@@ -0,0 +1,76 @@
+package finances
+
+import (
+	"context"
+	"fmt"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+	"github.com/kochnevns/finances-backend/internal/lib/currency"
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+type RatesManager interface {
+	SaveRates(ctx context.Context, rates []models.ExchangeRate) error
+	ListRates(ctx context.Context, currency string) ([]models.ExchangeRate, error)
+}
+
+// SetRates stores exchange rates to the base currency, replacing the ones
+// known for the same currency and date. The rates convert the ledgers of
+// all users, so only the owner sets them.
+func (f *Finances) SetRates(ctx context.Context, rates []financesgrpc.ExchangeRate) error {
+	uid, err := userID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if uid != models.OwnerID {
+		return fmt.Errorf("%w: only the owner sets exchange rates", financesgrpc.ErrPermissionDenied)
+	}
+
+	valid := make([]models.ExchangeRate, 0, len(rates))
+	for _, r := range rates {
+		rate, err := currency.ValidateRate(models.ExchangeRate{Currency: r.Currency, Date: r.Date, Rate: r.Rate})
+		if err != nil {
+			return fmt.Errorf("%w: %w", financesgrpc.ErrInvalidArgument, err)
+		}
+
+		if rate.Currency == f.baseCurrency {
+			return fmt.Errorf("%w: %s is the base currency", financesgrpc.ErrInvalidArgument, rate.Currency)
+		}
+
+		valid = append(valid, rate)
+	}
+
+	f.cache.Flush()
+
+	if err := f.ratesManager.SaveRates(ctx, valid); err != nil {
+		f.log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Rates returns the known exchange rates of the currency, or of all
+// currencies when it is empty.
+func (f *Finances) Rates(ctx context.Context, code string) ([]financesgrpc.ExchangeRate, error) {
+	if code != "" {
+		var err error
+		if code, err = currency.Normalize(code); err != nil {
+			return nil, fmt.Errorf("%w: %w", financesgrpc.ErrInvalidArgument, err)
+		}
+	}
+
+	rates, err := f.ratesManager.ListRates(ctx, code)
+	if err != nil {
+		f.log.Error(err.Error())
+		return nil, err
+	}
+
+	list := make([]financesgrpc.ExchangeRate, 0, len(rates))
+	for _, r := range rates {
+		list = append(list, financesgrpc.ExchangeRate{Currency: r.Currency, Date: r.Date, Rate: r.Rate})
+	}
+
+	return list, nil
+}
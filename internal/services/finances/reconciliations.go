@@ -0,0 +1,198 @@
+package finances
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+type ReconciliationsManager interface {
+	SaveReconciliation(ctx context.Context, r models.Reconciliation) (int64, error)
+	GetReconciliation(ctx context.Context, userID int64, id int64) (models.Reconciliation, error)
+	ListReconciliations(ctx context.Context, userID int64) ([]models.Reconciliation, error)
+	FinishReconciliation(ctx context.Context, userID int64, id int64, at time.Time) error
+	DeleteReconciliation(ctx context.Context, userID int64, id int64) error
+	UnclearedItems(ctx context.Context, userID int64, accountID int64, date string) ([]models.LedgerItem, error)
+	SetCleared(ctx context.Context, userID int64, items []models.LedgerItem, cleared bool) error
+}
+
+// StartReconciliation records the balance of a statement of the account on
+// its date, today when empty.
+func (f *Finances) StartReconciliation(ctx context.Context, r financesgrpc.Reconciliation) (financesgrpc.Reconciliation, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return financesgrpc.Reconciliation{}, err
+	}
+
+	reconciliation := models.Reconciliation{
+		UserID:           uid,
+		Account:          strings.TrimSpace(r.Account),
+		Date:             r.Date,
+		StatementBalance: r.StatementBalance,
+	}
+
+	if reconciliation.Account == "" {
+		return financesgrpc.Reconciliation{}, fmt.Errorf("%w: account is required", financesgrpc.ErrInvalidArgument)
+	}
+
+	if reconciliation.Date == "" {
+		reconciliation.Date = time.Now().Format(time.DateOnly)
+	} else if _, err := time.Parse(time.DateOnly, reconciliation.Date); err != nil {
+		return financesgrpc.Reconciliation{}, fmt.Errorf("%w: date must be in YYYY-MM-DD format", financesgrpc.ErrInvalidArgument)
+	}
+
+	id, err := f.reconciliationsManager.SaveReconciliation(ctx, reconciliation)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Reconciliation{}, err
+	}
+
+	saved, err := f.reconciliationsManager.GetReconciliation(ctx, uid, id)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Reconciliation{}, err
+	}
+
+	return toReconciliation(saved), nil
+}
+
+func (f *Finances) Reconciliation(ctx context.Context, id int64) (financesgrpc.Reconciliation, []financesgrpc.LedgerItem, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return financesgrpc.Reconciliation{}, nil, err
+	}
+
+	r, err := f.reconciliationsManager.GetReconciliation(ctx, uid, id)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Reconciliation{}, nil, err
+	}
+
+	items, err := f.reconciliationsManager.UnclearedItems(ctx, uid, r.AccountID, r.Date)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Reconciliation{}, nil, err
+	}
+
+	list := make([]financesgrpc.LedgerItem, 0, len(items))
+	for _, item := range items {
+		list = append(list, financesgrpc.LedgerItem{
+			Kind:        item.Kind,
+			ID:          item.ID,
+			Date:        item.Date,
+			Description: item.Description,
+			Amount:      item.Amount,
+		})
+	}
+
+	return toReconciliation(r), list, nil
+}
+
+func (f *Finances) Reconciliations(ctx context.Context) ([]financesgrpc.Reconciliation, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reconciliations, err := f.reconciliationsManager.ListReconciliations(ctx, uid)
+	if err != nil {
+		f.log.Error(err.Error())
+		return nil, err
+	}
+
+	list := make([]financesgrpc.Reconciliation, 0, len(reconciliations))
+	for _, r := range reconciliations {
+		list = append(list, toReconciliation(r))
+	}
+
+	return list, nil
+}
+
+// FinishReconciliation completes the reconciliation when the cleared
+// balance matches the statement. Finishing it again changes nothing.
+func (f *Finances) FinishReconciliation(ctx context.Context, id int64) (financesgrpc.Reconciliation, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return financesgrpc.Reconciliation{}, err
+	}
+
+	r, err := f.reconciliationsManager.GetReconciliation(ctx, uid, id)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Reconciliation{}, err
+	}
+
+	if d := r.StatementBalance - r.ClearedBalance; d != 0 {
+		return financesgrpc.Reconciliation{}, fmt.Errorf("%w: %d left to clear", financesgrpc.ErrUnreconciled, d)
+	}
+
+	if err := f.reconciliationsManager.FinishReconciliation(ctx, uid, id, time.Now()); err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Reconciliation{}, err
+	}
+
+	finished, err := f.reconciliationsManager.GetReconciliation(ctx, uid, id)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Reconciliation{}, err
+	}
+
+	return toReconciliation(finished), nil
+}
+
+func (f *Finances) DeleteReconciliation(ctx context.Context, id int64) error {
+	uid, err := userID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := f.reconciliationsManager.DeleteReconciliation(ctx, uid, id); err != nil {
+		f.log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// SetCleared marks the items as cleared, or not, all of them or none.
+func (f *Finances) SetCleared(ctx context.Context, items []financesgrpc.LedgerItem, cleared bool) error {
+	uid, err := userID(ctx)
+	if err != nil {
+		return err
+	}
+
+	list := make([]models.LedgerItem, 0, len(items))
+	for _, item := range items {
+		switch item.Kind {
+		case models.ItemExpense, models.ItemIncome, models.ItemTransfer:
+		default:
+			return fmt.Errorf("%w: unknown item kind %q", financesgrpc.ErrInvalidArgument, item.Kind)
+		}
+
+		list = append(list, models.LedgerItem{Kind: item.Kind, ID: item.ID})
+	}
+
+	if err := f.reconciliationsManager.SetCleared(ctx, uid, list, cleared); err != nil {
+		f.log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func toReconciliation(r models.Reconciliation) financesgrpc.Reconciliation {
+	return financesgrpc.Reconciliation{
+		ID:               r.ID,
+		Account:          r.Account,
+		Date:             r.Date,
+		StatementBalance: r.StatementBalance,
+		Balance:          r.Balance,
+		ClearedBalance:   r.ClearedBalance,
+		Difference:       r.StatementBalance - r.ClearedBalance,
+		FinishedAt:       r.FinishedAt,
+	}
+}
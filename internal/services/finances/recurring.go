@@ -0,0 +1,398 @@
+package finances
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+	"github.com/kochnevns/finances-backend/internal/lib/currency"
+	"github.com/kochnevns/finances-backend/internal/lib/recurrence"
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+type RecurringManager interface {
+	CreateRecurring(ctx context.Context, r models.Recurring) (int64, error)
+	GetRecurring(ctx context.Context, userID int64, id int64) (models.Recurring, error)
+	ListRecurring(ctx context.Context, userID int64) ([]models.Recurring, error)
+	UpdateRecurring(ctx context.Context, r models.Recurring) error
+	DeleteRecurring(ctx context.Context, userID int64, id int64) error
+	DueRecurring(ctx context.Context, until string) ([]models.Recurring, error)
+	MaterializeRecurring(ctx context.Context, r models.Recurring, dates []string, next string) (int64, error)
+}
+
+// today is the current date at midnight UTC, the way expense dates are parsed.
+func today() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour)
+}
+
+// CreateRecurring stores a recurring expense template. Its first occurrence
+// is on the start date, today when it is empty; occurrences in the past are
+// materialized on the next scheduler run.
+func (f *Finances) CreateRecurring(ctx context.Context, r financesgrpc.Recurring) (financesgrpc.Recurring, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return financesgrpc.Recurring{}, err
+	}
+
+	if r.StartDate == "" {
+		r.StartDate = today().Format(time.DateOnly)
+	}
+	if r.Interval == 0 {
+		r.Interval = 1
+	}
+
+	stored := models.Recurring{UserID: uid}
+	if err := applyRecurring(&stored, r); err != nil {
+		return financesgrpc.Recurring{}, err
+	}
+
+	if stored.Description == "" || stored.Amount <= 0 || stored.Category == "" {
+		return financesgrpc.Recurring{}, fmt.Errorf(
+			"%w: description, positive amount and category are required", financesgrpc.ErrInvalidArgument,
+		)
+	}
+
+	rule, err := toRule(stored)
+	if err != nil {
+		return financesgrpc.Recurring{}, err
+	}
+	stored.NextDate = nextDate(rule, rule.Start)
+
+	id, err := f.recurringManager.CreateRecurring(ctx, stored)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Recurring{}, err
+	}
+
+	return f.recurring(ctx, id)
+}
+
+// EditRecurring applies the non-zero fields of r to the template with its
+// ID. An empty end date, unlike a nil one, makes the template endless. A changed rule takes effect from today: occurrences it would have had
+// in the past are not materialized.
+func (f *Finances) EditRecurring(ctx context.Context, r financesgrpc.Recurring) (financesgrpc.Recurring, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return financesgrpc.Recurring{}, err
+	}
+
+	stored, err := f.recurringManager.GetRecurring(ctx, uid, r.ID)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Recurring{}, err
+	}
+
+	before := stored
+	if err := applyRecurring(&stored, r); err != nil {
+		return financesgrpc.Recurring{}, err
+	}
+
+	rule, err := toRule(stored)
+	if err != nil {
+		return financesgrpc.Recurring{}, err
+	}
+
+	if stored.Frequency != before.Frequency || stored.Interval != before.Interval ||
+		stored.StartDate != before.StartDate || stored.EndDate != before.EndDate {
+		stored.NextDate = nextDate(rule, later(rule.Start, today()))
+	}
+
+	if err := f.recurringManager.UpdateRecurring(ctx, stored); err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Recurring{}, err
+	}
+
+	return f.recurring(ctx, stored.ID)
+}
+
+// PauseRecurring stops or resumes materializing the template. Occurrences
+// that fell while it was paused are skipped.
+func (f *Finances) PauseRecurring(ctx context.Context, id int64, paused bool) error {
+	uid, err := userID(ctx)
+	if err != nil {
+		return err
+	}
+
+	stored, err := f.recurringManager.GetRecurring(ctx, uid, id)
+	if err != nil {
+		f.log.Error(err.Error())
+		return err
+	}
+
+	if stored.Paused == paused {
+		return nil
+	}
+	stored.Paused = paused
+
+	if !paused {
+		rule, err := toRule(stored)
+		if err != nil {
+			return err
+		}
+		stored.NextDate = nextDate(rule, later(rule.Start, today()))
+	}
+
+	if err := f.recurringManager.UpdateRecurring(ctx, stored); err != nil {
+		f.log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// DeleteRecurring removes the template and keeps the expenses it produced.
+func (f *Finances) DeleteRecurring(ctx context.Context, id int64) error {
+	uid, err := userID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := f.recurringManager.DeleteRecurring(ctx, uid, id); err != nil {
+		f.log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (f *Finances) RecurringList(ctx context.Context) ([]financesgrpc.Recurring, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := f.recurringManager.ListRecurring(ctx, uid)
+	if err != nil {
+		f.log.Error(err.Error())
+		return nil, err
+	}
+
+	list := make([]financesgrpc.Recurring, 0, len(stored))
+	for _, r := range stored {
+		list = append(list, f.toRecurring(r))
+	}
+
+	return list, nil
+}
+
+// maxPreviewCount is the most dates RecurringPreview returns.
+const maxPreviewCount = 100
+
+// RecurringPreview returns the dates of the next count expenses of the
+// template, including due ones the scheduler has not materialized yet.
+// A paused template is previewed as if it was resumed today.
+func (f *Finances) RecurringPreview(ctx context.Context, id int64, count int) ([]string, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if count <= 0 || count > maxPreviewCount {
+		return nil, fmt.Errorf("%w: count must be between 1 and %d", financesgrpc.ErrInvalidArgument, maxPreviewCount)
+	}
+
+	stored, err := f.recurringManager.GetRecurring(ctx, uid, id)
+	if err != nil {
+		f.log.Error(err.Error())
+		return nil, err
+	}
+
+	rule, err := toRule(stored)
+	if err != nil {
+		return nil, err
+	}
+
+	from := later(rule.Start, today())
+	if !stored.Paused {
+		if stored.NextDate == "" {
+			return []string{}, nil
+		}
+
+		if from, err = time.Parse(time.DateOnly, stored.NextDate); err != nil {
+			return nil, err
+		}
+	}
+
+	occurrences := rule.Between(from, from.AddDate(100, 0, 0), count)
+	dates := make([]string, 0, len(occurrences))
+	for _, d := range occurrences {
+		dates = append(dates, d.Format(time.DateOnly))
+	}
+
+	return dates, nil
+}
+
+// RunRecurring turns every due occurrence of the templates of all users into
+// an expense, catching up on the ones missed while the server was down. It
+// returns the number of expenses saved.
+func (f *Finances) RunRecurring(ctx context.Context) (int64, error) {
+	now := today()
+
+	due, err := f.recurringManager.DueRecurring(ctx, now.Format(time.DateOnly))
+	if err != nil {
+		f.log.Error(err.Error())
+		return 0, err
+	}
+
+	var saved int64
+	var errs []error
+	for _, r := range due {
+		n, err := f.materialize(ctx, r, now)
+		if err != nil {
+			f.log.Error(err.Error())
+			errs = append(errs, err)
+			continue
+		}
+		saved += n
+	}
+
+	if saved > 0 {
+		f.cache.Flush()
+	}
+
+	return saved, errors.Join(errs...)
+}
+
+func (f *Finances) materialize(ctx context.Context, r models.Recurring, now time.Time) (int64, error) {
+	rule, err := toRule(r)
+	if err != nil {
+		return 0, fmt.Errorf("recurring expense %d: %w", r.ID, err)
+	}
+
+	next, err := time.Parse(time.DateOnly, r.NextDate)
+	if err != nil {
+		return 0, fmt.Errorf("recurring expense %d: %w", r.ID, err)
+	}
+
+	var dates []string
+	for _, d := range rule.Between(next, now, 0) {
+		dates = append(dates, d.Format(time.DateOnly))
+	}
+
+	n, err := f.recurringManager.MaterializeRecurring(ctx, r, dates, nextDate(rule, now.AddDate(0, 0, 1)))
+	if n > 0 {
+		f.suggester.forget(r.UserID)
+	}
+
+	return n, err
+}
+
+func (f *Finances) recurring(ctx context.Context, id int64) (financesgrpc.Recurring, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return financesgrpc.Recurring{}, err
+	}
+
+	stored, err := f.recurringManager.GetRecurring(ctx, uid, id)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Recurring{}, err
+	}
+
+	return f.toRecurring(stored), nil
+}
+
+// applyRecurring copies the non-zero fields of r, and its end date when not
+// nil, to stored.
+func applyRecurring(stored *models.Recurring, r financesgrpc.Recurring) error {
+	if r.Currency != "" {
+		code, err := currency.Normalize(r.Currency)
+		if err != nil {
+			return fmt.Errorf("%w: %w", financesgrpc.ErrInvalidArgument, err)
+		}
+		stored.Currency = code
+	}
+
+	if r.Description = strings.TrimSpace(r.Description); r.Description != "" {
+		stored.Description = r.Description
+	}
+	if r.Amount < 0 {
+		return fmt.Errorf("%w: amount must be positive", financesgrpc.ErrInvalidArgument)
+	}
+	if r.Amount != 0 {
+		stored.Amount = r.Amount
+	}
+	if r.Category != "" {
+		stored.Category = r.Category
+	}
+	if r.Frequency != "" {
+		stored.Frequency = r.Frequency
+	}
+	if r.Interval != 0 {
+		stored.Interval = r.Interval
+	}
+	if r.StartDate != "" {
+		stored.StartDate = r.StartDate
+	}
+	if r.EndDate != nil {
+		stored.EndDate = *r.EndDate
+	}
+
+	return nil
+}
+
+// toRule parses and validates the recurrence rule of the template.
+func toRule(r models.Recurring) (recurrence.Rule, error) {
+	rule := recurrence.Rule{Frequency: r.Frequency, Interval: r.Interval}
+
+	var err error
+	if rule.Start, err = time.Parse(time.DateOnly, r.StartDate); err != nil {
+		return recurrence.Rule{}, fmt.Errorf("%w: start date must be in YYYY-MM-DD format", financesgrpc.ErrInvalidArgument)
+	}
+
+	if r.EndDate != "" {
+		if rule.End, err = time.Parse(time.DateOnly, r.EndDate); err != nil {
+			return recurrence.Rule{}, fmt.Errorf("%w: end date must be in YYYY-MM-DD format", financesgrpc.ErrInvalidArgument)
+		}
+	}
+
+	if err := rule.Validate(); err != nil {
+		return recurrence.Rule{}, fmt.Errorf("%w: %w", financesgrpc.ErrInvalidArgument, err)
+	}
+
+	return rule, nil
+}
+
+// nextDate formats the first date of the rule on or after from, or returns
+// an empty string when the rule has ended by then.
+func nextDate(rule recurrence.Rule, from time.Time) string {
+	d, ok := rule.Next(from)
+	if !ok {
+		return ""
+	}
+
+	return d.Format(time.DateOnly)
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+
+	return b
+}
+
+func (f *Finances) toRecurring(r models.Recurring) financesgrpc.Recurring {
+	code := r.Currency
+	if code == "" {
+		code = f.baseCurrency
+	}
+
+	return financesgrpc.Recurring{
+		ID:          r.ID,
+		Description: r.Description,
+		Amount:      r.Amount,
+		Currency:    code,
+		Category:    r.Category,
+		Color:       r.Color,
+		Frequency:   r.Frequency,
+		Interval:    r.Interval,
+		StartDate:   r.StartDate,
+		EndDate:     &r.EndDate,
+		NextDate:    r.NextDate,
+		Paused:      r.Paused,
+	}
+}
@@ -0,0 +1,225 @@
+package finances
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+	"github.com/kochnevns/finances-backend/internal/lib/categorize"
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+type RulesManager interface {
+	CreateRule(ctx context.Context, r models.CategorizationRule) (int64, error)
+	UpdateRule(ctx context.Context, r models.CategorizationRule) error
+	GetRule(ctx context.Context, userID int64, id int64) (models.CategorizationRule, error)
+	ListRules(ctx context.Context, userID int64) ([]models.CategorizationRule, error)
+	DeleteRule(ctx context.Context, userID int64, id int64) error
+}
+
+// SaveRule creates the rule when its ID is zero and otherwise replaces all
+// of its fields, so that conditions can be cleared.
+func (f *Finances) SaveRule(ctx context.Context, r financesgrpc.CategorizationRule) (financesgrpc.CategorizationRule, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return financesgrpc.CategorizationRule{}, err
+	}
+
+	rule := fromCategorizationRule(r)
+	rule.UserID = uid
+
+	if rule.Category == "" {
+		return financesgrpc.CategorizationRule{}, fmt.Errorf("%w: category is required", financesgrpc.ErrInvalidArgument)
+	}
+
+	if _, err := categorize.Compile(rule); err != nil {
+		return financesgrpc.CategorizationRule{}, fmt.Errorf("%w: %w", financesgrpc.ErrInvalidArgument, err)
+	}
+
+	if rule.ID == 0 {
+		id, err := f.rulesManager.CreateRule(ctx, rule)
+		if err != nil {
+			f.log.Error(err.Error())
+			return financesgrpc.CategorizationRule{}, err
+		}
+		rule.ID = id
+	} else if err := f.rulesManager.UpdateRule(ctx, rule); err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.CategorizationRule{}, err
+	}
+
+	saved, err := f.rulesManager.GetRule(ctx, rule.UserID, rule.ID)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.CategorizationRule{}, err
+	}
+
+	return toCategorizationRule(saved), nil
+}
+
+func (f *Finances) DeleteRule(ctx context.Context, id int64) error {
+	uid, err := userID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := f.rulesManager.DeleteRule(ctx, uid, id); err != nil {
+		f.log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (f *Finances) Rules(ctx context.Context) ([]financesgrpc.CategorizationRule, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := f.rulesManager.ListRules(ctx, uid)
+	if err != nil {
+		f.log.Error(err.Error())
+		return nil, err
+	}
+
+	list := make([]financesgrpc.CategorizationRule, 0, len(rules))
+	for _, r := range rules {
+		list = append(list, toCategorizationRule(r))
+	}
+
+	return list, nil
+}
+
+func (f *Finances) SuggestCategory(ctx context.Context, e financesgrpc.Expense) (financesgrpc.CategorizationRule, bool, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return financesgrpc.CategorizationRule{}, false, err
+	}
+
+	rule, ok, err := f.matchRule(ctx, uid, models.Expense{
+		Description: e.Description,
+		Amount:      e.Amount,
+		Date:        e.Date,
+	})
+	if err != nil || !ok {
+		return financesgrpc.CategorizationRule{}, false, err
+	}
+
+	return toCategorizationRule(rule), true, nil
+}
+
+// TestRule runs the rule over the recorded expenses without saving it. The
+// category of the rule is optional here.
+func (f *Finances) TestRule(
+	ctx context.Context, r financesgrpc.CategorizationRule, from, to time.Time, limit int,
+) (financesgrpc.RuleTest, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return financesgrpc.RuleTest{}, err
+	}
+
+	m, err := categorize.Compile(fromCategorizationRule(r))
+	if err != nil {
+		return financesgrpc.RuleTest{}, fmt.Errorf("%w: %w", financesgrpc.ErrInvalidArgument, err)
+	}
+
+	filter := models.ExpensesFilter{UserID: uid, From: from, To: to}
+
+	var test financesgrpc.RuleTest
+	err = f.expensesProvider.EachExpense(ctx, filter, func(e models.Expense) error {
+		if !m.Matches(e) {
+			return nil
+		}
+
+		test.Matched++
+		if strings.EqualFold(e.Category, r.Category) {
+			test.SameCategory++
+		}
+		if len(test.Expenses) < limit {
+			test.Expenses = append(test.Expenses, f.toExpense(e))
+		}
+
+		return nil
+	})
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.RuleTest{}, err
+	}
+
+	return test, nil
+}
+
+// assignCategory assigns the category of the first matching rule to a new
+// expense saved without one.
+func (f *Finances) assignCategory(ctx context.Context, e *models.Expense) error {
+	rule, ok, err := f.matchRule(ctx, e.UserID, *e)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return fmt.Errorf("%w: category is required, no rule matches the expense", financesgrpc.ErrInvalidArgument)
+	}
+	e.Category = rule.Category
+
+	return nil
+}
+
+func (f *Finances) matchRule(ctx context.Context, uid int64, e models.Expense) (models.CategorizationRule, bool, error) {
+	rules, err := f.rules(ctx, uid)
+	if err != nil {
+		return models.CategorizationRule{}, false, err
+	}
+
+	rule, ok := rules.Categorize(e)
+
+	return rule, ok, nil
+}
+
+// rules returns the categorization rules of the user in the order they are
+// tried.
+func (f *Finances) rules(ctx context.Context, uid int64) (categorize.Rules, error) {
+	stored, err := f.rulesManager.ListRules(ctx, uid)
+	if err != nil {
+		f.log.Error(err.Error())
+		return nil, err
+	}
+
+	rules, err := categorize.New(stored)
+	if err != nil {
+		f.log.Error(err.Error())
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func fromCategorizationRule(r financesgrpc.CategorizationRule) models.CategorizationRule {
+	return models.CategorizationRule{
+		ID:        r.ID,
+		Category:  strings.TrimSpace(r.Category),
+		Priority:  r.Priority,
+		Contains:  r.Contains,
+		Pattern:   r.Pattern,
+		MinAmount: r.MinAmount,
+		MaxAmount: r.MaxAmount,
+		Weekdays:  r.Weekdays,
+	}
+}
+
+func toCategorizationRule(r models.CategorizationRule) financesgrpc.CategorizationRule {
+	return financesgrpc.CategorizationRule{
+		ID:        r.ID,
+		Category:  r.Category,
+		Color:     r.Color,
+		Priority:  r.Priority,
+		Contains:  r.Contains,
+		Pattern:   r.Pattern,
+		MinAmount: r.MinAmount,
+		MaxAmount: r.MaxAmount,
+		Weekdays:  r.Weekdays,
+	}
+}
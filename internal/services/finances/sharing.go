@@ -0,0 +1,215 @@
+package finances
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+	"github.com/kochnevns/finances-backend/internal/lib/currency"
+	"github.com/kochnevns/finances-backend/internal/lib/settle"
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+type SharingManager interface {
+	SaveSettlement(ctx context.Context, settlement models.Settlement) (int64, error)
+	DeleteSettlement(ctx context.Context, userID int64, id int64) error
+	ListSettlements(ctx context.Context, userID int64) ([]models.Settlement, error)
+	Debts(ctx context.Context, userID int64, base string) ([]models.Debt, error)
+}
+
+// share computes the shares of the expense. It returns nil when the
+// expense is no longer shared.
+func share(amount int64, s financesgrpc.Sharing) (*models.Sharing, error) {
+	if len(s.Shares) == 0 {
+		return nil, nil
+	}
+
+	sharing := models.Sharing{PaidBy: s.PaidBy, Method: s.Method}
+	for _, sh := range s.Shares {
+		sharing.Shares = append(sharing.Shares, models.Share{Person: sh.Person, Percent: sh.Percent, Amount: sh.Amount})
+	}
+
+	shared, err := settle.Share(amount, sharing)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", financesgrpc.ErrInvalidArgument, err)
+	}
+
+	return &shared, nil
+}
+
+// reshare shares the new amount of a shared expense the way it was shared.
+func reshare(amount int64, sharing models.Sharing) (*models.Sharing, error) {
+	shared, err := settle.Share(amount, sharing)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", financesgrpc.ErrInvalidArgument, err)
+	}
+
+	return &shared, nil
+}
+
+func toSharing(s *models.Sharing) *financesgrpc.Sharing {
+	if s == nil {
+		return nil
+	}
+
+	sharing := &financesgrpc.Sharing{PaidBy: s.PaidBy, Method: s.Method}
+	for _, sh := range s.Shares {
+		sharing.Shares = append(sharing.Shares, financesgrpc.Share{Person: sh.Person, Percent: sh.Percent, Amount: sh.Amount})
+	}
+
+	return sharing
+}
+
+// SharedBalances nets what people owe each other over shared expenses and
+// settlements, in the base currency.
+func (f *Finances) SharedBalances(ctx context.Context) ([]financesgrpc.Debt, []financesgrpc.Balance, error) {
+	debts, balances, err := f.balances(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	list := make([]financesgrpc.Balance, 0, len(balances))
+	for _, b := range balances {
+		list = append(list, financesgrpc.Balance{Person: b.Person, Amount: b.Amount})
+	}
+
+	return toDebts(debts), list, nil
+}
+
+// SettleUp suggests transfers that settle all debts, fewer than there are
+// people with a balance but not always the fewest; see settle.Transfers.
+func (f *Finances) SettleUp(ctx context.Context) ([]financesgrpc.Debt, error) {
+	_, balances, err := f.balances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return toDebts(settle.Transfers(balances)), nil
+}
+
+func (f *Finances) balances(ctx context.Context) ([]models.Debt, []settle.Balance, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	debts, err := f.sharingManager.Debts(ctx, uid, f.baseCurrency)
+	if err != nil {
+		f.log.Error(err.Error())
+		return nil, nil, err
+	}
+
+	net, balances := settle.Net(debts)
+
+	return net, balances, nil
+}
+
+// SaveSettlement records that From paid To back. An empty currency is the
+// base currency and an empty date today.
+func (f *Finances) SaveSettlement(ctx context.Context, s financesgrpc.Settlement) (financesgrpc.Settlement, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return financesgrpc.Settlement{}, err
+	}
+
+	settlement := models.Settlement{
+		UserID: uid,
+		Date:   s.Date,
+		From:   strings.TrimSpace(s.From),
+		To:     strings.TrimSpace(s.To),
+		Amount: s.Amount,
+		Note:   strings.TrimSpace(s.Note),
+	}
+
+	if settlement.From == "" || settlement.To == "" || settlement.From == settlement.To {
+		return financesgrpc.Settlement{}, fmt.Errorf("%w: settlement is between two different people", financesgrpc.ErrInvalidArgument)
+	}
+
+	if settlement.Amount <= 0 {
+		return financesgrpc.Settlement{}, fmt.Errorf("%w: amount must be positive", financesgrpc.ErrInvalidArgument)
+	}
+
+	if settlement.Date == "" {
+		settlement.Date = time.Now().Format(time.DateOnly)
+	} else if _, err := time.Parse(time.DateOnly, settlement.Date); err != nil {
+		return financesgrpc.Settlement{}, fmt.Errorf("%w: date must be in YYYY-MM-DD format", financesgrpc.ErrInvalidArgument)
+	}
+
+	if s.Currency != "" {
+		var err error
+		if settlement.Currency, err = currency.Normalize(s.Currency); err != nil {
+			return financesgrpc.Settlement{}, fmt.Errorf("%w: %w", financesgrpc.ErrInvalidArgument, err)
+		}
+	}
+
+	id, err := f.sharingManager.SaveSettlement(ctx, settlement)
+	if err != nil {
+		f.log.Error(err.Error())
+		return financesgrpc.Settlement{}, err
+	}
+	settlement.ID = id
+
+	return f.toSettlement(settlement), nil
+}
+
+func (f *Finances) DeleteSettlement(ctx context.Context, id int64) error {
+	uid, err := userID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := f.sharingManager.DeleteSettlement(ctx, uid, id); err != nil {
+		f.log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (f *Finances) Settlements(ctx context.Context) ([]financesgrpc.Settlement, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	settlements, err := f.sharingManager.ListSettlements(ctx, uid)
+	if err != nil {
+		f.log.Error(err.Error())
+		return nil, err
+	}
+
+	list := make([]financesgrpc.Settlement, 0, len(settlements))
+	for _, s := range settlements {
+		list = append(list, f.toSettlement(s))
+	}
+
+	return list, nil
+}
+
+func (f *Finances) toSettlement(s models.Settlement) financesgrpc.Settlement {
+	code := s.Currency
+	if code == "" {
+		code = f.baseCurrency
+	}
+
+	return financesgrpc.Settlement{
+		ID:       s.ID,
+		Date:     s.Date,
+		From:     s.From,
+		To:       s.To,
+		Amount:   s.Amount,
+		Currency: code,
+		Note:     s.Note,
+	}
+}
+
+func toDebts(debts []models.Debt) []financesgrpc.Debt {
+	list := make([]financesgrpc.Debt, 0, len(debts))
+	for _, d := range debts {
+		list = append(list, financesgrpc.Debt{From: d.From, To: d.To, Amount: d.Amount})
+	}
+
+	return list
+}
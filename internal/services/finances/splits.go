@@ -0,0 +1,68 @@
+package finances
+
+import (
+	"fmt"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+var errSplitAmount = fmt.Errorf("%w: the amount of a split expense changes together with its line items", financesgrpc.ErrInvalidArgument)
+
+// fromSplits checks that the line items are at least two, each with a
+// category and a positive amount, and that they add up to the amount.
+func fromSplits(splits []financesgrpc.Split, amount int64) ([]models.Split, error) {
+	if len(splits) == 0 {
+		return []models.Split{}, nil
+	}
+
+	if len(splits) == 1 {
+		return nil, fmt.Errorf("%w: a split expense needs at least two line items", financesgrpc.ErrInvalidArgument)
+	}
+
+	list := make([]models.Split, 0, len(splits))
+	var sum int64
+	for _, s := range splits {
+		if s.Category == "" {
+			return nil, fmt.Errorf("%w: line item category is required", financesgrpc.ErrInvalidArgument)
+		}
+		if s.Amount <= 0 {
+			return nil, fmt.Errorf("%w: line item amount must be positive", financesgrpc.ErrInvalidArgument)
+		}
+
+		sum += s.Amount
+		list = append(list, models.Split{Category: s.Category, Amount: s.Amount})
+	}
+
+	if sum != amount {
+		return nil, fmt.Errorf("%w: line items add up to %d, not the amount %d", financesgrpc.ErrInvalidArgument, sum, amount)
+	}
+
+	return list, nil
+}
+
+// largestSplit returns the category of the largest line item, the first
+// of equal ones.
+func largestSplit(splits []models.Split) string {
+	var largest models.Split
+	for _, s := range splits {
+		if s.Amount > largest.Amount {
+			largest = s
+		}
+	}
+
+	return largest.Category
+}
+
+func toSplits(splits []models.Split) []financesgrpc.Split {
+	if splits == nil {
+		return nil
+	}
+
+	list := make([]financesgrpc.Split, 0, len(splits))
+	for _, s := range splits {
+		list = append(list, financesgrpc.Split{Category: s.Category, Color: s.Color, Amount: s.Amount})
+	}
+
+	return list
+}
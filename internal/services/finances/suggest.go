@@ -0,0 +1,140 @@
+package finances
+
+import (
+	"context"
+	"sync"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+	"github.com/kochnevns/finances-backend/internal/lib/classify"
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+// suggester keeps a category model per user, trained on the user's expenses
+// when first needed and kept up to date as expenses are saved. Changes that
+// touch many expenses drop the model so that it is trained again.
+type suggester struct {
+	mu     sync.Mutex
+	models map[int64]*classify.Model
+	// changes counts the changes of each user's expenses, so that a model
+	// trained while expenses changed is not kept.
+	changes map[int64]int
+}
+
+func newSuggester() *suggester {
+	return &suggester{models: map[int64]*classify.Model{}, changes: map[int64]int{}}
+}
+
+// learn applies a saved expense to the model of the user, replacing what
+// was learned from its previous version when it was edited.
+func (s *suggester) learn(uid int64, before, after *models.Expense) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.changes[uid]++
+
+	m := s.models[uid]
+	if m == nil {
+		return
+	}
+
+	if before != nil {
+		m.Remove(before.Description, before.Category)
+	}
+	if after != nil {
+		m.Add(after.Description, after.Category)
+	}
+}
+
+// forget drops the model of the user.
+func (s *suggester) forget(uid int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.changes[uid]++
+	delete(s.models, uid)
+}
+
+// predict runs fn on the model of the user, training one with train first
+// when there is none.
+func (s *suggester) predict(uid int64, train func(m *classify.Model) error, fn func(m *classify.Model)) error {
+	s.mu.Lock()
+	if m := s.models[uid]; m != nil {
+		fn(m)
+		s.mu.Unlock()
+		return nil
+	}
+	changes := s.changes[uid]
+	s.mu.Unlock()
+
+	m := classify.New()
+	if err := train(m); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.changes[uid] == changes {
+		s.models[uid] = m
+	}
+	fn(m)
+
+	return nil
+}
+
+// SuggestCategories returns up to limit categories the user most likely
+// means by the description, learned from the categories of past expenses
+// with similar descriptions. Archived categories are not suggested.
+func (f *Finances) SuggestCategories(ctx context.Context, description string, limit int) ([]financesgrpc.CategorySuggestion, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := f.categoriesProvider.ListCategories(ctx, uid)
+	if err != nil {
+		f.log.Error(err.Error())
+		return nil, err
+	}
+
+	// Archived categories are not listed.
+	active := map[string]models.Category{}
+	for _, c := range categories {
+		active[c.Name] = c
+	}
+
+	train := func(m *classify.Model) error {
+		return f.expensesProvider.EachExpense(ctx, models.ExpensesFilter{UserID: uid}, func(e models.Expense) error {
+			m.Add(e.Description, e.Category)
+			return nil
+		})
+	}
+
+	var predictions []classify.Prediction
+	err = f.suggester.predict(uid, train, func(m *classify.Model) {
+		predictions = m.Predict(description, len(active))
+	})
+	if err != nil {
+		f.log.Error(err.Error())
+		return nil, err
+	}
+
+	suggestions := make([]financesgrpc.CategorySuggestion, 0, limit)
+	for _, p := range predictions {
+		c, ok := active[p.Category]
+		if !ok {
+			continue
+		}
+		if len(suggestions) == limit {
+			break
+		}
+
+		suggestions = append(suggestions, financesgrpc.CategorySuggestion{
+			Category:   c.Name,
+			Color:      c.Color,
+			Confidence: p.Confidence,
+		})
+	}
+
+	return suggestions, nil
+}
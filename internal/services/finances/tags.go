@@ -0,0 +1,89 @@
+package finances
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+// maxTagLength is the longest tag name, in characters.
+const maxTagLength = 64
+
+type TagsManager interface {
+	ListTags(ctx context.Context, userID int64) ([]models.Tag, error)
+	TagsReport(ctx context.Context, userID int64, from, to time.Time, base string) ([]models.TagReport, error)
+}
+
+// normalizeTags trims and lowercases the tags, drops repeated ones and sorts
+// them. Tags must not be empty or contain commas.
+func normalizeTags(tags []string) ([]string, error) {
+	list := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+
+		switch {
+		case tag == "":
+			return nil, fmt.Errorf("%w: tags must not be empty", financesgrpc.ErrInvalidArgument)
+		case strings.Contains(tag, ","):
+			return nil, fmt.Errorf("%w: tag %q contains a comma", financesgrpc.ErrInvalidArgument, tag)
+		case utf8.RuneCountInString(tag) > maxTagLength:
+			return nil, fmt.Errorf("%w: tag %q is longer than %d characters", financesgrpc.ErrInvalidArgument, tag, maxTagLength)
+		}
+
+		list = append(list, tag)
+	}
+
+	slices.Sort(list)
+
+	return slices.Compact(list), nil
+}
+
+// Tags lists the tags on the user's expenses, the most used first.
+func (f *Finances) Tags(ctx context.Context) ([]financesgrpc.Tag, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := f.tagsManager.ListTags(ctx, uid)
+	if err != nil {
+		f.log.Error(err.Error())
+		return nil, err
+	}
+
+	list := make([]financesgrpc.Tag, 0, len(tags))
+	for _, t := range tags {
+		list = append(list, financesgrpc.Tag{Name: t.Name, Expenses: t.Expenses})
+	}
+
+	return list, nil
+}
+
+// TagsReport sums expenses per tag over the inclusive date range in the base
+// currency. Expenses with several tags count towards each of them, so the
+// amounts may add up to more than the total spent.
+func (f *Finances) TagsReport(ctx context.Context, from, to time.Time) ([]financesgrpc.TagReport, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := f.tagsManager.TagsReport(ctx, uid, from, to, f.baseCurrency)
+	if err != nil {
+		f.log.Error(err.Error())
+		return nil, err
+	}
+
+	list := make([]financesgrpc.TagReport, 0, len(report))
+	for _, r := range report {
+		list = append(list, financesgrpc.TagReport{Tag: r.Tag, Amount: r.Amount, Expenses: r.Expenses})
+	}
+
+	return list, nil
+}
@@ -0,0 +1,89 @@
+package finances
+
+import (
+	"context"
+	"time"
+
+	financesgrpc "github.com/kochnevns/finances-backend/internal/grpc/finances"
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+type ExpenseDeleter interface {
+	DeleteExpense(ctx context.Context, userID int64, id int64, at time.Time) error
+	RestoreExpense(ctx context.Context, userID int64, id int64) error
+	ListDeletedExpenses(ctx context.Context, userID int64, since time.Time) ([]models.Expense, error)
+	PurgeDeletedExpenses(ctx context.Context, before time.Time) (int64, error)
+}
+
+// DeleteExpense moves the expense to the trash. It stays restorable with
+// its attachments until the trash retention period is over.
+func (f *Finances) DeleteExpense(ctx context.Context, id int64) error {
+	uid, err := userID(ctx)
+	if err != nil {
+		return err
+	}
+
+	f.cache.Flush()
+	f.suggester.forget(uid)
+
+	if err := f.expenseDeleter.DeleteExpense(ctx, uid, id, time.Now()); err != nil {
+		f.log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (f *Finances) RestoreExpense(ctx context.Context, id int64) error {
+	uid, err := userID(ctx)
+	if err != nil {
+		return err
+	}
+
+	f.cache.Flush()
+	f.suggester.forget(uid)
+
+	if err := f.expenseDeleter.RestoreExpense(ctx, uid, id); err != nil {
+		f.log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// TrashList returns expenses deleted within the retention period.
+func (f *Finances) TrashList(ctx context.Context) ([]financesgrpc.Expense, error) {
+	uid, err := userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, err := f.expenseDeleter.ListDeletedExpenses(ctx, uid, time.Now().Add(-f.trashRetention))
+	if err != nil {
+		f.log.Error(err.Error())
+		return nil, err
+	}
+
+	list := make([]financesgrpc.Expense, 0, len(deleted))
+	for _, e := range deleted {
+		list = append(list, f.toExpense(e))
+	}
+
+	return list, nil
+}
+
+// PurgeTrash permanently removes expenses of all users whose retention
+// period is over, together with their attachments.
+func (f *Finances) PurgeTrash(ctx context.Context) (int64, error) {
+	purged, err := f.expenseDeleter.PurgeDeletedExpenses(ctx, time.Now().Add(-f.trashRetention))
+	if err != nil {
+		f.log.Error(err.Error())
+		return 0, err
+	}
+
+	if err := f.purgeAttachments(ctx); err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,159 @@
+// Package blobs stores files in a local directory by the SHA-256 of their
+// content, so that the same file uploaded twice is stored once.
+package blobs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+var (
+	ErrTooLarge = errors.New("file is too large")
+	ErrNotFound = errors.New("file not found")
+)
+
+type Store struct {
+	dir string
+}
+
+// New returns a store of the files in dir, creating it when missing.
+func New(dir string) (*Store, error) {
+	const op = "storage.blobs.New"
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Store{dir: dir}, nil
+}
+
+// path spreads the files over subdirectories by the first two characters
+// of the hash.
+func (s *Store) path(hash string) (string, error) {
+	if len(hash) != sha256.Size*2 {
+		return "", ErrNotFound
+	}
+	if _, err := hex.DecodeString(hash); err != nil {
+		return "", ErrNotFound
+	}
+
+	return filepath.Join(s.dir, hash[:2], hash), nil
+}
+
+// Pending is content written by Stage and not stored yet.
+type Pending struct {
+	Hash string
+	Size int64
+
+	tmp  string
+	path string
+}
+
+// Stage writes the content of r to a temporary file and hashes it. Content
+// over maxSize bytes is not kept and fails with ErrTooLarge. The content is
+// stored by Commit and dropped by Discard.
+func (s *Store) Stage(r io.Reader, maxSize int64) (*Pending, error) {
+	const op = "storage.blobs.Stage"
+
+	tmp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tmp.Close() // nolint: errcheck
+
+	p := &Pending{tmp: tmp.Name()}
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), io.LimitReader(r, maxSize+1))
+	if err == nil && size > maxSize {
+		err = ErrTooLarge
+	}
+	if err == nil {
+		err = tmp.Close()
+	}
+	if err != nil {
+		p.Discard() // nolint: errcheck
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	p.Hash = hex.EncodeToString(h.Sum(nil))
+	p.Size = size
+	p.path, _ = s.path(p.Hash)
+
+	return p, nil
+}
+
+// Commit stores the content under its hash. Committing over a stored copy
+// replaces it with the same content.
+func (p *Pending) Commit() error {
+	const op = "storage.blobs.Commit"
+
+	if err := os.MkdirAll(filepath.Dir(p.path), 0o750); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := os.Rename(p.tmp, p.path); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	p.tmp = ""
+
+	return nil
+}
+
+// Discard removes the temporary file of content not committed. It does
+// nothing after Commit.
+func (p *Pending) Discard() error {
+	const op = "storage.blobs.Discard"
+
+	if p.tmp == "" {
+		return nil
+	}
+
+	if err := os.Remove(p.tmp); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	p.tmp = ""
+
+	return nil
+}
+
+// Open returns the content stored under the hash.
+func (s *Store) Open(hash string) (io.ReadCloser, error) {
+	const op = "storage.blobs.Open"
+
+	path, err := s.path(hash)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s: %w", op, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return f, nil
+}
+
+// Remove deletes the content stored under the hash, if any.
+func (s *Store) Remove(hash string) error {
+	const op = "storage.blobs.Remove"
+
+	path, err := s.path(hash)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package blobs_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kochnevns/finances-backend/internal/storage/blobs"
+)
+
+// files returns the names of the entries of dir and its subdirectories.
+func files(t *testing.T, dir string) []string {
+	t.Helper()
+
+	var names []string
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			names = append(names, e.Name())
+			continue
+		}
+
+		sub, err := os.ReadDir(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range sub {
+			names = append(names, e.Name()+"/"+s.Name())
+		}
+	}
+
+	return names
+}
+
+func TestStage(t *testing.T) {
+	const content = "квитанция"
+	sum := sha256.Sum256([]byte(content))
+	hash := hex.EncodeToString(sum[:])
+
+	for _, tc := range []struct {
+		name    string
+		maxSize int64
+		commit  bool
+		err     error
+		stored  []string
+	}{
+		{name: "commit", maxSize: int64(len(content)), commit: true, stored: []string{hash[:2] + "/" + hash}},
+		{name: "discard", maxSize: int64(len(content))},
+		{name: "oversize", maxSize: int64(len(content)) - 1, err: blobs.ErrTooLarge},
+	} {
+		dir := t.TempDir()
+		s, err := blobs.New(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p, err := s.Stage(strings.NewReader(content), tc.maxSize)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s: stage: %v, want %v", tc.name, err, tc.err)
+			continue
+		}
+
+		if err == nil {
+			if p.Hash != hash || p.Size != int64(len(content)) {
+				t.Errorf("%s: staged %s of %d bytes", tc.name, p.Hash, p.Size)
+			}
+
+			if tc.commit {
+				err = p.Commit()
+			} else {
+				err = p.Discard()
+			}
+			if err != nil {
+				t.Errorf("%s: %v", tc.name, err)
+			}
+
+			// Discarding after either is a no-op.
+			if err := p.Discard(); err != nil {
+				t.Errorf("%s: discard again: %v", tc.name, err)
+			}
+		}
+
+		got := files(t, dir)
+		if strings.Join(got, ",") != strings.Join(tc.stored, ",") {
+			t.Errorf("%s: files %q, want %q", tc.name, got, tc.stored)
+		}
+	}
+}
+
+func TestOpenAndRemove(t *testing.T) {
+	s, err := blobs.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, content := range []string{"чек", "чек"} {
+		p, err := s.Stage(strings.NewReader(content), 1<<10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Commit(); err != nil {
+			t.Fatalf("commit over a stored copy: %v", err)
+		}
+	}
+
+	sum := sha256.Sum256([]byte("чек"))
+	hash := hex.EncodeToString(sum[:])
+
+	f, err := s.Open(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(f)
+	f.Close() // nolint: errcheck
+	if err != nil || string(content) != "чек" {
+		t.Errorf("content %q, %v", content, err)
+	}
+
+	if err := s.Remove(hash); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Remove(hash); err != nil {
+		t.Errorf("removing again: %v", err)
+	}
+
+	for name, h := range map[string]string{
+		"removed":  hash,
+		"short":    "abc",
+		"not hex":  strings.Repeat("z", 64),
+		"relative": "../" + hash[3:],
+	} {
+		if _, err := s.Open(h); !errors.Is(err, blobs.ErrNotFound) {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
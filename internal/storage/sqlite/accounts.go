@@ -0,0 +1,354 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kochnevns/finances-backend/internal/models"
+	"github.com/kochnevns/finances-backend/internal/storage"
+)
+
+// expenseAccount is the name of the account of the expense or income
+// aliased e, empty when it has none.
+const expenseAccount = `COALESCE((SELECT a.name FROM Accounts a WHERE a.id = e.account_id), '')`
+
+// movements has a row per change of an account balance, in the currency
+// of the account: live expenses paid from it, incomes paid to it and
+// transfers both ways. kind is one of the models.Item kinds. Items dated
+// before the account was opened are in its opening balance already.
+const movements = `(
+	SELECT l.* FROM (
+		SELECT 'expense' AS kind, id, account_id, date, COALESCE(description, '') AS description, -amount AS change, cleared
+		FROM Expenses WHERE account_id IS NOT NULL AND deleted_at IS NULL
+		UNION ALL
+		SELECT 'income', id, account_id, date, description, amount, cleared FROM Incomes WHERE account_id IS NOT NULL
+		UNION ALL
+		SELECT 'transfer', id, from_account_id, date, description, -amount, cleared FROM Transfers
+		UNION ALL
+		SELECT 'transfer', id, to_account_id, date, description, to_amount, cleared FROM Transfers
+	) l JOIN Accounts o ON o.id = l.account_id
+	WHERE date(l.date) >= o.opened_on
+) m`
+
+const accountColumns = `a.id, a.user_id, a.name, a.currency, a.opening_balance, a.opened_on,
+	a.opening_balance + COALESCE((SELECT sum(m.change) FROM ` + movements + ` WHERE m.account_id = a.id), 0)`
+
+// accountID resolves the account name to its ID, nil for no name.
+func (s *Storage) accountID(ctx context.Context, userID int64, name string) (any, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	var id int64
+
+	err := s.db.QueryRowContext(ctx,
+		"SELECT id FROM Accounts WHERE user_id = ? AND name = ?", userID, name,
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrAccountNotFound
+	}
+
+	return id, err
+}
+
+func (s *Storage) CreateAccount(ctx context.Context, account models.Account) (int64, error) {
+	const op = "storage.sqlite.CreateAccount"
+
+	res, err := s.db.ExecContext(ctx,
+		"INSERT INTO Accounts (user_id, name, currency, opening_balance, opened_on) VALUES (?, ?, ?, ?, ?)",
+		account.UserID, account.Name, account.Currency, account.OpeningBalance, account.OpenedOn,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrAccountExists)
+		}
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// UpdateAccount overwrites name, opening balance and date of the account. The
+// currency of an account does not change.
+func (s *Storage) UpdateAccount(ctx context.Context, account models.Account) error {
+	const op = "storage.sqlite.UpdateAccount"
+
+	res, err := s.db.ExecContext(ctx,
+		"UPDATE Accounts SET name = ?, opening_balance = ?, opened_on = ? WHERE id = ? AND user_id = ?",
+		account.Name, account.OpeningBalance, account.OpenedOn, account.ID, account.UserID,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s: %w", op, storage.ErrAccountExists)
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return expectAffected(op, res, storage.ErrAccountNotFound)
+}
+
+// Account returns the account with the name, with its current balance.
+func (s *Storage) Account(ctx context.Context, userID int64, name string) (models.Account, error) {
+	const op = "storage.sqlite.Account"
+
+	account, err := scanAccount(s.db.QueryRowContext(ctx,
+		"SELECT "+accountColumns+" FROM Accounts a WHERE a.user_id = ? AND a.name = ?", userID, name,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Account{}, fmt.Errorf("%s: %w", op, storage.ErrAccountNotFound)
+		}
+
+		return models.Account{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return account, nil
+}
+
+func (s *Storage) GetAccount(ctx context.Context, userID int64, id int64) (models.Account, error) {
+	const op = "storage.sqlite.GetAccount"
+
+	account, err := scanAccount(s.db.QueryRowContext(ctx,
+		"SELECT "+accountColumns+" FROM Accounts a WHERE a.id = ? AND a.user_id = ?", id, userID,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Account{}, fmt.Errorf("%s: %w", op, storage.ErrAccountNotFound)
+		}
+
+		return models.Account{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return account, nil
+}
+
+// ListAccounts returns the accounts of the user with their current
+// balances, ordered by name.
+func (s *Storage) ListAccounts(ctx context.Context, userID int64) ([]models.Account, error) {
+	const op = "storage.sqlite.ListAccounts"
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+accountColumns+" FROM Accounts a WHERE a.user_id = ? ORDER BY a.name", userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close() // nolint: errcheck
+
+	var accounts []models.Account
+	for rows.Next() {
+		account, err := scanAccount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		accounts = append(accounts, account)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return accounts, nil
+}
+
+func scanAccount(row scanner) (models.Account, error) {
+	var a models.Account
+	err := row.Scan(&a.ID, &a.UserID, &a.Name, &a.Currency, &a.OpeningBalance, &a.OpenedOn, &a.Balance)
+
+	return a, err
+}
+
+// DeleteAccount deletes the account with its reconciliations unless
+// anything else, deleted expenses included, refers to it. Goals kept in
+// it are unlinked.
+func (s *Storage) DeleteAccount(ctx context.Context, userID int64, id int64) error {
+	const op = "storage.sqlite.DeleteAccount"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	var used bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM Expenses WHERE account_id = ?1)
+			OR EXISTS(SELECT 1 FROM Incomes WHERE account_id = ?1)
+			OR EXISTS(SELECT 1 FROM Transfers WHERE from_account_id = ?1 OR to_account_id = ?1)`,
+		id,
+	).Scan(&used)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM Accounts WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := expectAffected(op, res, storage.ErrAccountNotFound); err != nil {
+		return err
+	}
+
+	if used {
+		return fmt.Errorf("%s: %w", op, storage.ErrAccountInUse)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM Reconciliations WHERE account_id = ?", id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE Goals SET account_id = NULL WHERE account_id = ?", id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SaveTransfer inserts the transfer between the accounts named in it and
+// returns its ID.
+func (s *Storage) SaveTransfer(ctx context.Context, transfer models.Transfer) (int64, error) {
+	const op = "storage.sqlite.SaveTransfer"
+
+	fromID, err := s.accountID(ctx, transfer.UserID, transfer.From)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	toID, err := s.accountID(ctx, transfer.UserID, transfer.To)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO Transfers (user_id, date, from_account_id, to_account_id, amount, to_amount, description)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		transfer.UserID, transfer.Date, fromID, toID, transfer.Amount, transfer.ToAmount, transfer.Description,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (s *Storage) DeleteTransfer(ctx context.Context, userID int64, id int64) error {
+	const op = "storage.sqlite.DeleteTransfer"
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM Transfers WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return expectAffected(op, res, storage.ErrTransferNotFound)
+}
+
+// ListTransfers returns the transfers dated within [from, to], latest first.
+func (s *Storage) ListTransfers(ctx context.Context, userID int64, from, to time.Time) ([]models.Transfer, error) {
+	const op = "storage.sqlite.ListTransfers"
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT t.id, t.user_id, date(t.date), f.name, d.name, t.amount, t.to_amount, t.description
+		FROM Transfers t
+		JOIN Accounts f ON f.id = t.from_account_id
+		JOIN Accounts d ON d.id = t.to_account_id
+		WHERE t.user_id = ? AND date(t.date) BETWEEN ? AND ?
+		ORDER BY t.date DESC, t.id DESC`,
+		userID, from.Format(time.DateOnly), to.Format(time.DateOnly),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close() // nolint: errcheck
+
+	var transfers []models.Transfer
+	for rows.Next() {
+		var t models.Transfer
+		err := rows.Scan(&t.ID, &t.UserID, &t.Date, &t.From, &t.To, &t.Amount, &t.ToAmount, &t.Description)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		transfers = append(transfers, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return transfers, nil
+}
+
+// AccountHistory returns the balance of the account at the end of each day
+// within [from, to] it changed on. The balance carries everything before
+// from, starting with the opening balance.
+func (s *Storage) AccountHistory(ctx context.Context, userID int64, accountID int64, from, to time.Time) ([]models.BalancePoint, error) {
+	const op = "storage.sqlite.AccountHistory"
+
+	account, err := s.GetAccount(ctx, userID, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var before int64
+	err = s.db.QueryRowContext(ctx,
+		"SELECT COALESCE(sum(m.change), 0) FROM "+movements+" WHERE m.account_id = ? AND date(m.date) < ?",
+		accountID, from.Format(time.DateOnly),
+	).Scan(&before)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	balance := account.OpeningBalance + before
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT date(m.date), sum(m.change)
+		FROM `+movements+`
+		WHERE m.account_id = ? AND date(m.date) BETWEEN ? AND ?
+		GROUP BY date(m.date)
+		ORDER BY date(m.date)`,
+		accountID, from.Format(time.DateOnly), to.Format(time.DateOnly),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close() // nolint: errcheck
+
+	var points []models.BalancePoint
+	for rows.Next() {
+		var p models.BalancePoint
+		if err := rows.Scan(&p.Date, &p.Change); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		balance += p.Change
+		p.Balance = balance
+		points = append(points, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return points, nil
+}
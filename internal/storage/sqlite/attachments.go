@@ -0,0 +1,225 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kochnevns/finances-backend/internal/models"
+	"github.com/kochnevns/finances-backend/internal/storage"
+)
+
+const attachmentColumns = "a.id, a.user_id, a.expense_id, a.name, a.content_type, a.size, a.hash, a.created_at"
+
+func scanAttachment(row scanner) (models.Attachment, error) {
+	var a models.Attachment
+	err := row.Scan(&a.ID, &a.UserID, &a.ExpenseID, &a.Name, &a.ContentType, &a.Size, &a.Hash, &a.CreatedAt)
+
+	return a, err
+}
+
+// SaveAttachment records a file attached to the live expense.
+func (s *Storage) SaveAttachment(ctx context.Context, a models.Attachment) (int64, error) {
+	const op = "storage.sqlite.SaveAttachment"
+
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO Attachments (user_id, expense_id, name, content_type, size, hash, created_at)
+		SELECT ?, id, ?, ?, ?, ?, ? FROM Expenses WHERE id = ? AND user_id = ? AND deleted_at IS NULL`,
+		a.UserID, a.Name, a.ContentType, a.Size, a.Hash, time.Now().UTC().Format(time.RFC3339), a.ExpenseID, a.UserID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := expectAffected(op, res, storage.ErrExpenseNotFound); err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// GetAttachment returns the attachment, also of an expense in the trash.
+func (s *Storage) GetAttachment(ctx context.Context, userID int64, id int64) (models.Attachment, error) {
+	const op = "storage.sqlite.GetAttachment"
+
+	a, err := scanAttachment(s.db.QueryRowContext(ctx,
+		"SELECT "+attachmentColumns+" FROM Attachments a WHERE a.id = ? AND a.user_id = ?", id, userID,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Attachment{}, fmt.Errorf("%s: %w", op, storage.ErrAttachmentNotFound)
+		}
+
+		return models.Attachment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return a, nil
+}
+
+// ListAttachments returns the attachments of the expense in the order they
+// were added. It fails with ErrExpenseNotFound for expenses of others.
+func (s *Storage) ListAttachments(ctx context.Context, userID int64, expenseID int64) ([]models.Attachment, error) {
+	const op = "storage.sqlite.ListAttachments"
+
+	var exists bool
+	err := s.db.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM Expenses WHERE id = ? AND user_id = ?)", expenseID, userID,
+	).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrExpenseNotFound)
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+attachmentColumns+" FROM Attachments a WHERE a.expense_id = ? AND a.user_id = ? ORDER BY a.id",
+		expenseID, userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close() // nolint: errcheck
+
+	var list []models.Attachment
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		list = append(list, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return list, nil
+}
+
+// DeleteAttachment removes the attachment and returns its hash when no
+// other attachment has the same content, so that the file can go too.
+func (s *Storage) DeleteAttachment(ctx context.Context, userID int64, id int64) (string, error) {
+	const op = "storage.sqlite.DeleteAttachment"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	var hash string
+	err = tx.QueryRowContext(ctx, "SELECT hash FROM Attachments WHERE id = ? AND user_id = ?", id, userID).Scan(&hash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("%s: %w", op, storage.ErrAttachmentNotFound)
+		}
+
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM Attachments WHERE id = ?", id); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	unused, err := unusedHashes(ctx, tx, []string{hash})
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(unused) == 0 {
+		return "", nil
+	}
+
+	return unused[0], nil
+}
+
+// PurgeOrphanAttachments removes the attachments of expenses purged from
+// the trash and returns the hashes of the files no attachment has anymore.
+func (s *Storage) PurgeOrphanAttachments(ctx context.Context) ([]string, error) {
+	const op = "storage.sqlite.PurgeOrphanAttachments"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	const orphans = "NOT EXISTS (SELECT 1 FROM Expenses e WHERE e.id = a.expense_id)"
+
+	rows, err := tx.QueryContext(ctx, "SELECT DISTINCT a.hash FROM Attachments a WHERE "+orphans)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			rows.Close() // nolint: errcheck
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		hashes = append(hashes, hash)
+	}
+	rows.Close() // nolint: errcheck
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM Attachments AS a WHERE "+orphans); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	unused, err := unusedHashes(ctx, tx, hashes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return unused, nil
+}
+
+// BlobUsed tells whether an attachment has the content with the hash.
+func (s *Storage) BlobUsed(ctx context.Context, hash string) (bool, error) {
+	const op = "storage.sqlite.BlobUsed"
+
+	var used bool
+	err := s.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM Attachments WHERE hash = ?)", hash).Scan(&used)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return used, nil
+}
+
+// unusedHashes returns the hashes no attachment has.
+func unusedHashes(ctx context.Context, tx *sql.Tx, hashes []string) ([]string, error) {
+	var unused []string
+	for _, hash := range hashes {
+		var used bool
+		err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM Attachments WHERE hash = ?)", hash).Scan(&used)
+		if err != nil {
+			return nil, err
+		}
+		if !used {
+			unused = append(unused, hash)
+		}
+	}
+
+	return unused, nil
+}
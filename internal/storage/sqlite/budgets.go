@@ -0,0 +1,84 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kochnevns/finances-backend/internal/models"
+	"github.com/kochnevns/finances-backend/internal/storage"
+)
+
+// SetBudget creates or replaces the budget of the category for the month.
+func (s *Storage) SetBudget(ctx context.Context, budget models.Budget) error {
+	const op = "storage.sqlite.SetBudget"
+
+	if _, err := s.GetCategoryById(ctx, budget.UserID, budget.CategoryID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO Budgets (user_id, category_id, month, amount, rolls_forward) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, category_id, month) DO UPDATE SET amount = excluded.amount, rolls_forward = excluded.rolls_forward`,
+		budget.UserID, budget.CategoryID, budget.Month, budget.Amount, budget.RollsForward,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteBudget removes the budget set for the category in the month.
+func (s *Storage) DeleteBudget(ctx context.Context, userID int64, categoryID int64, month string) error {
+	const op = "storage.sqlite.DeleteBudget"
+
+	res, err := s.db.ExecContext(ctx,
+		"DELETE FROM Budgets WHERE user_id = ? AND category_id = ? AND month = ?", userID, categoryID, month,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return expectAffected(op, res, storage.ErrBudgetNotFound)
+}
+
+// EffectiveBudgets returns the budget of each active category that applies
+// to the month: the one set for the month itself, or else the latest earlier
+// one that rolls forward. Month is the month the budget was set for.
+func (s *Storage) EffectiveBudgets(ctx context.Context, userID int64, month string) ([]models.Budget, error) {
+	const op = "storage.sqlite.EffectiveBudgets"
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT b.user_id, b.category_id, c.name, COALESCE(c.color, ''), b.month, b.amount, b.rolls_forward
+		FROM Budgets b JOIN Categories c ON b.category_id = c.id
+		WHERE b.user_id = ? AND c.archived_at IS NULL AND (b.month = ? OR (b.rolls_forward AND b.month < ?))
+		ORDER BY b.category_id, b.month = ? DESC, b.month DESC`,
+		userID, month, month, month,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close() // nolint: errcheck
+
+	var budgets []models.Budget
+	for rows.Next() {
+		var b models.Budget
+		err := rows.Scan(&b.UserID, &b.CategoryID, &b.Category, &b.Color, &b.Month, &b.Amount, &b.RollsForward)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		// Rows of a category come best first.
+		if len(budgets) > 0 && budgets[len(budgets)-1].CategoryID == b.CategoryID {
+			continue
+		}
+		budgets = append(budgets, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return budgets, nil
+}
@@ -0,0 +1,209 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/kochnevns/finances-backend/internal/models"
+	"github.com/kochnevns/finances-backend/internal/storage"
+)
+
+func (s *Storage) CreateCategory(ctx context.Context, category models.Category) (int64, error) {
+	const op = "storage.sqlite.CreateCategory"
+
+	res, err := s.db.ExecContext(ctx,
+		"INSERT INTO Categories(user_id, name, icon, color) VALUES(?, ?, NULLIF(?, ''), NULLIF(?, ''))",
+		category.UserID, category.Name, category.ImageURL, category.Color,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrCategoryExists)
+		}
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// UpdateCategory overwrites name, icon and color of the category.
+func (s *Storage) UpdateCategory(ctx context.Context, category models.Category) error {
+	const op = "storage.sqlite.UpdateCategory"
+
+	res, err := s.db.ExecContext(ctx,
+		"UPDATE Categories SET name = ?, icon = NULLIF(?, ''), color = NULLIF(?, '') WHERE id = ? AND user_id = ?",
+		category.Name, category.ImageURL, category.Color, category.ID, category.UserID,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s: %w", op, storage.ErrCategoryExists)
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return expectAffected(op, res, storage.ErrCategoryNotFound)
+}
+
+// SetCategoryArchived hides the category from the categories list (or brings
+// it back). Expenses keep pointing at archived categories.
+func (s *Storage) SetCategoryArchived(ctx context.Context, userID int64, id int64, archived bool, at time.Time) error {
+	const op = "storage.sqlite.SetCategoryArchived"
+
+	var archivedAt any
+	if archived {
+		archivedAt = at.UTC().Format(time.RFC3339)
+	}
+
+	res, err := s.db.ExecContext(ctx,
+		"UPDATE Categories SET archived_at = ? WHERE id = ? AND user_id = ?",
+		archivedAt, id, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return expectAffected(op, res, storage.ErrCategoryNotFound)
+}
+
+// MergeCategories reassigns all expenses, including the ones in the trash,
+// line items of split expenses, budgets, recurring templates and
+// categorization rules from one category to another and removes the source
+// category. Where both have a budget for the same month the target keeps its own.
+func (s *Storage) MergeCategories(ctx context.Context, userID int64, fromID, toID int64) (err error) {
+	const op = "storage.sqlite.MergeCategories"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	for _, id := range []int64{fromID, toID} {
+		var exists bool
+		err = tx.QueryRowContext(ctx,
+			"SELECT EXISTS(SELECT 1 FROM Categories WHERE id = ? AND user_id = ?)", id, userID,
+		).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if !exists {
+			return fmt.Errorf("%s: %w", op, storage.ErrCategoryNotFound)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE Expenses SET category_id = ? WHERE category_id = ? AND user_id = ?", toID, fromID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE ExpenseSplits SET category_id = ?
+		WHERE category_id = ? AND expense_id IN (SELECT id FROM Expenses WHERE user_id = ?)`,
+		toID, fromID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Budgets move too, except for months the target has a budget of its own.
+	_, err = tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO Budgets (user_id, category_id, month, amount, rolls_forward)
+		SELECT user_id, ?, month, amount, rolls_forward FROM Budgets WHERE category_id = ? AND user_id = ?`,
+		toID, fromID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM Budgets WHERE category_id = ? AND user_id = ?", fromID, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, table := range []string{"RecurringExpenses", "CategorizationRules"} {
+		_, err = tx.ExecContext(ctx,
+			"UPDATE "+table+" SET category_id = ? WHERE category_id = ? AND user_id = ?", toID, fromID, userID,
+		)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM Categories WHERE id = ? AND user_id = ?", fromID, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// rowQuerier is *sql.DB or *sql.Tx.
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// writableCategory returns the ID of the category named for a write.
+// Archived categories take no new records: a write names one only for a
+// record already in it, given in current, so that older expenses, line
+// items, recurring templates and categorization rules keep their category
+// when edited.
+func writableCategory(ctx context.Context, q rowQuerier, userID int64, name string, current ...int64) (int64, error) {
+	var id int64
+	var archived bool
+	err := q.QueryRowContext(ctx,
+		"SELECT id, archived_at IS NOT NULL FROM Categories WHERE name = ? AND user_id = ?", name, userID,
+	).Scan(&id, &archived)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, storage.ErrCategoryNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if archived && !slices.Contains(current, id) {
+		return 0, storage.ErrCategoryArchived
+	}
+
+	return id, nil
+}
+
+// recordCategory returns the category of the user's record in table,
+// zero when there is no such record.
+func recordCategory(ctx context.Context, q rowQuerier, table string, userID int64, id int64) (int64, error) {
+	var categoryID int64
+	err := q.QueryRowContext(ctx,
+		"SELECT category_id FROM "+table+" WHERE id = ? AND user_id = ?", id, userID,
+	).Scan(&categoryID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+
+	return categoryID, err
+}
+
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+
+	return errors.As(err, &sqliteErr) && errors.Is(sqliteErr.ExtendedCode, sqlite3.ErrConstraintUnique)
+}
@@ -0,0 +1,229 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/kochnevns/finances-backend/internal/models"
+	"github.com/kochnevns/finances-backend/internal/storage"
+)
+
+const goalColumns = `g.id, g.user_id, g.name, g.target, g.currency, COALESCE(g.deadline, ''),
+	COALESCE((SELECT a.name FROM Accounts a WHERE a.id = g.account_id), ''), g.created_on,
+	COALESCE((SELECT sum(c.amount) FROM GoalContributions c WHERE c.goal_id = g.id), 0)`
+
+func (s *Storage) CreateGoal(ctx context.Context, goal models.Goal) (int64, error) {
+	const op = "storage.sqlite.CreateGoal"
+
+	accountID, err := s.accountID(ctx, goal.UserID, goal.Account)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO Goals (user_id, name, target, currency, deadline, account_id, created_on)
+		VALUES (?, ?, ?, ?, NULLIF(?, ''), ?, ?)`,
+		goal.UserID, goal.Name, goal.Target, goal.Currency, goal.Deadline, accountID, goal.CreatedOn,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrGoalExists)
+		}
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// UpdateGoal overwrites name, target, deadline and account of the goal.
+func (s *Storage) UpdateGoal(ctx context.Context, goal models.Goal) error {
+	const op = "storage.sqlite.UpdateGoal"
+
+	accountID, err := s.accountID(ctx, goal.UserID, goal.Account)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE Goals SET name = ?, target = ?, deadline = NULLIF(?, ''), account_id = ?
+		WHERE id = ? AND user_id = ?`,
+		goal.Name, goal.Target, goal.Deadline, accountID, goal.ID, goal.UserID,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s: %w", op, storage.ErrGoalExists)
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return expectAffected(op, res, storage.ErrGoalNotFound)
+}
+
+func (s *Storage) GetGoal(ctx context.Context, userID int64, id int64) (models.Goal, error) {
+	const op = "storage.sqlite.GetGoal"
+
+	goal, err := scanGoal(s.db.QueryRowContext(ctx,
+		"SELECT "+goalColumns+" FROM Goals g WHERE g.id = ? AND g.user_id = ?", id, userID,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Goal{}, fmt.Errorf("%s: %w", op, storage.ErrGoalNotFound)
+		}
+
+		return models.Goal{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return goal, nil
+}
+
+// ListGoals returns the goals of the user, the nearest deadline first and
+// those without one last.
+func (s *Storage) ListGoals(ctx context.Context, userID int64) ([]models.Goal, error) {
+	const op = "storage.sqlite.ListGoals"
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+goalColumns+" FROM Goals g WHERE g.user_id = ? ORDER BY g.deadline IS NULL, g.deadline, g.name",
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close() // nolint: errcheck
+
+	var goals []models.Goal
+	for rows.Next() {
+		goal, err := scanGoal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		goals = append(goals, goal)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return goals, nil
+}
+
+func scanGoal(row scanner) (models.Goal, error) {
+	var g models.Goal
+	err := row.Scan(&g.ID, &g.UserID, &g.Name, &g.Target, &g.Currency, &g.Deadline, &g.Account, &g.CreatedOn, &g.Saved)
+
+	return g, err
+}
+
+// DeleteGoal deletes the goal with its contributions.
+func (s *Storage) DeleteGoal(ctx context.Context, userID int64, id int64) error {
+	const op = "storage.sqlite.DeleteGoal"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM Goals WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := expectAffected(op, res, storage.ErrGoalNotFound); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM GoalContributions WHERE goal_id = ?", id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SaveContribution records the contribution to the user's goal and returns
+// its ID.
+func (s *Storage) SaveContribution(ctx context.Context, userID int64, c models.Contribution) (int64, error) {
+	const op = "storage.sqlite.SaveContribution"
+
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO GoalContributions (goal_id, date, amount, note)
+		SELECT id, ?, ?, ? FROM Goals WHERE id = ? AND user_id = ?`,
+		c.Date, c.Amount, c.Note, c.GoalID, userID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := expectAffected(op, res, storage.ErrGoalNotFound); err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (s *Storage) DeleteContribution(ctx context.Context, userID int64, id int64) error {
+	const op = "storage.sqlite.DeleteContribution"
+
+	res, err := s.db.ExecContext(ctx, `
+		DELETE FROM GoalContributions
+		WHERE id = ? AND goal_id IN (SELECT id FROM Goals WHERE user_id = ?)`,
+		id, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return expectAffected(op, res, storage.ErrContributionNotFound)
+}
+
+// ListContributions returns the contributions to the user's goal, the
+// oldest first.
+func (s *Storage) ListContributions(ctx context.Context, userID int64, goalID int64) ([]models.Contribution, error) {
+	const op = "storage.sqlite.ListContributions"
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT c.id, c.goal_id, date(c.date), c.amount, c.note
+		FROM GoalContributions c JOIN Goals g ON g.id = c.goal_id
+		WHERE c.goal_id = ? AND g.user_id = ?
+		ORDER BY date(c.date), c.id`,
+		goalID, userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close() // nolint: errcheck
+
+	var contributions []models.Contribution
+	for rows.Next() {
+		var c models.Contribution
+		if err := rows.Scan(&c.ID, &c.GoalID, &c.Date, &c.Amount, &c.Note); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		contributions = append(contributions, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return contributions, nil
+}
@@ -0,0 +1,214 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/kochnevns/finances-backend/internal/lib/statement"
+	"github.com/kochnevns/finances-backend/internal/models"
+	"github.com/kochnevns/finances-backend/internal/storage"
+)
+
+// SaveImportProfile creates or replaces the profile with the same name.
+func (s *Storage) SaveImportProfile(ctx context.Context, userID int64, profile models.ImportProfile) error {
+	const op = "storage.sqlite.SaveImportProfile"
+
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO ImportProfiles (user_id, name, profile) VALUES (?, ?, ?)
+		ON CONFLICT (user_id, name) DO UPDATE SET profile = excluded.profile`,
+		userID, profile.Name, string(data),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) ImportProfile(ctx context.Context, userID int64, name string) (models.ImportProfile, error) {
+	const op = "storage.sqlite.ImportProfile"
+
+	var data string
+
+	err := s.db.QueryRowContext(ctx,
+		"SELECT profile FROM ImportProfiles WHERE user_id = ? AND name = ?", userID, name,
+	).Scan(&data)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ImportProfile{}, fmt.Errorf("%s: %w", op, storage.ErrImportProfileNotFound)
+		}
+
+		return models.ImportProfile{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var profile models.ImportProfile
+	if err := json.Unmarshal([]byte(data), &profile); err != nil {
+		return models.ImportProfile{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return profile, nil
+}
+
+// ListImportProfiles returns the profiles of the user ordered by name.
+func (s *Storage) ListImportProfiles(ctx context.Context, userID int64) ([]models.ImportProfile, error) {
+	const op = "storage.sqlite.ListImportProfiles"
+
+	rows, err := s.db.QueryContext(ctx, "SELECT profile FROM ImportProfiles WHERE user_id = ? ORDER BY name", userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close() // nolint: errcheck
+
+	var profiles []models.ImportProfile
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		var profile models.ImportProfile
+		if err := json.Unmarshal([]byte(data), &profile); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		profiles = append(profiles, profile)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return profiles, nil
+}
+
+// ImportExpenses saves the new rows of a statement as expenses of the user,
+// unless dryRun is set, and returns the rows with their outcome. A row is a
+// duplicate when an expense with the same date, amount and description is
+// already recorded; each recorded expense matches one row at most. Rows
+// naming an unknown or archived category are invalid.
+func (s *Storage) ImportExpenses(ctx context.Context, userID int64, rows []models.ImportRow, dryRun bool) ([]models.ImportRow, error) {
+	const op = "storage.sqlite.ImportExpenses"
+
+	rows = slices.Clone(rows)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	recorded, err := recordedHashes(ctx, tx, userID, rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx,
+		"INSERT INTO Expenses (user_id, date, description, amount, currency, category_id) VALUES (?, ?, ?, ?, ?, ?)",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close() // nolint: errcheck
+
+	for i := range rows {
+		row := &rows[i]
+		if row.Status != models.ImportNew {
+			continue
+		}
+
+		id, err := writableCategory(ctx, tx, userID, row.Expense.Category)
+		switch {
+		case errors.Is(err, storage.ErrCategoryNotFound):
+			row.Status = models.ImportInvalid
+			row.Reason = fmt.Sprintf("unknown category %q", row.Expense.Category)
+			continue
+		case errors.Is(err, storage.ErrCategoryArchived):
+			row.Status = models.ImportInvalid
+			row.Reason = fmt.Sprintf("archived category %q", row.Expense.Category)
+			continue
+		case err != nil:
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		row.Expense.UserID = userID
+		row.Expense.CategoryID = id
+
+		if h := statement.Hash(row.Expense); recorded[h] > 0 {
+			recorded[h]--
+			row.Status = models.ImportDuplicate
+			continue
+		}
+
+		if dryRun {
+			continue
+		}
+
+		res, err := stmt.ExecContext(ctx,
+			userID, row.Expense.Date, row.Expense.Description, row.Expense.Amount, row.Expense.Currency, id,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if row.Expense.ID, err = res.LastInsertId(); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rows, nil
+}
+
+// recordedHashes counts the user's expenses per statement.Hash over the
+// dates of the new rows.
+func recordedHashes(ctx context.Context, tx *sql.Tx, userID int64, rows []models.ImportRow) (map[string]int, error) {
+	var from, to string
+	for _, row := range rows {
+		if row.Status != models.ImportNew {
+			continue
+		}
+		if from == "" || row.Expense.Date < from {
+			from = row.Expense.Date
+		}
+		if row.Expense.Date > to {
+			to = row.Expense.Date
+		}
+	}
+
+	counts := map[string]int{}
+	if from == "" {
+		return counts, nil
+	}
+
+	recorded, err := tx.QueryContext(ctx, `
+		SELECT date(date), amount, COALESCE(description, '') FROM Expenses
+		WHERE user_id = ? AND deleted_at IS NULL AND date(date) BETWEEN ? AND ?`,
+		userID, from, to,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer recorded.Close() // nolint: errcheck
+
+	for recorded.Next() {
+		var e models.Expense
+		if err := recorded.Scan(&e.Date, &e.Amount, &e.Description); err != nil {
+			return nil, err
+		}
+		counts[statement.Hash(e)]++
+	}
+
+	return counts, recorded.Err()
+}
@@ -0,0 +1,332 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kochnevns/finances-backend/internal/models"
+	"github.com/kochnevns/finances-backend/internal/storage"
+)
+
+// incomesFrom joins incomes with their sources under the aliases the
+// currency conversion expects.
+const incomesFrom = "Incomes e LEFT JOIN IncomeSources c ON e.source_id = c.id"
+
+const incomeColumns = `e.id, e.user_id, date(e.date), e.description, e.amount, e.currency, e.source_id,
+	COALESCE(c.name, ''), COALESCE(c.color, ''), ` + expenseAccount
+
+func (s *Storage) CreateIncomeSource(ctx context.Context, source models.IncomeSource) (int64, error) {
+	const op = "storage.sqlite.CreateIncomeSource"
+
+	res, err := s.db.ExecContext(ctx,
+		"INSERT INTO IncomeSources (user_id, name, color) VALUES (?, ?, NULLIF(?, ''))",
+		source.UserID, source.Name, source.Color,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrIncomeSourceExists)
+		}
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// ListIncomeSources returns the income sources of the user ordered by name.
+func (s *Storage) ListIncomeSources(ctx context.Context, userID int64) ([]models.IncomeSource, error) {
+	const op = "storage.sqlite.ListIncomeSources"
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, user_id, name, COALESCE(color, '') FROM IncomeSources WHERE user_id = ? ORDER BY name", userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close() // nolint: errcheck
+
+	var sources []models.IncomeSource
+	for rows.Next() {
+		var source models.IncomeSource
+		if err := rows.Scan(&source.ID, &source.UserID, &source.Name, &source.Color); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		sources = append(sources, source)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sources, nil
+}
+
+func (s *Storage) GetIncomeSource(ctx context.Context, userID int64, id int64) (models.IncomeSource, error) {
+	const op = "storage.sqlite.GetIncomeSource"
+
+	var source models.IncomeSource
+
+	err := s.db.QueryRowContext(ctx,
+		"SELECT id, user_id, name, COALESCE(color, '') FROM IncomeSources WHERE id = ? AND user_id = ?", id, userID,
+	).Scan(&source.ID, &source.UserID, &source.Name, &source.Color)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.IncomeSource{}, fmt.Errorf("%s: %w", op, storage.ErrIncomeSourceNotFound)
+		}
+
+		return models.IncomeSource{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return source, nil
+}
+
+// UpdateIncomeSource overwrites name and color of the source. Its incomes
+// follow the rename.
+func (s *Storage) UpdateIncomeSource(ctx context.Context, source models.IncomeSource) error {
+	const op = "storage.sqlite.UpdateIncomeSource"
+
+	res, err := s.db.ExecContext(ctx,
+		"UPDATE IncomeSources SET name = ?, color = NULLIF(?, '') WHERE id = ? AND user_id = ?",
+		source.Name, source.Color, source.ID, source.UserID,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s: %w", op, storage.ErrIncomeSourceExists)
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return expectAffected(op, res, storage.ErrIncomeSourceNotFound)
+}
+
+// DeleteIncomeSource deletes the source unless any income refers to it.
+func (s *Storage) DeleteIncomeSource(ctx context.Context, userID int64, id int64) error {
+	const op = "storage.sqlite.DeleteIncomeSource"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	var used bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM Incomes WHERE source_id = ?)", id).Scan(&used)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM IncomeSources WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := expectAffected(op, res, storage.ErrIncomeSourceNotFound); err != nil {
+		return err
+	}
+
+	if used {
+		return fmt.Errorf("%s: %w", op, storage.ErrIncomeSourceInUse)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) incomeSourceID(ctx context.Context, userID int64, name string) (int64, error) {
+	var id int64
+
+	err := s.db.QueryRowContext(ctx,
+		"SELECT id FROM IncomeSources WHERE user_id = ? AND name = ?", userID, name,
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, storage.ErrIncomeSourceNotFound
+	}
+
+	return id, err
+}
+
+// SaveIncome inserts the income with the source named in it and returns its ID.
+func (s *Storage) SaveIncome(ctx context.Context, income models.Income) (int64, error) {
+	const op = "storage.sqlite.SaveIncome"
+
+	sourceID, err := s.incomeSourceID(ctx, income.UserID, income.Source)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	accountID, err := s.accountID(ctx, income.UserID, income.Account)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := s.db.ExecContext(ctx,
+		"INSERT INTO Incomes (user_id, date, description, amount, currency, source_id, account_id) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		income.UserID, income.Date, income.Description, income.Amount, income.Currency, sourceID, accountID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// UpdateIncome overwrites the income, with the source named in it.
+func (s *Storage) UpdateIncome(ctx context.Context, income models.Income) error {
+	const op = "storage.sqlite.UpdateIncome"
+
+	sourceID, err := s.incomeSourceID(ctx, income.UserID, income.Source)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	accountID, err := s.accountID(ctx, income.UserID, income.Account)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE Incomes SET date = ?, description = ?, amount = ?, currency = ?, source_id = ?, account_id = ?
+		WHERE id = ? AND user_id = ?`,
+		income.Date, income.Description, income.Amount, income.Currency, sourceID, accountID, income.ID, income.UserID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return expectAffected(op, res, storage.ErrIncomeNotFound)
+}
+
+func (s *Storage) GetIncome(ctx context.Context, userID int64, id int64) (models.Income, error) {
+	const op = "storage.sqlite.GetIncome"
+
+	var income models.Income
+
+	err := s.db.QueryRowContext(ctx,
+		"SELECT "+incomeColumns+" FROM "+incomesFrom+" WHERE e.id = ? AND e.user_id = ?", id, userID,
+	).Scan(
+		&income.ID, &income.UserID, &income.Date, &income.Description, &income.Amount, &income.Currency,
+		&income.SourceID, &income.Source, &income.Color, &income.Account,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Income{}, fmt.Errorf("%s: %w", op, storage.ErrIncomeNotFound)
+		}
+
+		return models.Income{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return income, nil
+}
+
+func (s *Storage) DeleteIncome(ctx context.Context, userID int64, id int64) error {
+	const op = "storage.sqlite.DeleteIncome"
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM Incomes WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return expectAffected(op, res, storage.ErrIncomeNotFound)
+}
+
+// incomePeriodWhere selects the user's incomes dated within [from, to].
+func incomePeriodWhere(userID int64, from, to time.Time) where {
+	var w where
+	w.add("e.user_id = ?", userID)
+	w.add("date(e.date) BETWEEN ? AND ?", from.Format(time.DateOnly), to.Format(time.DateOnly))
+
+	return w
+}
+
+// ListIncomes returns the incomes dated within [from, to], latest first.
+func (s *Storage) ListIncomes(ctx context.Context, userID int64, from, to time.Time) ([]models.Income, error) {
+	const op = "storage.sqlite.ListIncomes"
+
+	w := incomePeriodWhere(userID, from, to)
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+incomeColumns+" FROM "+incomesFrom+" WHERE "+w.String()+" ORDER BY e.date DESC, e.id DESC",
+		w.args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close() // nolint: errcheck
+
+	var incomes []models.Income
+	for rows.Next() {
+		var income models.Income
+		err := rows.Scan(
+			&income.ID, &income.UserID, &income.Date, &income.Description, &income.Amount, &income.Currency,
+			&income.SourceID, &income.Source, &income.Color, &income.Account,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		incomes = append(incomes, income)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return incomes, nil
+}
+
+// ListIncomeSourcesReport sums incomes per source for dates in [from, to],
+// converted to the base currency.
+func (s *Storage) ListIncomeSourcesReport(ctx context.Context, userID int64, from, to time.Time, base string) ([]models.CategoryReport, error) {
+	const op = "storage.sqlite.ListIncomeSourcesReport"
+
+	w := incomePeriodWhere(userID, from, to)
+	if err := s.checkRatesIn(ctx, op, incomesFrom, w, base); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT sum(`+inBaseCurrency+`), e.source_id, COALESCE(c.name, ''), COALESCE(c.color, '')
+		FROM `+incomesFrom+`
+		WHERE `+w.String()+`
+		GROUP BY e.source_id`,
+		append([]any{base}, w.args...)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close() // nolint: errcheck
+
+	var sources []models.CategoryReport
+	for rows.Next() {
+		var source models.CategoryReport
+		if err := rows.Scan(&source.Amount, &source.CategoryID, &source.Name, &source.Color); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		sources = append(sources, source)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sources, nil
+}
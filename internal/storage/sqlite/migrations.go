@@ -0,0 +1,218 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Migration is a dbmate-style migration file: <version>_<name>.sql with
+// "-- migrate:up" and "-- migrate:down" sections.
+type Migration struct {
+	Version string
+	Name    string
+	Applied bool
+
+	up   string
+	down string
+}
+
+const createMigrationsTable = `CREATE TABLE IF NOT EXISTS "schema_migrations" (version varchar(128) primary key)`
+
+// Migrate applies pending migrations from fsys in version order and returns
+// the names of the applied ones. Applied versions are tracked in the same
+// schema_migrations table dbmate uses, so both tools can be mixed.
+//
+// seeds are the versions of seed-data migrations. On a database with no
+// migrations applied yet they are recorded as applied without being run, so
+// that a new install starts with an empty ledger.
+func (s *Storage) Migrate(ctx context.Context, fsys fs.FS, seeds []string) ([]string, error) {
+	const op = "storage.sqlite.Migrate"
+
+	migrations, err := s.MigrationsStatus(ctx, fsys)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	fresh := !slices.ContainsFunc(migrations, func(m Migration) bool { return m.Applied })
+
+	var applied []string
+	for _, m := range migrations {
+		if m.Applied {
+			continue
+		}
+
+		up := m.up
+		if fresh && slices.Contains(seeds, m.Version) {
+			up = ""
+		}
+
+		if err := s.runMigration(ctx, up, "INSERT INTO schema_migrations (version) VALUES (?)", m.Version); err != nil {
+			return applied, fmt.Errorf("%s: %s: %w", op, m.Name, err)
+		}
+
+		applied = append(applied, m.Name)
+	}
+
+	return applied, nil
+}
+
+// MigrateDown rolls back the latest applied migration from fsys and returns
+// its name, or an empty string when nothing is applied.
+func (s *Storage) MigrateDown(ctx context.Context, fsys fs.FS) (string, error) {
+	const op = "storage.sqlite.MigrateDown"
+
+	migrations, err := s.MigrationsStatus(ctx, fsys)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if !m.Applied {
+			continue
+		}
+
+		if err := s.runMigration(ctx, m.down, "DELETE FROM schema_migrations WHERE version = ?", m.Version); err != nil {
+			return "", fmt.Errorf("%s: %s: %w", op, m.Name, err)
+		}
+
+		return m.Name, nil
+	}
+
+	return "", nil
+}
+
+// MigrationsStatus lists migrations from fsys in version order, marking the
+// ones already applied to the database.
+func (s *Storage) MigrationsStatus(ctx context.Context, fsys fs.FS) ([]Migration, error) {
+	const op = "storage.sqlite.MigrationsStatus"
+
+	migrations, err := readMigrations(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, createMigrationsTable); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close() // nolint: errcheck
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		applied[version] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := range migrations {
+		migrations[i].Applied = applied[migrations[i].Version]
+	}
+
+	return migrations, nil
+}
+
+// runMigration executes the migration body and records the change in
+// schema_migrations within one transaction.
+func (s *Storage) runMigration(ctx context.Context, body string, record string, version string) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if strings.TrimSpace(body) != "" {
+		if _, err = tx.ExecContext(ctx, body); err != nil {
+			return err
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, record, version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func readMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+
+	migrations := make([]Migration, 0, len(files))
+	for _, file := range files {
+		version, _, ok := strings.Cut(file, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: name must be <version>_<name>.sql", file)
+		}
+
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		up, down, err := splitMigration(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", file, err)
+		}
+
+		migrations = append(migrations, Migration{
+			Version: version,
+			Name:    path.Base(file),
+			up:      up,
+			down:    down,
+		})
+	}
+
+	return migrations, nil
+}
+
+// splitMigration splits a dbmate migration into its up and down sections.
+func splitMigration(content string) (string, string, error) {
+	const (
+		upMarker   = "-- migrate:up"
+		downMarker = "-- migrate:down"
+	)
+
+	_, body, ok := strings.Cut(content, upMarker)
+	if !ok {
+		return "", "", fmt.Errorf("missing %q section", upMarker)
+	}
+
+	up, down, _ := strings.Cut(body, downMarker)
+
+	return skipLine(up), skipLine(down), nil
+}
+
+// skipLine drops the rest of a marker line, which may carry dbmate options.
+func skipLine(s string) string {
+	if _, rest, ok := strings.Cut(s, "\n"); ok {
+		return rest
+	}
+
+	return ""
+}
@@ -0,0 +1,90 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/kochnevns/finances-backend/db"
+	"github.com/kochnevns/finances-backend/internal/storage/sqlite"
+)
+
+// migrations returns the embedded migrations of the versions.
+func migrations(t *testing.T, versions []string) fs.FS {
+	t.Helper()
+
+	res := fstest.MapFS{}
+	for _, v := range versions {
+		files, err := fs.Glob(db.Migrations(), v+"_*.sql")
+		if err != nil || len(files) != 1 {
+			t.Fatalf("migration %s: %v", v, files)
+		}
+
+		content, err := fs.ReadFile(db.Migrations(), files[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		res[files[0]] = &fstest.MapFile{Data: content}
+	}
+
+	return res
+}
+
+func TestMigrate_Seeds(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		// applied are the versions a dbmate deployment applied before.
+		applied  []string
+		expenses bool
+	}{
+		{name: "new install"},
+		{name: "dbmate deployment", applied: []string{"20240424201629", "20240424201734"}, expenses: true},
+	} {
+		path := filepath.Join(t.TempDir(), "expenses.db.sqlite")
+
+		conn, err := sql.Open("sqlite3", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close() // nolint: errcheck
+
+		storage, err := sqlite.New(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = storage.Stop() })
+
+		if len(tc.applied) > 0 {
+			if _, err := storage.Migrate(context.Background(), migrations(t, tc.applied), nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if _, err := storage.Migrate(context.Background(), db.Migrations(), db.Seeds()); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		status, err := storage.MigrationsStatus(context.Background(), db.Migrations())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range status {
+			if !m.Applied {
+				t.Errorf("%s: %s is not applied", tc.name, m.Name)
+			}
+		}
+
+		var expenses, categories int
+		if err := conn.QueryRow("SELECT (SELECT count(*) FROM Expenses), (SELECT count(*) FROM Categories)").Scan(&expenses, &categories); err != nil {
+			t.Fatal(err)
+		}
+		if expenses > 0 != tc.expenses || categories == 0 {
+			t.Errorf("%s: %d expenses, %d categories", tc.name, expenses, categories)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/kochnevns/finances-backend/internal/models"
+	"github.com/kochnevns/finances-backend/internal/storage"
+)
+
+// inBaseCurrency converts e.amount to the base currency, bound as its only
+// argument, at the latest rate known on the expense date. It is NULL when
+// there is no such rate.
+const inBaseCurrency = `(CASE WHEN e.currency IN ('', ?) THEN e.amount ELSE CAST(round(e.amount * (
+	SELECT r.rate FROM ExchangeRates r
+	WHERE r.currency = e.currency AND r.date <= date(e.date)
+	ORDER BY r.date DESC LIMIT 1
+)) AS INTEGER) END)`
+
+// checkRates returns ErrRateNotFound when an expense selected by w cannot
+// be converted to the base currency, so that sums over them are not silently
+// short. w may refer to the expense as e and to its category as c.
+func (s *Storage) checkRates(ctx context.Context, op string, w where, base string) error {
+	return s.checkRatesIn(ctx, op, "Expenses e LEFT JOIN Categories c ON e.category_id = c.id", w, base)
+}
+
+// checkRatesIn is checkRates for the rows of from, which must name the
+// converted table e.
+func (s *Storage) checkRatesIn(ctx context.Context, op string, from string, w where, base string) error {
+	var currency, date string
+
+	err := s.db.QueryRowContext(ctx,
+		"SELECT e.currency, date(e.date) FROM "+from+" WHERE "+
+			w.String()+" AND "+inBaseCurrency+" IS NULL LIMIT 1",
+		append(slices.Clone(w.args), base)...,
+	).Scan(&currency, &date)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return fmt.Errorf("%s: %w: %s on %s", op, storage.ErrRateNotFound, currency, date)
+}
+
+// SaveRates stores the rates, replacing the ones already known for the
+// same currency and date.
+func (s *Storage) SaveRates(ctx context.Context, rates []models.ExchangeRate) error {
+	const op = "storage.sqlite.SaveRates"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	stmt, err := tx.PrepareContext(ctx, "INSERT OR REPLACE INTO ExchangeRates (currency, date, rate) VALUES (?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close() // nolint: errcheck
+
+	for _, r := range rates {
+		if _, err := stmt.ExecContext(ctx, r.Currency, r.Date, r.Rate); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ListRates returns the known rates, of one currency unless it is empty,
+// ordered by currency and date.
+func (s *Storage) ListRates(ctx context.Context, currency string) ([]models.ExchangeRate, error) {
+	const op = "storage.sqlite.ListRates"
+
+	var w where
+	if currency != "" {
+		w.add("currency = ?", currency)
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT currency, date, rate FROM ExchangeRates WHERE "+w.String()+" ORDER BY currency, date",
+		w.args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close() // nolint: errcheck
+
+	var rates []models.ExchangeRate
+	for rows.Next() {
+		var r models.ExchangeRate
+		if err := rows.Scan(&r.Currency, &r.Date, &r.Rate); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		rates = append(rates, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rates, nil
+}
@@ -0,0 +1,233 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kochnevns/finances-backend/internal/models"
+	"github.com/kochnevns/finances-backend/internal/storage"
+)
+
+// reconciledBalance and clearedBalance compute the balances of the account
+// as of the date of the reconciliation r, of all items and of the cleared ones.
+const (
+	reconciledBalance = `(SELECT opening_balance FROM Accounts WHERE id = r.account_id) +
+		COALESCE((SELECT sum(m.change) FROM ` + movements + `
+			WHERE m.account_id = r.account_id AND date(m.date) <= r.date), 0)`
+	clearedBalance = `(SELECT opening_balance FROM Accounts WHERE id = r.account_id) +
+		COALESCE((SELECT sum(m.change) FROM ` + movements + `
+			WHERE m.account_id = r.account_id AND m.cleared AND date(m.date) <= r.date), 0)`
+)
+
+// reconciliationColumns are the columns of the reconciliation r joined with
+// its account a. The balances are the ones kept when it was finished.
+const reconciliationColumns = `r.id, r.user_id, a.name, r.account_id, r.date, r.statement_balance,
+	COALESCE(r.balance, ` + reconciledBalance + `), COALESCE(r.cleared_balance, ` + clearedBalance + `),
+	COALESCE(r.finished_at, '')`
+
+const reconciliationsFrom = "Reconciliations r JOIN Accounts a ON a.id = r.account_id"
+
+// clearedTables are the tables of the ledger item kinds and the errors of
+// items not found in them.
+var clearedTables = map[string]struct {
+	table    string
+	notFound error
+}{
+	models.ItemExpense:  {"Expenses", storage.ErrExpenseNotFound},
+	models.ItemIncome:   {"Incomes", storage.ErrIncomeNotFound},
+	models.ItemTransfer: {"Transfers", storage.ErrTransferNotFound},
+}
+
+// SaveReconciliation starts the reconciliation of the account named in it
+// and returns its ID.
+func (s *Storage) SaveReconciliation(ctx context.Context, r models.Reconciliation) (int64, error) {
+	const op = "storage.sqlite.SaveReconciliation"
+
+	accountID, err := s.accountID(ctx, r.UserID, r.Account)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := s.db.ExecContext(ctx,
+		"INSERT INTO Reconciliations (user_id, account_id, date, statement_balance) VALUES (?, ?, ?, ?)",
+		r.UserID, accountID, r.Date, r.StatementBalance,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (s *Storage) GetReconciliation(ctx context.Context, userID int64, id int64) (models.Reconciliation, error) {
+	const op = "storage.sqlite.GetReconciliation"
+
+	r, err := scanReconciliation(s.db.QueryRowContext(ctx,
+		"SELECT "+reconciliationColumns+" FROM "+reconciliationsFrom+" WHERE r.id = ? AND r.user_id = ?", id, userID,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Reconciliation{}, fmt.Errorf("%s: %w", op, storage.ErrReconciliationNotFound)
+		}
+
+		return models.Reconciliation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return r, nil
+}
+
+// ListReconciliations returns the reconciliations of the user, the latest
+// statement first.
+func (s *Storage) ListReconciliations(ctx context.Context, userID int64) ([]models.Reconciliation, error) {
+	const op = "storage.sqlite.ListReconciliations"
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+reconciliationColumns+" FROM "+reconciliationsFrom+" WHERE r.user_id = ? ORDER BY r.date DESC, r.id DESC",
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close() // nolint: errcheck
+
+	var list []models.Reconciliation
+	for rows.Next() {
+		r, err := scanReconciliation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		list = append(list, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return list, nil
+}
+
+func scanReconciliation(row scanner) (models.Reconciliation, error) {
+	var r models.Reconciliation
+	err := row.Scan(
+		&r.ID, &r.UserID, &r.Account, &r.AccountID, &r.Date, &r.StatementBalance,
+		&r.Balance, &r.ClearedBalance, &r.FinishedAt,
+	)
+
+	return r, err
+}
+
+// FinishReconciliation records when the reconciliation was finished and
+// keeps its balances as they are then, unless it already is finished.
+func (s *Storage) FinishReconciliation(ctx context.Context, userID int64, id int64, at time.Time) error {
+	const op = "storage.sqlite.FinishReconciliation"
+
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE Reconciliations AS r SET
+			finished_at = COALESCE(r.finished_at, ?),
+			balance = CASE WHEN r.finished_at IS NULL THEN `+reconciledBalance+` ELSE r.balance END,
+			cleared_balance = CASE WHEN r.finished_at IS NULL THEN `+clearedBalance+` ELSE r.cleared_balance END
+		WHERE r.id = ? AND r.user_id = ?`,
+		at.UTC().Format(time.RFC3339), id, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return expectAffected(op, res, storage.ErrReconciliationNotFound)
+}
+
+func (s *Storage) DeleteReconciliation(ctx context.Context, userID int64, id int64) error {
+	const op = "storage.sqlite.DeleteReconciliation"
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM Reconciliations WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return expectAffected(op, res, storage.ErrReconciliationNotFound)
+}
+
+// UnclearedItems returns the items of the account dated up to the date
+// (YYYY-MM-DD) that are not cleared yet, the oldest first.
+func (s *Storage) UnclearedItems(ctx context.Context, userID int64, accountID int64, date string) ([]models.LedgerItem, error) {
+	const op = "storage.sqlite.UnclearedItems"
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT m.kind, m.id, date(m.date), m.description, m.change
+		FROM `+movements+`
+		JOIN Accounts a ON a.id = m.account_id
+		WHERE a.id = ? AND a.user_id = ? AND NOT m.cleared AND date(m.date) <= ?
+		ORDER BY date(m.date), m.kind, m.id`,
+		accountID, userID, date,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close() // nolint: errcheck
+
+	var items []models.LedgerItem
+	for rows.Next() {
+		var item models.LedgerItem
+		if err := rows.Scan(&item.Kind, &item.ID, &item.Date, &item.Description, &item.Amount); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return items, nil
+}
+
+// SetCleared marks the items as cleared, or not, all of them or none when
+// one is not found. Deleted expenses are not found.
+func (s *Storage) SetCleared(ctx context.Context, userID int64, items []models.LedgerItem, cleared bool) error {
+	const op = "storage.sqlite.SetCleared"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	for _, item := range items {
+		t, ok := clearedTables[item.Kind]
+		if !ok {
+			return fmt.Errorf("%s: unknown kind %q", op, item.Kind)
+		}
+
+		live := ""
+		if item.Kind == models.ItemExpense {
+			live = " AND deleted_at IS NULL"
+		}
+
+		res, err := tx.ExecContext(ctx,
+			"UPDATE "+t.table+" SET cleared = ? WHERE id = ? AND user_id = ?"+live, cleared, item.ID, userID,
+		)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := expectAffected(op, res, t.notFound); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
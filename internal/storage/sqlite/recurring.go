@@ -0,0 +1,220 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/kochnevns/finances-backend/internal/models"
+	"github.com/kochnevns/finances-backend/internal/storage"
+)
+
+const recurringColumns = `r.id, r.user_id, r.description, r.amount, r.currency, r.category_id,
+	COALESCE(c.name, ''), COALESCE(c.color, ''), r.frequency, r.interval, r.start_date,
+	COALESCE(r.end_date, ''), COALESCE(r.next_date, ''), r.paused`
+
+const recurringFrom = " FROM RecurringExpenses r LEFT JOIN Categories c ON r.category_id = c.id"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRecurring(row scanner) (models.Recurring, error) {
+	var r models.Recurring
+	err := row.Scan(
+		&r.ID, &r.UserID, &r.Description, &r.Amount, &r.Currency, &r.CategoryID,
+		&r.Category, &r.Color, &r.Frequency, &r.Interval, &r.StartDate,
+		&r.EndDate, &r.NextDate, &r.Paused,
+	)
+
+	return r, err
+}
+
+// CreateRecurring stores the template with the category named in it and
+// returns its ID.
+func (s *Storage) CreateRecurring(ctx context.Context, r models.Recurring) (int64, error) {
+	const op = "storage.sqlite.CreateRecurring"
+
+	categoryID, err := writableCategory(ctx, s.db, r.UserID, r.Category)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO RecurringExpenses (user_id, description, amount, currency, category_id, frequency, interval,
+			start_date, end_date, next_date, paused)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), ?)`,
+		r.UserID, r.Description, r.Amount, r.Currency, categoryID, r.Frequency, r.Interval,
+		r.StartDate, r.EndDate, r.NextDate, r.Paused,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (s *Storage) GetRecurring(ctx context.Context, userID int64, id int64) (models.Recurring, error) {
+	const op = "storage.sqlite.GetRecurring"
+
+	r, err := scanRecurring(s.db.QueryRowContext(ctx,
+		"SELECT "+recurringColumns+recurringFrom+" WHERE r.id = ? AND r.user_id = ?", id, userID,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Recurring{}, fmt.Errorf("%s: %w", op, storage.ErrRecurringNotFound)
+		}
+
+		return models.Recurring{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return r, nil
+}
+
+// ListRecurring returns the templates of the user ordered by ID.
+func (s *Storage) ListRecurring(ctx context.Context, userID int64) ([]models.Recurring, error) {
+	const op = "storage.sqlite.ListRecurring"
+
+	return s.queryRecurring(ctx, op, " WHERE r.user_id = ? ORDER BY r.id", userID)
+}
+
+// DueRecurring returns the active templates of all users with an occurrence
+// on or before until that is not materialized yet.
+func (s *Storage) DueRecurring(ctx context.Context, until string) ([]models.Recurring, error) {
+	const op = "storage.sqlite.DueRecurring"
+
+	return s.queryRecurring(ctx, op, " WHERE NOT r.paused AND r.next_date <= ? ORDER BY r.id", until)
+}
+
+func (s *Storage) queryRecurring(ctx context.Context, op string, tail string, args ...any) ([]models.Recurring, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+recurringColumns+recurringFrom+tail, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close() // nolint: errcheck
+
+	var list []models.Recurring
+	for rows.Next() {
+		r, err := scanRecurring(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		list = append(list, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return list, nil
+}
+
+// UpdateRecurring overwrites the template, with the category named in it.
+func (s *Storage) UpdateRecurring(ctx context.Context, r models.Recurring) error {
+	const op = "storage.sqlite.UpdateRecurring"
+
+	current, err := recordCategory(ctx, s.db, "RecurringExpenses", r.UserID, r.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	categoryID, err := writableCategory(ctx, s.db, r.UserID, r.Category, current)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE RecurringExpenses
+		SET description = ?, amount = ?, currency = ?, category_id = ?, frequency = ?, interval = ?,
+			start_date = ?, end_date = NULLIF(?, ''), next_date = NULLIF(?, ''), paused = ?
+		WHERE id = ? AND user_id = ?`,
+		r.Description, r.Amount, r.Currency, categoryID, r.Frequency, r.Interval,
+		r.StartDate, r.EndDate, r.NextDate, r.Paused, r.ID, r.UserID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return expectAffected(op, res, storage.ErrRecurringNotFound)
+}
+
+// DeleteRecurring removes the template. Expenses it has already produced
+// are kept.
+func (s *Storage) DeleteRecurring(ctx context.Context, userID int64, id int64) error {
+	const op = "storage.sqlite.DeleteRecurring"
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM RecurringExpenses WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return expectAffected(op, res, storage.ErrRecurringNotFound)
+}
+
+// MaterializeRecurring saves the occurrences of the template on dates as
+// expenses and moves its next date to next, or clears it when next is empty.
+// Nothing is saved when the next date has moved since r was read, and
+// occurrences saved before are skipped. It returns the number of expenses
+// saved.
+func (s *Storage) MaterializeRecurring(ctx context.Context, r models.Recurring, dates []string, next string) (int64, error) {
+	const op = "storage.sqlite.MaterializeRecurring"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	res, err := tx.ExecContext(ctx,
+		"UPDATE RecurringExpenses SET next_date = NULLIF(?, '') WHERE id = ? AND next_date = ?",
+		next, r.ID, r.NextDate,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	moved, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if moved == 0 {
+		// Another run or an edit got there first.
+		return 0, nil
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT OR IGNORE INTO Expenses (user_id, date, description, amount, currency, category_id, recurring_id, recurring_date)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close() // nolint: errcheck
+
+	var saved int64
+	for _, date := range dates {
+		res, err := stmt.ExecContext(ctx, r.UserID, date, r.Description, r.Amount, r.Currency, r.CategoryID, r.ID, date)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		saved += n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return saved, nil
+}
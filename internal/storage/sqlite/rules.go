@@ -0,0 +1,154 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kochnevns/finances-backend/internal/models"
+	"github.com/kochnevns/finances-backend/internal/storage"
+)
+
+const ruleColumns = `r.id, r.user_id, r.category_id, COALESCE(c.name, ''), COALESCE(c.color, ''), r.priority,
+	r.contains, r.pattern, r.min_amount, r.max_amount, r.weekdays`
+
+const ruleFrom = " FROM CategorizationRules r LEFT JOIN Categories c ON r.category_id = c.id"
+
+func scanRule(row scanner) (models.CategorizationRule, error) {
+	var r models.CategorizationRule
+	var weekdays int
+	err := row.Scan(
+		&r.ID, &r.UserID, &r.CategoryID, &r.Category, &r.Color, &r.Priority,
+		&r.Contains, &r.Pattern, &r.MinAmount, &r.MaxAmount, &weekdays,
+	)
+
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if weekdays&(1<<d) != 0 {
+			r.Weekdays = append(r.Weekdays, d)
+		}
+	}
+
+	return r, err
+}
+
+// weekdayMask packs weekdays into the weekdays column.
+func weekdayMask(days []time.Weekday) int {
+	mask := 0
+	for _, d := range days {
+		mask |= 1 << d
+	}
+
+	return mask
+}
+
+// CreateRule stores the rule with the category named in it and returns its ID.
+func (s *Storage) CreateRule(ctx context.Context, r models.CategorizationRule) (int64, error) {
+	const op = "storage.sqlite.CreateRule"
+
+	categoryID, err := writableCategory(ctx, s.db, r.UserID, r.Category)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO CategorizationRules (user_id, category_id, priority, contains, pattern, min_amount, max_amount, weekdays)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.UserID, categoryID, r.Priority, r.Contains, r.Pattern, r.MinAmount, r.MaxAmount, weekdayMask(r.Weekdays),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// UpdateRule overwrites the rule, with the category named in it.
+func (s *Storage) UpdateRule(ctx context.Context, r models.CategorizationRule) error {
+	const op = "storage.sqlite.UpdateRule"
+
+	current, err := recordCategory(ctx, s.db, "CategorizationRules", r.UserID, r.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	categoryID, err := writableCategory(ctx, s.db, r.UserID, r.Category, current)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE CategorizationRules
+		SET category_id = ?, priority = ?, contains = ?, pattern = ?, min_amount = ?, max_amount = ?, weekdays = ?
+		WHERE id = ? AND user_id = ?`,
+		categoryID, r.Priority, r.Contains, r.Pattern, r.MinAmount, r.MaxAmount, weekdayMask(r.Weekdays), r.ID, r.UserID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return expectAffected(op, res, storage.ErrRuleNotFound)
+}
+
+func (s *Storage) GetRule(ctx context.Context, userID int64, id int64) (models.CategorizationRule, error) {
+	const op = "storage.sqlite.GetRule"
+
+	r, err := scanRule(s.db.QueryRowContext(ctx,
+		"SELECT "+ruleColumns+ruleFrom+" WHERE r.id = ? AND r.user_id = ?", id, userID,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.CategorizationRule{}, fmt.Errorf("%s: %w", op, storage.ErrRuleNotFound)
+		}
+
+		return models.CategorizationRule{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return r, nil
+}
+
+// ListRules returns the rules of the user in the order they are tried.
+func (s *Storage) ListRules(ctx context.Context, userID int64) ([]models.CategorizationRule, error) {
+	const op = "storage.sqlite.ListRules"
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+ruleColumns+ruleFrom+" WHERE r.user_id = ? ORDER BY r.priority DESC, r.id", userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close() // nolint: errcheck
+
+	var list []models.CategorizationRule
+	for rows.Next() {
+		r, err := scanRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		list = append(list, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return list, nil
+}
+
+func (s *Storage) DeleteRule(ctx context.Context, userID int64, id int64) error {
+	const op = "storage.sqlite.DeleteRule"
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM CategorizationRules WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return expectAffected(op, res, storage.ErrRuleNotFound)
+}
@@ -0,0 +1,200 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/kochnevns/finances-backend/internal/models"
+	"github.com/kochnevns/finances-backend/internal/storage"
+)
+
+// debts has a row per amount owed, aliased e like the Expenses table for
+// the currency conversion: the shares of live shared expenses other than
+// the payer's own, owed by the participant to the payer, and settlements,
+// which the payer is owed back by the one paid.
+const debts = `(
+	SELECT x.user_id, x.date, x.currency, s.amount, s.person AS debtor, h.paid_by AS creditor
+	FROM Expenses x
+	JOIN SharedExpenses h ON h.expense_id = x.id
+	JOIN ExpenseShares s ON s.expense_id = x.id
+	WHERE x.deleted_at IS NULL AND s.person != h.paid_by
+	UNION ALL
+	SELECT user_id, date, currency, amount, to_person, from_person
+	FROM Settlements
+) e`
+
+// setExpenseSharing replaces how the expense is shared. Nil sharing makes
+// it an expense of the user alone. The shares are checked by the caller.
+func setExpenseSharing(ctx context.Context, tx *sql.Tx, expenseID int64, sharing *models.Sharing) error {
+	for _, table := range []string{"SharedExpenses", "ExpenseShares"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE expense_id = ?", expenseID); err != nil {
+			return err
+		}
+	}
+
+	if sharing == nil {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO SharedExpenses (expense_id, paid_by, method) VALUES (?, ?, ?)", expenseID, sharing.PaidBy, sharing.Method,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, share := range sharing.Shares {
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO ExpenseShares (expense_id, person, percent, amount) VALUES (?, ?, ?, ?)",
+			expenseID, share.Person, share.Percent, share.Amount,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// expenseSharing returns how the expense is shared, nil when it is not.
+func (s *Storage) expenseSharing(ctx context.Context, expenseID int64) (*models.Sharing, error) {
+	var sharing models.Sharing
+
+	err := s.db.QueryRowContext(ctx,
+		"SELECT paid_by, method FROM SharedExpenses WHERE expense_id = ?", expenseID,
+	).Scan(&sharing.PaidBy, &sharing.Method)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT person, percent, amount FROM ExpenseShares WHERE expense_id = ? ORDER BY id", expenseID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close() // nolint: errcheck
+
+	for rows.Next() {
+		var share models.Share
+		if err := rows.Scan(&share.Person, &share.Percent, &share.Amount); err != nil {
+			return nil, err
+		}
+		sharing.Shares = append(sharing.Shares, share)
+	}
+
+	return &sharing, rows.Err()
+}
+
+func (s *Storage) SaveSettlement(ctx context.Context, settlement models.Settlement) (int64, error) {
+	const op = "storage.sqlite.SaveSettlement"
+
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO Settlements (user_id, date, from_person, to_person, amount, currency, note)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		settlement.UserID, settlement.Date, settlement.From, settlement.To, settlement.Amount, settlement.Currency, settlement.Note,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (s *Storage) DeleteSettlement(ctx context.Context, userID int64, id int64) error {
+	const op = "storage.sqlite.DeleteSettlement"
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM Settlements WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return expectAffected(op, res, storage.ErrSettlementNotFound)
+}
+
+// ListSettlements returns the settlements of the user, the latest first.
+func (s *Storage) ListSettlements(ctx context.Context, userID int64) ([]models.Settlement, error) {
+	const op = "storage.sqlite.ListSettlements"
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, date(date), from_person, to_person, amount, currency, note
+		FROM Settlements WHERE user_id = ?
+		ORDER BY date(date) DESC, id DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close() // nolint: errcheck
+
+	var settlements []models.Settlement
+	for rows.Next() {
+		var st models.Settlement
+		err := rows.Scan(&st.ID, &st.UserID, &st.Date, &st.From, &st.To, &st.Amount, &st.Currency, &st.Note)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		settlements = append(settlements, st)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return settlements, nil
+}
+
+// Debts sums what each person owes each other one over shared expenses
+// and settlements, converted to the base currency at the rate on their
+// dates. Debts in both directions between two people are both returned.
+func (s *Storage) Debts(ctx context.Context, userID int64, base string) ([]models.Debt, error) {
+	const op = "storage.sqlite.Debts"
+
+	var w where
+	w.add("e.user_id = ?", userID)
+
+	if err := s.checkRatesIn(ctx, op, debts, w, base); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT e.debtor, e.creditor, sum(`+inBaseCurrency+`)
+		FROM `+debts+`
+		WHERE `+w.String()+`
+		GROUP BY e.debtor, e.creditor
+		ORDER BY e.debtor, e.creditor`,
+		append([]any{base}, w.args...)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close() // nolint: errcheck
+
+	var list []models.Debt
+	for rows.Next() {
+		var d models.Debt
+		if err := rows.Scan(&d.From, &d.To, &d.Amount); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		list = append(list, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return list, nil
+}
@@ -0,0 +1,103 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+// lineItems has a row per line item of the expenses, aliased e like the
+// Expenses table: the splits of split expenses and the expense itself
+// otherwise. Reports sum it so that split expenses count per line item.
+const lineItems = `(
+	SELECT x.user_id, x.date, x.currency, x.deleted_at, s.category_id, s.amount
+	FROM Expenses x JOIN ExpenseSplits s ON s.expense_id = x.id
+	UNION ALL
+	SELECT x.user_id, x.date, x.currency, x.deleted_at, x.category_id, x.amount
+	FROM Expenses x WHERE NOT EXISTS (SELECT 1 FROM ExpenseSplits s WHERE s.expense_id = x.id)
+) e`
+
+// setExpenseSplits replaces the line items of the expense with the ones
+// given, each with the category named in it. No splits make it a single
+// item expense again. The amounts are checked by the caller.
+func setExpenseSplits(ctx context.Context, tx *sql.Tx, userID int64, expenseID int64, splits []models.Split) error {
+	// The expense may keep line items in the archived categories it is in.
+	current, err := expenseCategories(ctx, tx, expenseID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM ExpenseSplits WHERE expense_id = ?", expenseID); err != nil {
+		return err
+	}
+
+	for _, split := range splits {
+		id, err := writableCategory(ctx, tx, userID, split.Category, current...)
+		if err != nil {
+			return fmt.Errorf("line item %q: %w", split.Category, err)
+		}
+
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO ExpenseSplits (expense_id, category_id, amount) VALUES (?, ?, ?)", expenseID, id, split.Amount,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// expenseCategories returns the categories the expense and its line items are in.
+func expenseCategories(ctx context.Context, tx *sql.Tx, expenseID int64) ([]int64, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT category_id FROM Expenses WHERE id = ?
+		UNION SELECT category_id FROM ExpenseSplits WHERE expense_id = ?`,
+		expenseID, expenseID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close() // nolint: errcheck
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// expenseSplits returns the line items of the expense, the largest first.
+func (s *Storage) expenseSplits(ctx context.Context, expenseID int64) ([]models.Split, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT s.category_id, COALESCE(c.name, ''), COALESCE(c.color, ''), s.amount
+		FROM ExpenseSplits s LEFT JOIN Categories c ON s.category_id = c.id
+		WHERE s.expense_id = ?
+		ORDER BY s.amount DESC, s.id`,
+		expenseID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close() // nolint: errcheck
+
+	var splits []models.Split
+	for rows.Next() {
+		var split models.Split
+		if err := rows.Scan(&split.CategoryID, &split.Category, &split.Color, &split.Amount); err != nil {
+			return nil, err
+		}
+		splits = append(splits, split)
+	}
+
+	return splits, rows.Err()
+}
@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/mattn/go-sqlite3"
 
@@ -19,10 +21,22 @@ type Storage struct {
 	db *sql.DB
 }
 
+// driverName is the sqlite3 driver extended with the functions the queries
+// rely on: casefold lowercases non-ASCII text, unlike the built-in lower.
+const driverName = "sqlite3_finances"
+
+func init() {
+	sql.Register(driverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("casefold", strings.ToLower, true)
+		},
+	})
+}
+
 func New(storagePath string) (*Storage, error) {
 	const op = "storage.sqlite.New"
 
-	db, err := sql.Open("sqlite3", storagePath)
+	db, err := sql.Open(driverName, storagePath)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -34,9 +48,11 @@ func (s *Storage) Stop() error {
 	return s.db.Close()
 }
 
-func (s *Storage) GetCategoryById(_ context.Context, id int64) (*models.Category, error) {
+const categoryColumns = "id, user_id, name, COALESCE(icon, ''), COALESCE(color, ''), archived_at IS NOT NULL"
+
+func (s *Storage) GetCategoryById(ctx context.Context, userID int64, id int64) (*models.Category, error) {
 	const op = "storage.sqlite.GetCategoryById"
-	stmt, err := s.db.Prepare("SELECT id, name FROM Categories WHERE id =?")
+	stmt, err := s.db.Prepare("SELECT " + categoryColumns + " FROM Categories WHERE id =? AND user_id =?")
 
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
@@ -46,61 +62,120 @@ func (s *Storage) GetCategoryById(_ context.Context, id int64) (*models.Category
 
 	var category models.Category
 
-	err = stmt.QueryRow(id).Scan(&category.ID, &category.Name)
+	err = stmt.QueryRowContext(ctx, id, userID).Scan(
+		&category.ID, &category.UserID, &category.Name, &category.ImageURL, &category.Color, &category.Archived,
+	)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, sql.ErrNoRows
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrCategoryNotFound)
 		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return &category, nil
 }
 
-func (s *Storage) GetCategoryByName(_ context.Context, name string) (*models.Category, error) {
+func (s *Storage) GetCategoryByName(ctx context.Context, userID int64, name string) (*models.Category, error) {
 	const op = "storage.sqlite.GetCategoryByName"
-	stmt, err := s.db.Prepare("SELECT id, name FROM Categories WHERE name =?")
+	stmt, err := s.db.Prepare("SELECT " + categoryColumns + " FROM Categories WHERE name =? AND user_id =?")
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	defer stmt.Close()
 	var category models.Category
-	err = stmt.QueryRow(name).Scan(&category.ID, &category.Name)
+	err = stmt.QueryRowContext(ctx, name, userID).Scan(
+		&category.ID, &category.UserID, &category.Name, &category.ImageURL, &category.Color, &category.Archived,
+	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, sql.ErrNoRows
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrCategoryNotFound)
 		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	return &category, nil
 }
 
-func (s *Storage) ListCategoriesReport(ctx context.Context, filter string, month int, year int) ([]models.CategoryReport, error) {
-	const op = "storage.sqlite.ListCategoriesReport"
-	strMonth := fmt.Sprintf("%02d", month)
-	strYear := fmt.Sprintf("%04d", year)
+func (s *Storage) GetExpense(ctx context.Context, userID int64, id int64) (models.Expense, error) {
+	const op = "storage.sqlite.GetExpense"
+
+	stmt, err := s.db.Prepare(`
+		SELECT e.id, e.user_id, date(e.date), COALESCE(e.description, ''), e.amount, e.currency, e.category_id,
+			COALESCE(c.name, ''), COALESCE(c.color, ''), ` + expenseTags + `, ` + expenseAccount + `
+		FROM Expenses e LEFT JOIN Categories c ON e.category_id = c.id
+		WHERE e.id = ? AND e.user_id = ? AND e.deleted_at IS NULL`)
+	if err != nil {
+		return models.Expense{}, fmt.Errorf("%s: %w", op, err)
+	}
 
-	sql := `
-	SELECT sum(amount) AS cat_amount, Categories.name as cat_name, Categories.color as color
-	FROM Expenses JOIN Categories ON Expenses.category_id = Categories.id
-	WHERE strftime('%m', date) = $1 AND strftime('%Y', date) = $2
-	GROUP BY Categories.name;`
+	defer stmt.Close() // nolint: errcheck
 
-	stmt, err := s.db.Prepare(sql)
+	var expense models.Expense
+	var tags string
 
+	err = stmt.QueryRowContext(ctx, id, userID).Scan(
+		&expense.ID, &expense.UserID, &expense.Date, &expense.Description, &expense.Amount, &expense.Currency,
+		&expense.CategoryID, &expense.Category, &expense.Color, &tags, &expense.Account,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Expense{}, fmt.Errorf("%s: %w", op, storage.ErrExpenseNotFound)
+		}
+
+		return models.Expense{}, fmt.Errorf("%s: %w", op, err)
 	}
+	expense.Tags = splitTags(tags)
 
-	var categories []models.CategoryReport
-	rows, err := stmt.QueryContext(ctx, strMonth, strYear)
+	if expense.Splits, err = s.expenseSplits(ctx, expense.ID); err != nil {
+		return models.Expense{}, fmt.Errorf("%s: %w", op, err)
+	}
 
+	if expense.Sharing, err = s.expenseSharing(ctx, expense.ID); err != nil {
+		return models.Expense{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return expense, nil
+}
+
+// periodWhere selects the user's expenses dated within [from, to].
+func periodWhere(userID int64, from, to time.Time) where {
+	var w where
+	w.add("e.user_id = ?", userID)
+	w.add("date(e.date) BETWEEN ? AND ?", from.Format(time.DateOnly), to.Format(time.DateOnly))
+	w.add("e.deleted_at IS NULL")
+
+	return w
+}
+
+// ListCategoriesReport sums expenses per category for dates in [from, to],
+// converted to the base currency. Split expenses count per line item.
+func (s *Storage) ListCategoriesReport(ctx context.Context, userID int64, from, to time.Time, base string) ([]models.CategoryReport, error) {
+	const op = "storage.sqlite.ListCategoriesReport"
+
+	w := periodWhere(userID, from, to)
+	if err := s.checkRates(ctx, op, w, base); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT sum(`+inBaseCurrency+`) AS cat_amount, c.id, c.name as cat_name, COALESCE(c.color, '') as color
+	FROM `+lineItems+` JOIN Categories c ON e.category_id = c.id
+	WHERE `+w.String()+`
+	GROUP BY c.id;`,
+		append([]any{base}, w.args...)...,
+	)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	defer rows.Close() // nolint: errcheck
+
+	var categories []models.CategoryReport
 	for rows.Next() {
 		var category models.CategoryReport
-		err = rows.Scan(&category.Amount, &category.Name, &category.Color)
+		err = rows.Scan(&category.Amount, &category.CategoryID, &category.Name, &category.Color)
 
 		if err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
@@ -111,23 +186,22 @@ func (s *Storage) ListCategoriesReport(ctx context.Context, filter string, month
 	return categories, nil
 }
 
-func (s *Storage) Total(filter string, month int, year int) (int64, error) {
+// Total sums expenses for dates in [from, to], converted to the base currency
+// per line item, so that it adds up with ListCategoriesReport.
+func (s *Storage) Total(ctx context.Context, userID int64, from, to time.Time, base string) (int64, error) {
 	const op = "storage.sqlite.TotalAmount"
 
-	strMonth := fmt.Sprintf("%02d", month)
-	strYear := fmt.Sprintf("%04d", year)
-
-	sql := `SELECT COALESCE(sum(amount), 0) FROM Expenses WHERE strftime('%m', date) = $1 AND strftime('%Y', date) = $2`
-
-	stmt, err := s.db.Prepare(sql)
-	if err != nil {
-		return 0, fmt.Errorf("%s: %w", op, err)
+	w := periodWhere(userID, from, to)
+	if err := s.checkRates(ctx, op, w, base); err != nil {
+		return 0, err
 	}
-	defer stmt.Close() // nolint: errcheck
 
 	var totalAmount int64
 
-	err = stmt.QueryRow(strMonth, strYear).Scan(&totalAmount)
+	err := s.db.QueryRowContext(ctx,
+		"SELECT COALESCE(sum("+inBaseCurrency+"), 0) FROM "+lineItems+" WHERE "+w.String(),
+		append([]any{base}, w.args...)...,
+	).Scan(&totalAmount)
 
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
@@ -136,28 +210,33 @@ func (s *Storage) Total(filter string, month int, year int) (int64, error) {
 	return totalAmount, nil
 }
 
-func (s *Storage) MedianAndMiddle(ctx context.Context, month int, year int) (int64, int64, error) {
+// MedianAndMiddle returns the average and the median of daily totals over
+// the days in [from, to] that have expenses, in the base currency converted
+// per line item.
+func (s *Storage) MedianAndMiddle(ctx context.Context, userID int64, from, to time.Time, base string) (int64, int64, error) {
 	const op = "storage.sqlite.Median"
 
 	var sum int
 	var rowsCount int
 
-	strMonth := fmt.Sprintf("%02d", month)
-	strYear := fmt.Sprintf("%04d", year)
-
-	sql := `SELECT sum(amount) AS day_amount, strftime('%d', date) as day
-	FROM Expenses
-	WHERE strftime('%m', date) = $1 AND strftime('%Y', date) = $2
-	GROUP BY day;`
-
-	stmt, _ := s.db.Prepare(sql)
+	w := periodWhere(userID, from, to)
+	if err := s.checkRates(ctx, op, w, base); err != nil {
+		return -1, -1, err
+	}
 
-	rows, err := stmt.QueryContext(ctx, strMonth, strYear)
+	rows, err := s.db.QueryContext(ctx, `SELECT sum(`+inBaseCurrency+`) AS day_amount, date(e.date) as day
+	FROM `+lineItems+`
+	WHERE `+w.String()+`
+	GROUP BY day;`,
+		append([]any{base}, w.args...)...,
+	)
 
 	if err != nil {
 		return -1, -1, fmt.Errorf("%s: %w", op, err)
 	}
 
+	defer rows.Close() // nolint: errcheck
+
 	amounts := make([]int, 0)
 	for rows.Next() {
 		var daily models.DailyStats
@@ -185,139 +264,319 @@ func (s *Storage) MedianAndMiddle(ctx context.Context, month int, year int) (int
 	return middle, int64(amounts[len(amounts)/2]), nil
 }
 
-func (s *Storage) SaveExpense(ctx context.Context, expense models.Expense) error {
+// SaveExpense inserts the expense with its details and returns its ID.
+func (s *Storage) SaveExpense(ctx context.Context, expense models.Expense, details models.ExpenseDetails) (int64, error) {
 	const op = "storage.sqlite.SaveExpense"
 
-	category, _ := s.GetCategoryByName(ctx, expense.Category)
-
-	if category == nil {
-		category = &models.Category{
-			ID:       0,
-			Name:     "TEST",
-			ImageURL: "",
-		}
+	categoryID, err := writableCategory(ctx, s.db, expense.UserID, expense.Category)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	stmt, err := s.db.Prepare("INSERT INTO Expenses(date, description, amount, category_id) VALUES(?,?,?,?)")
+	accountID, err := s.accountID(ctx, expense.UserID, expense.Account)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
 
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
 	}
+	defer tx.Rollback() // nolint: errcheck
 
-	_, err = stmt.ExecContext(ctx, expense.Date, expense.Description, expense.Amount, category.ID)
+	res, err := tx.ExecContext(ctx,
+		"INSERT INTO Expenses(user_id, date, description, amount, currency, category_id, account_id) VALUES(?,?,?,?,?,?,?)",
+		expense.UserID, expense.Date, expense.Description, expense.Amount, expense.Currency, categoryID, accountID,
+	)
 	if err != nil {
 		var sqliteErr sqlite3.Error
 		if errors.As(err, &sqliteErr) && errors.Is(sqliteErr.ExtendedCode, sqlite3.ErrConstraintUnique) {
-			return fmt.Errorf("%s: %w", op, storage.ErrUserExists)
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrUserExists)
 		}
 
-		return fmt.Errorf("%s: %w", op, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return nil
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := setExpenseDetails(ctx, tx, expense.UserID, id, details); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
 }
 
-func (s *Storage) UpdateExpense(ctx context.Context, expense models.Expense) error {
-	const op = "storage.sqlite.SaveExpense"
+// UpdateExpense overwrites the live expense and its details.
+func (s *Storage) UpdateExpense(ctx context.Context, expense models.Expense, details models.ExpenseDetails) error {
+	const op = "storage.sqlite.UpdateExpense"
+
+	current, err := recordCategory(ctx, s.db, "Expenses", expense.UserID, expense.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
-	category, _ := s.GetCategoryByName(ctx, expense.Category)
+	categoryID, err := writableCategory(ctx, s.db, expense.UserID, expense.Category, current)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
-	if category == nil {
-		category = &models.Category{
-			ID:       0,
-			Name:     "TEST",
-			ImageURL: "",
-		}
+	accountID, err := s.accountID(ctx, expense.UserID, expense.Account)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	stmt, err := s.db.Prepare(`
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	res, err := tx.ExecContext(ctx, `
 		UPDATE Expenses
 		SET date=?,
 		description=?,
-		amount=?, category_id=?
-		WHERE id=?;
-	`)
-
+		amount=?, currency=?, category_id=?, account_id=?
+		WHERE id=? AND user_id=? AND deleted_at IS NULL;
+	`, expense.Date, expense.Description, expense.Amount, expense.Currency, categoryID, accountID, expense.ID, expense.UserID)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	fmt.Println(expense)
+	if err := expectAffected(op, res, storage.ErrExpenseNotFound); err != nil {
+		return err
+	}
 
-	_, err = stmt.ExecContext(ctx, expense.Date, expense.Description, expense.Amount, category.ID, expense.ID)
-	if err != nil {
-		var sqliteErr sqlite3.Error
-		if errors.As(err, &sqliteErr) && errors.Is(sqliteErr.ExtendedCode, sqlite3.ErrConstraintUnique) {
-			return fmt.Errorf("%s: %w", op, storage.ErrUserExists)
+	if err := setExpenseDetails(ctx, tx, expense.UserID, expense.ID, details); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// setExpenseDetails writes the details of the expense given in them.
+func setExpenseDetails(ctx context.Context, tx *sql.Tx, userID int64, expenseID int64, details models.ExpenseDetails) error {
+	if details.Tags != nil {
+		if err := setExpenseTags(ctx, tx, userID, expenseID, details.Tags); err != nil {
+			return err
+		}
+	}
+
+	if details.Splits != nil {
+		if err := setExpenseSplits(ctx, tx, userID, expenseID, details.Splits); err != nil {
+			return err
+		}
+	}
+
+	if details.Reshare {
+		if err := setExpenseSharing(ctx, tx, expenseID, details.Sharing); err != nil {
+			return err
 		}
+	}
+
+	return nil
+}
 
+// expectAffected returns notFound wrapped with op when the statement touched no rows.
+func expectAffected(op string, res sql.Result, notFound error) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, notFound)
+	}
+
 	return nil
 }
 
-func (s *Storage) ListExpenses(ctx context.Context, category string, month, year int64) ([]models.Expense, int, error) {
+// ListExpenses returns the expenses matching the filter together with the
+// total amount of all matching expenses, regardless of paging, converted to
+// the base currency.
+func (s *Storage) ListExpenses(ctx context.Context, filter models.ExpensesFilter) ([]models.Expense, int, error) {
 	const op = "storage.sqlite.ListExpenses"
-	sql := `
-	SELECT e.id as id, date(date) as date, description, amount, category_id, c.color, $1, $2
-	FROM Expenses e JOIN Categories c on e.category_id = c.id
-	WHERE date(date) IS NOT NULL AND strftime('%m', date) = strftime('%m', 'now') AND strftime('%Y', date) = strftime('%Y', 'now')
-	ORDER BY date DESC
-	`
-	total := 0
 
-	if category != "" {
-		sql = fmt.Sprintf(`
-		SELECT e.id as id, date(date) as date, description, amount, category_id, c.color, '1', '2'
-		FROM Expenses e JOIN Categories c on e.category_id = c.id
-		WHERE date(date) IS NOT NULL AND category_id = 
-		(SELECT id FROM Categories WHERE name = '%s')
-		`, category)
+	w := expensesWhere(filter)
 
-		sql += `AND strftime('%m', date) = $1 AND strftime('%Y', date) = $2
-		ORDER BY date DESC`
+	if err := s.checkRates(ctx, op, w, filter.BaseCurrency); err != nil {
+		return nil, 0, err
 	}
-	stmt, err := s.db.Prepare(sql) // nolint: errcheck, gosec
+
+	total := 0
+	err := s.db.QueryRowContext(ctx,
+		"SELECT COALESCE(sum("+inBaseCurrency+"), 0) FROM Expenses e JOIN Categories c on e.category_id = c.id WHERE "+w.String(),
+		append([]any{filter.BaseCurrency}, w.args...)...,
+	).Scan(&total)
 	if err != nil {
 		return nil, 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	defer stmt.Close() // nolint: errcheck
+	// sortKey, direction and cmp only take the fixed values below, never input.
+	sortKey := "date(e.date)"
+	if filter.SortBy == models.SortByAmount {
+		sortKey = "e.amount"
+	}
 
-	var expenses []models.Expense
-	rows, err := stmt.Query(fmt.Sprintf("%02d", month), fmt.Sprintf("%04d", year)) // nolint: errcheck
+	direction, cmp := "DESC", "<"
+	if filter.Ascending {
+		direction, cmp = "ASC", ">"
+	}
+
+	if filter.After != nil {
+		var key any = filter.After.Date
+		if filter.SortBy == models.SortByAmount {
+			key = filter.After.Amount
+		}
+
+		w.add(fmt.Sprintf("(%s, e.id) %s (?, ?)", sortKey, cmp), key, filter.After.ID)
+	}
 
+	query := `SELECT e.id, e.user_id, date(e.date), COALESCE(e.description, ''), e.amount, e.currency, e.category_id, c.name, COALESCE(c.color, ''), ` +
+		expenseTags + `, ` + expenseAccount + `
+	FROM Expenses e JOIN Categories c on e.category_id = c.id WHERE ` + w.String() +
+		fmt.Sprintf(" ORDER BY %s %s, e.id %s", sortKey, direction, direction)
+
+	args := w.args
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("%s: %w", op, err)
 	}
 
 	defer rows.Close() // nolint: errcheck
 
+	var expenses []models.Expense
 	for rows.Next() {
 		var expense models.Expense
-		var dummy string
-		err = rows.Scan(&expense.ID, &expense.Date, &expense.Description, &expense.Amount, &expense.CategoryID, &expense.Color, &dummy, &dummy)
+		var tags string
+		err = rows.Scan(
+			&expense.ID, &expense.UserID, &expense.Date, &expense.Description, &expense.Amount, &expense.Currency,
+			&expense.CategoryID, &expense.Category, &expense.Color, &tags, &expense.Account,
+		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("%s: %w", op, err)
 		}
-		cat, _ := s.GetCategoryById(ctx, expense.CategoryID)
-		total += int(expense.Amount)
-		expense.Category = cat.Name
+		expense.Tags = splitTags(tags)
 		expenses = append(expenses, expense)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
 	return expenses, total, nil
 }
-func (s *Storage) ListCategories(ctx context.Context) ([]models.Category, error) {
+
+// EachExpense calls fn with every expense matching the filter, oldest
+// first, reading them one at a time. Ordering and paging of the filter are
+// ignored. An error returned by fn stops the iteration and is returned.
+func (s *Storage) EachExpense(ctx context.Context, filter models.ExpensesFilter, fn func(models.Expense) error) error {
+	const op = "storage.sqlite.EachExpense"
+
+	w := expensesWhere(filter)
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT e.id, e.user_id, date(e.date), COALESCE(e.description, ''), e.amount, e.currency, e.category_id, c.name, COALESCE(c.color, ''), `+
+			expenseTags+`, `+expenseAccount+`
+		FROM Expenses e JOIN Categories c on e.category_id = c.id WHERE `+w.String()+" ORDER BY date(e.date), e.id",
+		w.args...,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close() // nolint: errcheck
+
+	for rows.Next() {
+		var expense models.Expense
+		var tags string
+		err = rows.Scan(
+			&expense.ID, &expense.UserID, &expense.Date, &expense.Description, &expense.Amount, &expense.Currency,
+			&expense.CategoryID, &expense.Category, &expense.Color, &tags, &expense.Account,
+		)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		expense.Tags = splitTags(tags)
+
+		if err := fn(expense); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// expensesWhere selects the live expenses matching the filter, ignoring its
+// ordering and paging. Expenses are aliased e and their categories c.
+func expensesWhere(filter models.ExpensesFilter) where {
+	var w where
+	w.add("e.user_id = ?", filter.UserID)
+	w.add("date(e.date) IS NOT NULL")
+	w.add("e.deleted_at IS NULL")
+	taggedWith(&w, filter.Tags)
+
+	// Categories given by name and by ID make up one set.
+	var categories where
+	in(&categories, "c.name", filter.Categories)
+	in(&categories, "e.category_id", filter.CategoryIDs)
+	w.addAny(categories)
+
+	if !filter.From.IsZero() {
+		w.add("date(e.date) >= ?", filter.From.Format(time.DateOnly))
+	}
+	if !filter.To.IsZero() {
+		w.add("date(e.date) <= ?", filter.To.Format(time.DateOnly))
+	}
+	if filter.MinAmount != 0 {
+		w.add("e.amount >= ?", filter.MinAmount)
+	}
+	if filter.MaxAmount != 0 {
+		w.add("e.amount <= ?", filter.MaxAmount)
+	}
+	for _, word := range filter.Search {
+		w.add(`casefold(COALESCE(e.description, '')) LIKE ? ESCAPE '\'`, "%"+escapeLike(strings.ToLower(word))+"%")
+	}
+
+	return w
+}
+
+// escapeLike escapes LIKE wildcards so that s is matched literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (s *Storage) ListCategories(ctx context.Context, userID int64) ([]models.Category, error) {
 	const op = "storage.sqlite.CategoriesList"
 	stmt, err := s.db.Prepare(`
-		SELECT DISTINCT c.id as id, c.name as name FROM Categories c JOIN (
+		SELECT c.id as id, c.user_id, c.name as name, COALESCE(c.icon, ''), COALESCE(c.color, '')
+		FROM Categories c LEFT JOIN (
 			SELECT category_id, count(category_id) as cnt From Expenses e
+			WHERE e.deleted_at IS NULL
 			GROUP BY e.category_id
 		) e
 		ON c.id = e.category_id
-		ORDER BY e.cnt DESC`)
+		WHERE c.archived_at IS NULL AND c.user_id = ?
+		ORDER BY COALESCE(e.cnt, 0) DESC, c.name`)
 
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
@@ -326,7 +585,7 @@ func (s *Storage) ListCategories(ctx context.Context) ([]models.Category, error)
 	defer stmt.Close()
 
 	var categories []models.Category
-	rows, err := stmt.QueryContext(ctx) // nolint: errcheck
+	rows, err := stmt.QueryContext(ctx, userID) // nolint: errcheck
 
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
@@ -336,7 +595,7 @@ func (s *Storage) ListCategories(ctx context.Context) ([]models.Category, error)
 
 	for rows.Next() {
 		var category models.Category
-		err = rows.Scan(&category.ID, &category.Name)
+		err = rows.Scan(&category.ID, &category.UserID, &category.Name, &category.ImageURL, &category.Color)
 		if err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
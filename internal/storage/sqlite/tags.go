@@ -0,0 +1,141 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kochnevns/finances-backend/internal/models"
+)
+
+// expenseTags selects the comma-separated tags of the expense e. Tag names
+// never contain commas.
+const expenseTags = `COALESCE((SELECT group_concat(t.name) FROM ExpenseTags et JOIN Tags t ON et.tag_id = t.id
+	WHERE et.expense_id = e.id), '')`
+
+// splitTags parses the expenseTags column.
+func splitTags(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	tags := strings.Split(s, ",")
+	slices.Sort(tags)
+
+	return tags
+}
+
+// taggedWith selects the expenses e with any of the tags.
+func taggedWith(w *where, tags []string) {
+	if len(tags) == 0 {
+		return
+	}
+
+	var sub where
+	in(&sub, "t.name", tags)
+	w.add("e.id IN (SELECT et.expense_id FROM ExpenseTags et JOIN Tags t ON et.tag_id = t.id WHERE "+sub.String()+")",
+		sub.args...)
+}
+
+// setExpenseTags replaces the tags of the expense, creating the tags the
+// user has not used before.
+func setExpenseTags(ctx context.Context, tx *sql.Tx, userID int64, expenseID int64, tags []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM ExpenseTags WHERE expense_id = ?", expenseID); err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		if _, err := tx.ExecContext(ctx, "INSERT OR IGNORE INTO Tags (user_id, name) VALUES (?, ?)", userID, tag); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx, `
+			INSERT OR IGNORE INTO ExpenseTags (expense_id, tag_id)
+			SELECT ?, id FROM Tags WHERE user_id = ? AND name = ?`,
+			expenseID, userID, tag,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ListTags returns the tags on live expenses of the user, the most used
+// first.
+func (s *Storage) ListTags(ctx context.Context, userID int64) ([]models.Tag, error) {
+	const op = "storage.sqlite.ListTags"
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT t.name, count(*) FROM Tags t
+		JOIN ExpenseTags et ON et.tag_id = t.id
+		JOIN Expenses e ON et.expense_id = e.id AND e.deleted_at IS NULL
+		WHERE t.user_id = ?
+		GROUP BY t.id
+		ORDER BY count(*) DESC, t.name`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close() // nolint: errcheck
+
+	var tags []models.Tag
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.Name, &tag.Expenses); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		tags = append(tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tags, nil
+}
+
+// TagsReport sums expenses per tag for dates in [from, to], converted to the
+// base currency, the largest first.
+func (s *Storage) TagsReport(ctx context.Context, userID int64, from, to time.Time, base string) ([]models.TagReport, error) {
+	const op = "storage.sqlite.TagsReport"
+
+	const tagged = "Expenses e JOIN ExpenseTags et ON et.expense_id = e.id JOIN Tags t ON et.tag_id = t.id"
+
+	w := periodWhere(userID, from, to)
+	if err := s.checkRatesIn(ctx, op, tagged, w, base); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT t.name, sum("+inBaseCurrency+"), count(*) FROM "+tagged+" WHERE "+w.String()+
+			" GROUP BY t.id ORDER BY 2 DESC, t.name",
+		append([]any{base}, w.args...)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close() // nolint: errcheck
+
+	var report []models.TagReport
+	for rows.Next() {
+		var r models.TagReport
+		if err := rows.Scan(&r.Tag, &r.Amount, &r.Expenses); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		report = append(report, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,122 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kochnevns/finances-backend/internal/models"
+	"github.com/kochnevns/finances-backend/internal/storage"
+)
+
+// DeleteExpense moves the expense to the trash by setting its deleted_at mark.
+func (s *Storage) DeleteExpense(ctx context.Context, userID int64, id int64, at time.Time) error {
+	const op = "storage.sqlite.DeleteExpense"
+
+	res, err := s.db.ExecContext(ctx,
+		"UPDATE Expenses SET deleted_at = ? WHERE id = ? AND user_id = ? AND deleted_at IS NULL",
+		at.UTC().Format(time.RFC3339), id, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return expectAffected(op, res, storage.ErrExpenseNotFound)
+}
+
+// RestoreExpense takes the expense back out of the trash.
+func (s *Storage) RestoreExpense(ctx context.Context, userID int64, id int64) error {
+	const op = "storage.sqlite.RestoreExpense"
+
+	res, err := s.db.ExecContext(ctx,
+		"UPDATE Expenses SET deleted_at = NULL WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL",
+		id, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return expectAffected(op, res, storage.ErrExpenseNotFound)
+}
+
+// ListDeletedExpenses returns expenses deleted at or after since, most recently deleted first.
+func (s *Storage) ListDeletedExpenses(ctx context.Context, userID int64, since time.Time) ([]models.Expense, error) {
+	const op = "storage.sqlite.ListDeletedExpenses"
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT e.id, e.user_id, date(e.date), COALESCE(e.description, ''), e.amount, e.currency, e.category_id,
+			COALESCE(c.name, ''), COALESCE(c.color, ''), e.deleted_at, `+expenseTags+`, `+expenseAccount+`
+		FROM Expenses e LEFT JOIN Categories c ON e.category_id = c.id
+		WHERE e.user_id = ? AND e.deleted_at IS NOT NULL AND e.deleted_at >= ?
+		ORDER BY e.deleted_at DESC`,
+		userID, since.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close() // nolint: errcheck
+
+	var expenses []models.Expense
+	for rows.Next() {
+		var expense models.Expense
+		var tags string
+		err = rows.Scan(
+			&expense.ID, &expense.UserID, &expense.Date, &expense.Description, &expense.Amount, &expense.Currency,
+			&expense.CategoryID, &expense.Category, &expense.Color, &expense.DeletedAt, &tags, &expense.Account,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		expense.Tags = splitTags(tags)
+		expenses = append(expenses, expense)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return expenses, nil
+}
+
+// PurgeDeletedExpenses permanently removes expenses of all users deleted
+// before the given time, together with their tags, line items and shares.
+func (s *Storage) PurgeDeletedExpenses(ctx context.Context, before time.Time) (int64, error) {
+	const op = "storage.sqlite.PurgeDeletedExpenses"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	cutoff := before.UTC().Format(time.RFC3339)
+
+	for _, table := range []string{"ExpenseTags", "ExpenseSplits", "SharedExpenses", "ExpenseShares"} {
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM `+table+` WHERE expense_id IN (
+				SELECT id FROM Expenses WHERE deleted_at IS NOT NULL AND deleted_at < ?
+			)`,
+			cutoff,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM Expenses WHERE deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	purged, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,46 @@
+package sqlite
+
+import "strings"
+
+// where accumulates AND-ed SQL conditions together with their bound
+// arguments, so that no filter value is ever spliced into the query text.
+type where struct {
+	conds []string
+	args  []any
+}
+
+func (w *where) add(cond string, args ...any) {
+	w.conds = append(w.conds, cond)
+	w.args = append(w.args, args...)
+}
+
+// in adds "column IN (?, ...)" for non-empty values.
+func in[T any](w *where, column string, values []T) {
+	if len(values) == 0 {
+		return
+	}
+
+	args := make([]any, 0, len(values))
+	for _, v := range values {
+		args = append(args, v)
+	}
+
+	w.add(column+" IN ("+strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")+")", args...)
+}
+
+// addAny adds a condition that holds when any of the conditions of other do.
+func (w *where) addAny(other where) {
+	if len(other.conds) == 0 {
+		return
+	}
+
+	w.add("("+strings.Join(other.conds, " OR ")+")", other.args...)
+}
+
+func (w *where) String() string {
+	if len(w.conds) == 0 {
+		return "1 = 1"
+	}
+
+	return strings.Join(w.conds, " AND ")
+}
@@ -3,7 +3,35 @@ package storage
 import "errors"
 
 var (
-	ErrUserExists   = errors.New("user already exists")
-	ErrUserNotFound = errors.New("user not found")
-	ErrAppNotFound  = errors.New("app not found")
+	ErrUserExists        = errors.New("user already exists")
+	ErrUserNotFound      = errors.New("user not found")
+	ErrAppNotFound       = errors.New("app not found")
+	ErrExpenseNotFound   = errors.New("expense not found")
+	ErrCategoryNotFound  = errors.New("category not found")
+	ErrCategoryExists    = errors.New("category already exists")
+	ErrCategoryArchived  = errors.New("category is archived")
+	ErrRateNotFound      = errors.New("exchange rate not found")
+	ErrBudgetNotFound    = errors.New("budget not found")
+	ErrRecurringNotFound = errors.New("recurring expense not found")
+
+	ErrIncomeNotFound       = errors.New("income not found")
+	ErrIncomeSourceNotFound = errors.New("income source not found")
+	ErrIncomeSourceExists   = errors.New("income source already exists")
+	ErrIncomeSourceInUse    = errors.New("income source has incomes")
+
+	ErrImportProfileNotFound = errors.New("import profile not found")
+	ErrRuleNotFound          = errors.New("categorization rule not found")
+	ErrSettlementNotFound    = errors.New("settlement not found")
+	ErrAttachmentNotFound    = errors.New("attachment not found")
+
+	ErrAccountNotFound  = errors.New("account not found")
+	ErrAccountExists    = errors.New("account already exists")
+	ErrAccountInUse     = errors.New("account has expenses, incomes or transfers")
+	ErrTransferNotFound = errors.New("transfer not found")
+
+	ErrReconciliationNotFound = errors.New("reconciliation not found")
+
+	ErrGoalNotFound         = errors.New("goal not found")
+	ErrGoalExists           = errors.New("goal already exists")
+	ErrContributionNotFound = errors.New("contribution not found")
 )